@@ -10,6 +10,7 @@ type App struct {
 	meshMgr   *NavMgr
 	octreeMgr *OctreeMgr
 	physxMgr  *PhysxMgr
+	apiServer *ApiServer
 }
 
 // NewApp creates a new App application struct
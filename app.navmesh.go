@@ -1,18 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-func (a *App) LoadNavMeshLocal(id, name, navType, filename string) error {
+func (a *App) LoadNavMeshLocal(id, name, navType string, filename FilePath) error {
 	if a.meshMgr.IsItemExists(id) {
 		return nil
 	}
 
-	data, err := os.ReadFile(filename)
+	data, err := os.ReadFile(string(filename))
 	if err != nil {
 		return err
 	}
@@ -28,30 +29,233 @@ func (a *App) RemoveNavMesh(id string) {
 	a.meshMgr.RemoveItem(id)
 }
 
-func (a *App) AddAgent(id string, x, y, z, r, h, speed, acc float32) error {
+// BuildNavMesh builds a tiled navmesh from triangles and loads it as id,
+// replacing an item with the same id. The result has the time of every
+// build stage and, with params.Debug, its intermediate geometry.
+func (a *App) BuildNavMesh(id string, params NavBuildParams, triangles []Triangle) (*NavBuildResult, error) {
+	return a.meshMgr.Build(id, params, triangles)
+}
+
+func (a *App) BuildNavMeshFromObj(id string, path FilePath, params NavBuildParams) (*NavBuildResult, error) {
+	mesh, err := LoadObj(string(path))
+	if err != nil {
+		return nil, err
+	}
+	return a.meshMgr.Build(id, params, mesh.Triangles())
+}
+
+// ExportNavMesh writes the navmesh polygons with area colors to path,
+// format is ExportFormatObj, ExportFormatGlb or ExportFormatGltf and taken
+// from the extension of path when empty.
+func (a *App) ExportNavMesh(id string, path FilePath, format string) error {
+	return a.meshMgr.Export(id, string(path), format)
+}
+
+func (a *App) GetNavOffMeshConnections(id string) ([]NavOffMeshLink, error) {
+	return a.meshMgr.GetOffMeshConnections(id)
+}
+
+// CanEditNavOffMeshConnections returns why the off-mesh connections of a
+// navmesh can't be edited, call it before starting an edit. Only built
+// navmeshes and loaded navmesh set files can be edited, the other navTypes
+// the loader accepts, like solo meshes, can't be written back.
+func (a *App) CanEditNavOffMeshConnections(id string) error {
+	return a.meshMgr.CanEditOffMeshConnections(id)
+}
+
+// AddNavOffMeshConnection adds an off-mesh connection and returns its ref,
+// it fails as CanEditNavOffMeshConnections does before changing anything.
+// SaveNavMesh writes the edited navmesh as a navmesh set file.
+func (a *App) AddNavOffMeshConnection(id string, link NavOffMeshLink) (uint64, error) {
+	return a.meshMgr.AddOffMeshConnection(id, link)
+}
+
+// MoveNavOffMeshConnection moves an off-mesh connection, its ref changes.
+func (a *App) MoveNavOffMeshConnection(id string, ref uint64, start, end Vec3) (uint64, error) {
+	return a.meshMgr.MoveOffMeshConnection(id, ref, start, end)
+}
+
+func (a *App) RemoveNavOffMeshConnection(id string, ref uint64) error {
+	return a.meshMgr.RemoveOffMeshConnection(id, ref)
+}
+
+// SetNavObstacle adds or replaces a temporary obstacle on a navmesh, the
+// agents go around it from the next UpdateAgents. The first obstacle on a
+// loaded navmesh makes its tile cache from the tile polygons.
+func (a *App) SetNavObstacle(id, obstacleId string, obstacle NavObstacle) error {
+	return a.meshMgr.SetObstacle(id, obstacleId, obstacle)
+}
+
+func (a *App) RemoveNavObstacle(id, obstacleId string) error {
+	return a.meshMgr.RemoveObstacle(id, obstacleId)
+}
+
+func (a *App) GetNavObstacles(id string) ([]NavObstacleInfo, error) {
+	return a.meshMgr.GetObstacles(id)
+}
+
+// DiffNavMesh compares navmesh idA to idB tile by tile and polygon by
+// polygon, the primitives overlay the regions in the NavMesh scene.
+func (a *App) DiffNavMesh(idA, idB string) (*NavMeshDiff, error) {
+	return a.meshMgr.Diff(idA, idB)
+}
+
+// SaveNavMesh writes a navmesh to path, a built or edited navmesh is saved
+// as a NavTypeTileMesh file and a loaded one in the navType it was loaded as.
+func (a *App) SaveNavMesh(id string, path FilePath) error {
+	return a.meshMgr.Save(id, string(path))
+}
+
+// AddAgent adds a crowd agent and returns its id for the per agent calls.
+func (a *App) AddAgent(id string, x, y, z, r, h, speed, acc float32) (uint32, error) {
 	return a.meshMgr.AddAgent(id, x, y, z, r, h, speed, acc)
 }
 
-func (a *App) UpdateAgents(id string) {
-	a.meshMgr.UpdateAgents(id)
+func (a *App) UpdateAgents(id string) error {
+	return a.meshMgr.UpdateAgents(id)
 }
 
 func (a *App) ClearAgent(id string) {
 	a.meshMgr.ClearAgent(id)
 }
 
-func (a *App) SetAgentTarget(id string, x, y, z float32) {
-	a.meshMgr.SetAgentTarget(id, x, y, z)
+func (a *App) SetAgentTarget(id string, x, y, z float32) error {
+	return a.meshMgr.SetAgentTarget(id, x, y, z)
 }
 
 func (a *App) TeleportAgent(id string, x, y, z float32) bool {
 	return a.meshMgr.TeleportAgent(id, x, y, z)
 }
 
+// FindPathNavMesh finds a path on the navmesh, the result holds the polygon
+// corridor and the straightened waypoints.
+func (a *App) FindPathNavMesh(id string, start, end Vec3, filter NavQueryFilter) (*NavPath, error) {
+	return a.meshMgr.FindPath(id, start, end, filter)
+}
+
+// FindPathNavMeshWithPreset finds a path with the filter of a preset, the
+// empty name is the default filter.
+func (a *App) FindPathNavMeshWithPreset(id string, start, end Vec3, preset string) (*NavPath, error) {
+	return a.meshMgr.FindPathWithPreset(id, start, end, preset)
+}
+
+// SetNavFilterPreset adds or replaces a named query filter, agents using it
+// plan their paths again with the new costs.
+func (a *App) SetNavFilterPreset(name string, filter NavQueryFilter) error {
+	return a.meshMgr.SetFilterPreset(name, filter)
+}
+
+func (a *App) RemoveNavFilterPreset(name string) error {
+	return a.meshMgr.RemoveFilterPreset(name)
+}
+
+func (a *App) GetNavFilterPresets() []NavFilterPreset {
+	return a.meshMgr.GetFilterPresets()
+}
+
+func (a *App) RaycastNavMesh(id string, start, end Vec3) (*RaycastResult, error) {
+	return a.meshMgr.Raycast(id, start, end)
+}
+
+func (a *App) FindNearestPointNavMesh(id string, pos, extents Vec3) (*NearestPoint, error) {
+	return a.meshMgr.FindNearestPoint(id, pos, extents)
+}
+
+// GetNavMeshTiles returns the tile headers of a navmesh with the vertices,
+// area and flags of their polygons and their off-mesh links.
+func (a *App) GetNavMeshTiles(id string) ([]NavTileInfo, error) {
+	return a.meshMgr.GetTiles(id)
+}
+
+// GetNavMeshPoly returns one polygon with its neighbour links, e.g. for the
+// polygon clicked in the navmesh view.
+func (a *App) GetNavMeshPoly(id string, polyRef uint64) (*NavPolyInfo, error) {
+	return a.meshMgr.GetPoly(id, polyRef)
+}
+
+// CheckNavMeshReachability returns the reachability matrix of the points,
+// with a seed point name it also lists the polygons the seed can not reach.
+func (a *App) CheckNavMeshReachability(id string, points []ReachPoint, seed string, filter NavQueryFilter) (*ReachabilityReport, error) {
+	return a.meshMgr.CheckReachability(id, points, seed, filter)
+}
+
+func (a *App) SetAgentTargetById(id string, agentId uint32, x, y, z float32) error {
+	return a.meshMgr.SetAgentTargetById(id, agentId, x, y, z)
+}
+
+func (a *App) TeleportAgentById(id string, agentId uint32, x, y, z float32) error {
+	return a.meshMgr.TeleportAgentById(id, agentId, x, y, z)
+}
+
+func (a *App) RemoveAgentById(id string, agentId uint32) error {
+	return a.meshMgr.RemoveAgentById(id, agentId)
+}
+
+func (a *App) SetAgentParamsById(id string, agentId uint32, params ServerAgentParams) error {
+	return a.meshMgr.SetAgentParamsById(id, agentId, params)
+}
+
+// SetAgentFilterById makes an agent use a filter preset, the empty name puts
+// it back on the default filter.
+func (a *App) SetAgentFilterById(id string, agentId uint32, preset string) error {
+	return a.meshMgr.SetAgentFilterById(id, agentId, preset)
+}
+
+func (a *App) PauseAgentById(id string, agentId uint32, paused bool) error {
+	return a.meshMgr.PauseAgentById(id, agentId, paused)
+}
+
+// NavSnapshotEvent is the Wails event carrying a NavSimSnapshot.
+const NavSnapshotEvent = "navmesh:snapshot"
+
+// StartNavSimulation runs the crowd of the navmesh in Go and emits a
+// NavSnapshotEvent after every tick.
+func (a *App) StartNavSimulation(id string, params NavSimParams) error {
+	if a.ctx == nil {
+		return errors.New("simulation events need the app window")
+	}
+	return a.meshMgr.StartSimulation(id, params, func(snap *NavSimSnapshot) {
+		runtime.EventsEmit(a.ctx, NavSnapshotEvent, snap)
+	})
+}
+
+func (a *App) StopNavSimulation(id string) {
+	a.meshMgr.StopSimulation(id)
+}
+
+func (a *App) SetNavSimulationParams(id string, params NavSimParams) error {
+	return a.meshMgr.SetSimulationParams(id, params)
+}
+
+// StartNavRecording logs every agent call and crowd update of the navmesh to
+// filename until StopNavRecording.
+func (a *App) StartNavRecording(id string, filename FilePath) error {
+	return a.meshMgr.StartRecording(id, string(filename))
+}
+
+func (a *App) StopNavRecording(id string) error {
+	return a.meshMgr.StopRecording(id)
+}
+
+// OpenNavReplay loads a recording as navmesh id, navFile must be the navmesh
+// the recording was made on.
+func (a *App) OpenNavReplay(id string, recordFile, navFile FilePath) error {
+	return a.meshMgr.OpenReplay(id, string(recordFile), string(navFile))
+}
+
+func (a *App) SeekNavReplay(id string, tick uint64) (*NavReplayState, error) {
+	return a.meshMgr.SeekReplay(id, tick)
+}
+
+func (a *App) StepNavReplay(id string, delta int) (*NavReplayState, error) {
+	return a.meshMgr.StepReplay(id, delta)
+}
+
 type NavInfo struct {
 	Primitives []*DebugDrawerPrimitive `json:"primitives"`
 	Agents     []*ServerAgent          `json:"agents"`
 	Params     *ServerAgentParams      `json:"agent_params"`
+	Obstacles  []NavObstacleInfo       `json:"obstacles"`
 }
 
 type ServerAgent struct {
@@ -134,5 +338,10 @@ func (a *App) GetNavMeshInfo(id string, addMesh bool) *NavInfo {
 		MaxAcceleration: info.Params.MaxAcceleration,
 	}
 
+	navInfo.Obstacles, _ = a.meshMgr.GetObstacles(id)
+	if addMesh && len(navInfo.Obstacles) > 0 {
+		navInfo.Primitives = append(navInfo.Primitives, debugObstacles(navInfo.Obstacles))
+	}
+
 	return navInfo
 }
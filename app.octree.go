@@ -1,11 +1,29 @@
 package main
 
-import "runtime"
+import (
+	"runtime"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
 
 func (a *App) AddOctreeItem(id string, octreeParam OctreeParam, agentParam AgentParam, triangles []Triangle) error {
 	return a.octreeMgr.Add(id, octreeParam, agentParam, triangles)
 }
 
+// AddOctreeItemFromObj builds an octree item from an OBJ file on disk, so the
+// triangles don't have to go through the frontend.
+func (a *App) AddOctreeItemFromObj(id string, path FilePath, octreeParam OctreeParam, agentParam AgentParam) error {
+	if a.octreeMgr.Exist(id) {
+		return nil
+	}
+
+	mesh, err := LoadObj(string(path))
+	if err != nil {
+		return err
+	}
+	return a.octreeMgr.Add(id, octreeParam, agentParam, mesh.Triangles())
+}
+
 func (a *App) GetOctreeData(id string) (*OctreeExport, error) {
 	return a.octreeMgr.GetOctreeData(id)
 }
@@ -22,6 +40,156 @@ func (a *App) ExistOctree(id string) bool {
 	return a.octreeMgr.Exist(id)
 }
 
-func (a *App) FindPathOctree(id string, start, end Vec3) []Vec3 {
-	return a.octreeMgr.FindPath(id, start, end)
+// FindPathOctree finds a path for the named agent profile, an empty profile
+// uses the agent the octree was built with.
+func (a *App) FindPathOctree(id, profile string, start, end Vec3) []Vec3 {
+	return a.octreeMgr.FindPath(id, profile, start, end)
+}
+
+// AddOctreeProfile adds an agent profile to a built octree, only the path
+// graph and the voxel padding are computed again.
+func (a *App) AddOctreeProfile(id, name string, agentParam AgentParam) error {
+	return a.octreeMgr.AddProfile(id, name, agentParam)
+}
+
+func (a *App) RemoveOctreeProfile(id, name string) error {
+	return a.octreeMgr.RemoveProfile(id, name)
+}
+
+func (a *App) GetOctreeProfiles(id string) ([]OctreeProfileInfo, error) {
+	return a.octreeMgr.GetProfiles(id)
+}
+
+func (a *App) RaycastOctree(id string, start, end Vec3) (*RaycastResult, error) {
+	return a.octreeMgr.Raycast(id, start, end)
+}
+
+func (a *App) FindNearestPointOctree(id string, pos, extents Vec3) (*NearestPoint, error) {
+	return a.octreeMgr.FindNearestPoint(id, pos, extents)
+}
+
+// SaveOctree writes the built octree to path, LoadOctree reads it back
+// without building it again.
+func (a *App) SaveOctree(id string, path FilePath) error {
+	return a.octreeMgr.Save(id, string(path))
+}
+
+func (a *App) LoadOctree(id string, path FilePath) error {
+	return a.octreeMgr.Load(id, string(path))
+}
+
+// OctreeBuildEvent is the Wails event carrying an OctreeBuildProgress.
+const OctreeBuildEvent = "octree:build"
+
+// StartOctreeBuild builds the octree in the background and returns the job
+// id, progress is emitted as OctreeBuildEvent.
+func (a *App) StartOctreeBuild(id string, octreeParam OctreeParam, agentParam AgentParam, triangles []Triangle) (string, error) {
+	return a.octreeMgr.StartBuild(id, octreeParam, agentParam, triangles, a.emitOctreeBuild)
+}
+
+func (a *App) StartOctreeBuildFromObj(id string, path FilePath, octreeParam OctreeParam, agentParam AgentParam) (string, error) {
+	mesh, err := LoadObj(string(path))
+	if err != nil {
+		return "", err
+	}
+	return a.octreeMgr.StartBuild(id, octreeParam, agentParam, mesh.Triangles(), a.emitOctreeBuild)
+}
+
+func (a *App) CancelOctreeBuild(jobId string) error {
+	return a.octreeMgr.CancelBuild(jobId)
+}
+
+func (a *App) GetOctreeBuildStatus(jobId string) (*OctreeBuildProgress, error) {
+	return a.octreeMgr.GetBuildStatus(jobId)
+}
+
+func (a *App) emitOctreeBuild(progress *OctreeBuildProgress) {
+	// headless callers poll GetOctreeBuildStatus instead
+	if a.ctx == nil {
+		return
+	}
+	wailsruntime.EventsEmit(a.ctx, OctreeBuildEvent, progress)
+}
+
+// ExportOctree writes the octree leaves selected by filter to path, format
+// is ExportFormatObj, ExportFormatGlb or ExportFormatGltf and taken from
+// the extension of path when empty.
+func (a *App) ExportOctree(id string, path FilePath, format string, filter OctreeDataFilter) error {
+	return a.octreeMgr.Export(id, string(path), format, filter)
+}
+
+// GetOctreeFlatData is the compact form of GetOctreeData, see
+// OctreeFlatExport for the layout.
+func (a *App) GetOctreeFlatData(id string, filter OctreeDataFilter) (*OctreeFlatExport, error) {
+	return a.octreeMgr.GetOctreeFlatData(id, filter, nil)
+}
+
+// GetOctreeFlatDataInBounds only exports the nodes intersecting bounds.
+func (a *App) GetOctreeFlatDataInBounds(id string, filter OctreeDataFilter, bounds Bounds) (*OctreeFlatExport, error) {
+	return a.octreeMgr.GetOctreeFlatData(id, filter, &bounds)
+}
+
+// FindPathOctreeWithOptions is FindPathOctree with pruning, smoothing and
+// search statistics.
+func (a *App) FindPathOctreeWithOptions(id string, start, end Vec3, options OctreePathOptions) (*OctreePath, error) {
+	return a.octreeMgr.FindPathWithOptions(id, start, end, options)
+}
+
+// SetOctreeObstacle adds or replaces a dynamic obstacle, only the subtree
+// around it is built again.
+func (a *App) SetOctreeObstacle(id, obstacleId string, obstacle OctreeObstacle) error {
+	return a.octreeMgr.SetObstacle(id, obstacleId, obstacle)
+}
+
+func (a *App) RemoveOctreeObstacle(id, obstacleId string) error {
+	return a.octreeMgr.RemoveObstacle(id, obstacleId)
+}
+
+func (a *App) MoveOctreeObstacle(id, obstacleId string, offset Vec3) error {
+	return a.octreeMgr.MoveObstacle(id, obstacleId, offset)
+}
+
+func (a *App) GetOctreeObstacles(id string) ([]string, error) {
+	return a.octreeMgr.GetObstacles(id)
+}
+
+// GetOctreeReport returns build diagnostics of an octree: nodes per depth,
+// leaf volumes, connected free space, triangles outside the bounds, phase
+// times and memory.
+func (a *App) GetOctreeReport(id string) (*OctreeReport, error) {
+	return a.octreeMgr.GetReport(id)
+}
+
+// CheckOctreeReachability returns the reachability matrix of the points for
+// an agent profile, with a seed point name it also lists the free space the
+// seed can not reach.
+func (a *App) CheckOctreeReachability(id, profile string, points []ReachPoint, seed string) (*ReachabilityReport, error) {
+	return a.octreeMgr.CheckReachability(id, profile, points, seed)
+}
+
+// BuildOctreeFlowField computes a flow field towards goal over the free
+// space of the octree, units sample it with SampleOctreeFlowField instead of
+// finding a path each.
+func (a *App) BuildOctreeFlowField(id string, goal Vec3) (*OctreeFlowFieldInfo, error) {
+	return a.octreeMgr.BuildFlowField(id, goal)
+}
+
+func (a *App) RemoveOctreeFlowField(id string) error {
+	return a.octreeMgr.RemoveFlowField(id)
+}
+
+func (a *App) SampleOctreeFlowField(id string, positions []Vec3) ([]OctreeFlowSample, error) {
+	return a.octreeMgr.SampleFlowField(id, positions)
+}
+
+// GetOctreeFlowFieldData exports the flow field per node for the octree
+// scene, see OctreeFlowFieldExport for the layout.
+func (a *App) GetOctreeFlowFieldData(id string) (*OctreeFlowFieldExport, error) {
+	return a.octreeMgr.GetFlowFieldData(id)
+}
+
+// FindPathsOctree runs a batch of path queries in parallel, the results are
+// in request order.
+func (a *App) FindPathsOctree(id string, requests []OctreePathRequest) ([]OctreePathResult, error) {
+	return a.octreeMgr.FindPaths(id, requests)
 }
@@ -0,0 +1,26 @@
+package main
+
+// StartApiServer exposes the App methods on a loopback http address,
+// e.g. "127.0.0.1:7788", and returns the bearer token of the requests.
+// The calls only reach the files under root, none when it is empty.
+func (a *App) StartApiServer(addr, root string) (string, error) {
+	if a.apiServer == nil {
+		a.apiServer = NewApiServer(a)
+	}
+	return a.apiServer.Start(addr, root)
+}
+
+func (a *App) StopApiServer() error {
+	if a.apiServer == nil {
+		return nil
+	}
+	return a.apiServer.Stop()
+}
+
+// ApiServerStatus returns the listening address, empty when not running.
+func (a *App) ApiServerStatus() string {
+	if a.apiServer == nil {
+		return ""
+	}
+	return a.apiServer.Addr()
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// cliCommand is a headless subcommand, e.g. "workbench navmesh info".
+type cliCommand struct {
+	group string
+	name  string
+	usage string
+	run   func(app *App, out io.Writer, args []string) (interface{}, error)
+}
+
+var cliCommands = []*cliCommand{
+	{"navmesh", "info", "print navmesh debug info of a navmesh file", cliNavMeshInfo},
+	{"navmesh", "path", "find a path between two points on a navmesh file", cliNavMeshPath},
+	{"navmesh", "crowd", "simulate one crowd agent towards a target", cliNavMeshCrowd},
+	{"navmesh", "simulate", "run the simulation loop and stream agent snapshots as json lines", cliNavMeshSimulate},
+	{"navmesh", "reach", "check which named points reach each other on a navmesh file", cliNavMeshReach},
+	{"navmesh", "build", "build a tilemesh navmesh from an obj file and print the stage timings", cliNavMeshBuild},
+	{"octree", "build", "build an octree from a build spec and print its stats", cliOctreeBuild},
+	{"octree", "path", "build an octree and find a path between two points", cliOctreePath},
+	{"octree", "reach", "build an octree and check which named points reach each other", cliOctreeReach},
+	{"api", "serve", "serve the App methods over loopback http until interrupted", cliApiServe},
+}
+
+// isCliCommand reports whether args start with a headless command group.
+func isCliCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	for _, cmd := range cliCommands {
+		if cmd.group == args[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// runCli runs a headless command and writes its result as JSON to stdout,
+// errors and usage go to stderr. It returns the process exit code.
+func runCli(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		cliUsage(stderr)
+		return 2
+	}
+
+	var cmd *cliCommand
+	for _, c := range cliCommands {
+		if c.group == args[0] && c.name == args[1] {
+			cmd = c
+			break
+		}
+	}
+	if cmd == nil {
+		cliUsage(stderr)
+		return 2
+	}
+
+	result, err := cmd.run(NewApp(), stdout, args[2:])
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(result); err != nil {
+		fmt.Fprintln(stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}
+
+func cliUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: workbench <group> <command> [flags]")
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(w, "  %-8s %-8s %s\n", cmd.group, cmd.name, cmd.usage)
+	}
+}
+
+// parseVec3 parses a vector written as "x,y,z".
+func parseVec3(s string) (Vec3, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return Vec3{}, fmt.Errorf("invalid vector %q, want x,y,z", s)
+	}
+
+	var v [3]float32
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return Vec3{}, fmt.Errorf("invalid vector %q: %v", s, err)
+		}
+		v[i] = float32(f)
+	}
+	return Vec3{X: v[0], Y: v[1], Z: v[2]}, nil
+}
+
+// cliLoadNavMesh loads the navmesh file given by -type and -file into the app.
+func cliLoadNavMesh(app *App, navType, filename string) error {
+	if filename == "" {
+		return errors.New("missing -file")
+	}
+	return app.LoadNavMeshLocal(filename, filename, navType, FilePath(filename))
+}
+
+func cliNavMeshInfo(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh info", flag.ContinueOnError)
+	navType := fs.String("type", "", "navmesh type passed to the loader")
+	filename := fs.String("file", "", "navmesh file")
+	addMesh := fs.Bool("mesh", false, "include debug draw primitives")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := cliLoadNavMesh(app, *navType, *filename); err != nil {
+		return nil, err
+	}
+	return app.GetNavMeshInfo(*filename, *addMesh), nil
+}
+
+func cliNavMeshPath(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh path", flag.ContinueOnError)
+	navType := fs.String("type", "", "navmesh type passed to the loader")
+	filename := fs.String("file", "", "navmesh file")
+	start := fs.String("start", "", "path start x,y,z")
+	end := fs.String("end", "", "path end x,y,z")
+	include := fs.Uint("include", 0, "include poly flags, 0 means all")
+	exclude := fs.Uint("exclude", 0, "exclude poly flags")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	startPos, err := parseVec3(*start)
+	if err != nil {
+		return nil, err
+	}
+	endPos, err := parseVec3(*end)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cliLoadNavMesh(app, *navType, *filename); err != nil {
+		return nil, err
+	}
+
+	filter := NavQueryFilter{IncludeFlags: uint16(*include), ExcludeFlags: uint16(*exclude)}
+	return app.FindPathNavMesh(*filename, startPos, endPos, filter)
+}
+
+func cliNavMeshCrowd(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh crowd", flag.ContinueOnError)
+	navType := fs.String("type", "", "navmesh type passed to the loader")
+	filename := fs.String("file", "", "navmesh file")
+	start := fs.String("start", "", "agent start position x,y,z")
+	target := fs.String("target", "", "agent target position x,y,z")
+	radius := fs.Float64("radius", 0.6, "agent radius")
+	height := fs.Float64("height", 2.0, "agent height")
+	speed := fs.Float64("speed", 3.5, "agent max speed")
+	acc := fs.Float64("acc", 8.0, "agent max acceleration")
+	ticks := fs.Int("ticks", 100, "number of crowd updates")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	startPos, err := parseVec3(*start)
+	if err != nil {
+		return nil, err
+	}
+	targetPos, err := parseVec3(*target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cliLoadNavMesh(app, *navType, *filename); err != nil {
+		return nil, err
+	}
+
+	id := *filename
+	_, err = app.AddAgent(id, startPos.X, startPos.Y, startPos.Z, float32(*radius), float32(*height), float32(*speed), float32(*acc))
+	if err != nil {
+		return nil, err
+	}
+	if err = app.SetAgentTarget(id, targetPos.X, targetPos.Y, targetPos.Z); err != nil {
+		return nil, err
+	}
+
+	// one snapshot per tick so the trajectory can be inspected offline
+	frames := make([][]*ServerAgent, 0, *ticks)
+	for i := 0; i < *ticks; i++ {
+		if err = app.UpdateAgents(id); err != nil {
+			return nil, err
+		}
+		frames = append(frames, app.GetNavMeshInfo(id, false).Agents)
+	}
+	return frames, nil
+}
+
+func cliNavMeshSimulate(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh simulate", flag.ContinueOnError)
+	navType := fs.String("type", "", "navmesh type passed to the loader")
+	filename := fs.String("file", "", "navmesh file")
+	start := fs.String("start", "", "agent start position x,y,z")
+	target := fs.String("target", "", "agent target position x,y,z")
+	agents := fs.Int("agents", 1, "number of agents spawned at start")
+	rate := fs.Float64("rate", 30, "ticks per second")
+	dt := fs.Float64("dt", 0, "fixed seconds per tick, 0 uses real time")
+	duration := fs.Duration("duration", 5*time.Second, "how long to simulate")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	startPos, err := parseVec3(*start)
+	if err != nil {
+		return nil, err
+	}
+	targetPos, err := parseVec3(*target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cliLoadNavMesh(app, *navType, *filename); err != nil {
+		return nil, err
+	}
+
+	id := *filename
+	for i := 0; i < *agents; i++ {
+		if _, err = app.AddAgent(id, startPos.X, startPos.Y, startPos.Z, 0.6, 2.0, 3.5, 8.0); err != nil {
+			return nil, err
+		}
+	}
+	if err = app.SetAgentTarget(id, targetPos.X, targetPos.Y, targetPos.Z); err != nil {
+		return nil, err
+	}
+
+	var ticks atomic.Uint64
+	encoder := json.NewEncoder(out)
+	// the first write error ends the run, e.g. when the reader closed the pipe
+	failed := make(chan error, 1)
+	params := NavSimParams{TickRate: float32(*rate), FixedDt: float32(*dt)}
+	err = app.meshMgr.StartSimulation(id, params, func(snap *NavSimSnapshot) {
+		ticks.Store(snap.Tick)
+		if err := encoder.Encode(snap); err != nil {
+			select {
+			case failed <- err:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(*duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case err = <-failed:
+	}
+	// returns after the last snapshot was written
+	app.StopNavSimulation(id)
+	if err != nil {
+		return nil, err
+	}
+	return ticks.Load(), nil
+}
+
+// cliLoadReachPoints reads a json array of named points from -points.
+func cliLoadReachPoints(filename string) ([]ReachPoint, error) {
+	if filename == "" {
+		return nil, errors.New("missing -points")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []ReachPoint
+	if err = json.Unmarshal(data, &points); err != nil {
+		return nil, fmt.Errorf("failed to parse points %s: %v", filename, err)
+	}
+	return points, nil
+}
+
+func cliNavMeshReach(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh reach", flag.ContinueOnError)
+	navType := fs.String("type", "", "navmesh type passed to the loader")
+	filename := fs.String("file", "", "navmesh file")
+	pointsFile := fs.String("points", "", "json array of {name, position}")
+	seed := fs.String("seed", "", "point name to find unreachable islands from")
+	include := fs.Uint("include", 0, "include poly flags, 0 means all")
+	exclude := fs.Uint("exclude", 0, "exclude poly flags")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	points, err := cliLoadReachPoints(*pointsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cliLoadNavMesh(app, *navType, *filename); err != nil {
+		return nil, err
+	}
+
+	filter := NavQueryFilter{IncludeFlags: uint16(*include), ExcludeFlags: uint16(*exclude)}
+	return app.CheckNavMeshReachability(*filename, points, *seed, filter)
+}
+
+func cliNavMeshBuild(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("navmesh build", flag.ContinueOnError)
+	objFile := fs.String("obj", "", "obj file with the input geometry")
+	paramsFile := fs.String("params", "", "json NavBuildParams, defaults when empty")
+	outFile := fs.String("out", "", "save the built navmesh to this file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if *objFile == "" {
+		return nil, errors.New("missing -obj")
+	}
+
+	var params NavBuildParams
+	if *paramsFile != "" {
+		data, err := os.ReadFile(*paramsFile)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("failed to parse params %s: %v", *paramsFile, err)
+		}
+	}
+
+	result, err := app.BuildNavMeshFromObj(*objFile, FilePath(*objFile), params)
+	if err != nil {
+		return nil, err
+	}
+	if *outFile != "" {
+		if err = app.SaveNavMesh(*objFile, FilePath(*outFile)); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// OctreeBuildSpec is the input of the headless octree commands, it holds the
+// same arguments as App.AddOctreeItem. When Obj is set the triangles are
+// loaded from that OBJ file instead.
+type OctreeBuildSpec struct {
+	Octree    OctreeParam
+	Agent     AgentParam
+	Triangles []Triangle
+	Obj       string
+}
+
+// OctreeBuildStats is the output of "octree build".
+type OctreeBuildStats struct {
+	Triangles  int `json:"triangles"`
+	Nodes      int `json:"nodes"`
+	Leaves     int `json:"leaves"`
+	FreeLeaves int `json:"free_leaves"`
+}
+
+// cliBuildOctree reads a build spec from -spec and builds it into the app.
+func cliBuildOctree(app *App, filename string) (*OctreeBuildSpec, error) {
+	if filename == "" {
+		return nil, errors.New("missing -spec")
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &OctreeBuildSpec{}
+	if err = json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec %s: %v", filename, err)
+	}
+
+	if spec.Obj != "" {
+		mesh, err := LoadObj(spec.Obj)
+		if err != nil {
+			return nil, err
+		}
+		spec.Triangles = mesh.Triangles()
+	}
+
+	if err = app.AddOctreeItem(filename, spec.Octree, spec.Agent, spec.Triangles); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func countOctreeNodes(node *OctreeNodeExport, stats *OctreeBuildStats) {
+	if node == nil {
+		return
+	}
+	stats.Nodes++
+	if node.IsLeaf {
+		stats.Leaves++
+		if !node.IsOccupied {
+			stats.FreeLeaves++
+		}
+	}
+	for _, child := range node.Children {
+		countOctreeNodes(child, stats)
+	}
+}
+
+func cliOctreeBuild(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("octree build", flag.ContinueOnError)
+	filename := fs.String("spec", "", "json build spec with Octree, Agent and Triangles or Obj")
+	outFile := fs.String("out", "", "save the built octree to this file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	spec, err := cliBuildOctree(app, *filename)
+	if err != nil {
+		return nil, err
+	}
+	if *outFile != "" {
+		if err = app.SaveOctree(*filename, FilePath(*outFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	export, err := app.GetOctreeData(*filename)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &OctreeBuildStats{Triangles: len(spec.Triangles)}
+	countOctreeNodes(export.Root, stats)
+	return stats, nil
+}
+
+func cliOctreePath(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("octree path", flag.ContinueOnError)
+	filename := fs.String("spec", "", "json build spec with Octree, Agent and Triangles or Obj")
+	start := fs.String("start", "", "path start x,y,z")
+	end := fs.String("end", "", "path end x,y,z")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	startPos, err := parseVec3(*start)
+	if err != nil {
+		return nil, err
+	}
+	endPos, err := parseVec3(*end)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = cliBuildOctree(app, *filename); err != nil {
+		return nil, err
+	}
+	return app.FindPathOctree(*filename, "", startPos, endPos), nil
+}
+
+func cliOctreeReach(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("octree reach", flag.ContinueOnError)
+	filename := fs.String("spec", "", "json build spec with Octree, Agent and Triangles or Obj")
+	pointsFile := fs.String("points", "", "json array of {name, position}")
+	seed := fs.String("seed", "", "point name to find unreachable islands from")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	points, err := cliLoadReachPoints(*pointsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = cliBuildOctree(app, *filename); err != nil {
+		return nil, err
+	}
+	return app.CheckOctreeReachability(*filename, "", points, *seed)
+}
+
+func cliApiServe(app *App, out io.Writer, args []string) (interface{}, error) {
+	fs := flag.NewFlagSet("api serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:7788", "loopback address to listen on")
+	root := fs.String("root", "", "directory holding the files the calls may read and write")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	token, err := app.StartApiServer(*addr, *root)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(os.Stderr, "api server listening on", app.ApiServerStatus(), "token", token)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+
+	return app.ApiServerStatus(), app.StopApiServer()
+}
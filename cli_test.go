@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRunCli runs the octree commands end to end and checks that stdout
+// holds nothing but the JSON result.
+func TestRunCli(t *testing.T) {
+	dir := t.TempDir()
+	spec := OctreeBuildSpec{
+		Octree: OctreeParam{
+			Bounds:   Bounds{Min: Vec3{X: -5, Y: -1, Z: -5}, Max: Vec3{X: 5, Y: 3, Z: 5}},
+			MaxDepth: 4,
+			MinSize:  0.5,
+			StepSize: 0.5,
+		},
+		Agent: AgentParam{Radius: 0.3, Height: 1},
+		Triangles: []Triangle{
+			{A: Vec3{X: -4, Z: -4}, B: Vec3{X: -4, Z: 4}, C: Vec3{X: 4, Z: 4}},
+			{A: Vec3{X: -4, Z: -4}, B: Vec3{X: 4, Z: 4}, C: Vec3{X: 4, Z: -4}},
+		},
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specFile := filepath.Join(dir, "spec.json")
+	if err = os.WriteFile(specFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	outFile := filepath.Join(dir, "octree.bin")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		result interface{}
+	}{
+		{name: "octree build", args: []string{"octree", "build", "-spec", specFile, "-out", outFile}, result: &OctreeBuildStats{}},
+		{name: "octree path", args: []string{"octree", "path", "-spec", specFile, "-start", "-3,0.6,-3", "-end", "3,0.6,3"}, result: &[]Vec3{}},
+		{name: "unknown command", args: []string{"octree", "nope"}, code: 2},
+		{name: "bad flag", args: []string{"octree", "build", "-nope"}, code: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runCli(test.args, &stdout, &stderr); code != test.code {
+				t.Fatalf("got exit code %d, want %d, stderr %q", code, test.code, stderr.String())
+			}
+			if test.result == nil {
+				if stdout.Len() != 0 {
+					t.Fatalf("got output %q on a failure", stdout.String())
+				}
+				return
+			}
+			if err := json.Unmarshal(stdout.Bytes(), test.result); err != nil {
+				t.Fatalf("stdout is not the JSON result: %v\n%s", err, stdout.String())
+			}
+		})
+	}
+
+	if _, err := os.Stat(outFile); err != nil {
+		t.Fatalf("octree build -out: %v", err)
+	}
+}
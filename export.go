@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Formats of the navmesh and octree exporters.
+const (
+	ExportFormatObj  = "obj"  // Wavefront OBJ with vertex colors
+	ExportFormatGlb  = "glb"  // binary glTF 2.0
+	ExportFormatGltf = "gltf" // JSON glTF 2.0 with the buffer embedded as base64
+)
+
+// exportGroup is a named set of colored polygons, or of line segments when
+// lines is set. Every group becomes an OBJ group and a glTF primitive.
+type exportGroup struct {
+	name   string
+	lines  bool
+	verts  []Vec3
+	colors [][4]float32
+	faces  [][]uint32
+}
+
+type exportMesh struct {
+	name   string
+	groups []*exportGroup
+}
+
+// group returns the group with name, adding it when missing.
+func (m *exportMesh) group(name string, lines bool) *exportGroup {
+	for _, g := range m.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	g := &exportGroup{name: name, lines: lines}
+	m.groups = append(m.groups, g)
+	return g
+}
+
+// addFace adds a polygon or a line segment with a single color.
+func (g *exportGroup) addFace(verts []Vec3, color [4]float32) {
+	face := make([]uint32, len(verts))
+	for i, v := range verts {
+		face[i] = uint32(len(g.verts))
+		g.verts = append(g.verts, v)
+		g.colors = append(g.colors, color)
+	}
+	g.faces = append(g.faces, face)
+}
+
+// exportFormat checks format, the empty format is taken from the extension
+// of path.
+func exportFormat(path, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	switch format = strings.ToLower(format); format {
+	case ExportFormatObj, ExportFormatGlb, ExportFormatGltf:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected %s, %s or %s", format, ExportFormatObj, ExportFormatGlb, ExportFormatGltf)
+}
+
+// write saves the mesh to path in format.
+func (m *exportMesh) write(path, format string) error {
+	format, err := exportFormat(path, format)
+	if err != nil {
+		return err
+	}
+	empty := true
+	for _, g := range m.groups {
+		if len(g.faces) > 0 {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		return errors.New("nothing to export")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	switch format {
+	case ExportFormatObj:
+		err = m.writeObj(w)
+	case ExportFormatGlb:
+		err = m.writeGlb(w)
+	default:
+		err = m.writeGltf(w)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeObj writes the mesh as OBJ, the color follows the position of every
+// vertex as most viewers expect.
+func (m *exportMesh) writeObj(w *bufio.Writer) error {
+	fmt.Fprintf(w, "# exported by workbench-go\no %s\n", m.name)
+	base := 1
+	for _, g := range m.groups {
+		if len(g.faces) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "g %s\n", g.name)
+		for i, v := range g.verts {
+			c := g.colors[i]
+			fmt.Fprintf(w, "v %g %g %g %.3f %.3f %.3f\n", v.X, v.Y, v.Z, c[0], c[1], c[2])
+		}
+		for _, face := range g.faces {
+			if g.lines {
+				w.WriteString("l")
+			} else {
+				w.WriteString("f")
+			}
+			for _, i := range face {
+				fmt.Fprintf(w, " %d", base+int(i))
+			}
+			w.WriteString("\n")
+		}
+		base += len(g.verts)
+	}
+	return nil
+}
+
+// glTF constants of the exporter.
+const (
+	gltfMagic         = 0x46546c67 // "glTF"
+	gltfChunkJson     = 0x4e4f534a
+	gltfChunkBin      = 0x004e4942
+	gltfFloat         = 5126
+	gltfUnsignedInt   = 5125
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+	gltfModeLines     = 1
+	gltfModeTriangles = 4
+)
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfDocument struct {
+	Asset       map[string]string        `json:"asset"`
+	Scene       int                      `json:"scene"`
+	Scenes      []map[string]interface{} `json:"scenes"`
+	Nodes       []map[string]interface{} `json:"nodes"`
+	Meshes      []map[string]interface{} `json:"meshes"`
+	Materials   []map[string]interface{} `json:"materials"`
+	Accessors   []gltfAccessor           `json:"accessors"`
+	BufferViews []gltfBufferView         `json:"bufferViews"`
+	Buffers     []gltfBuffer             `json:"buffers"`
+}
+
+type gltfBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	Uri        string `json:"uri,omitempty"`
+}
+
+// gltf lays the mesh out as a glTF document and its single buffer. Polygons
+// are fanned into triangles, which is exact for the convex navmesh polygons
+// and the octree faces.
+func (m *exportMesh) gltf() (*gltfDocument, []byte) {
+	doc := gltfDocument{
+		Asset:  map[string]string{"version": "2.0", "generator": "workbench-go"},
+		Scenes: []map[string]interface{}{{"nodes": []int{0}}},
+		Nodes:  []map[string]interface{}{{"mesh": 0, "name": m.name}},
+		// the vertex colors are the only color, and the winding of the
+		// navmesh polygons depends on its source
+		Materials: []map[string]interface{}{{"doubleSided": true}},
+	}
+	var bin []byte
+	view := func(data []byte, target int) int {
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{ByteOffset: len(bin), ByteLength: len(data), Target: target})
+		bin = append(bin, data...)
+		return len(doc.BufferViews) - 1
+	}
+	accessor := func(a gltfAccessor) int {
+		doc.Accessors = append(doc.Accessors, a)
+		return len(doc.Accessors) - 1
+	}
+
+	var primitives []gltfPrimitive
+	for _, g := range m.groups {
+		if len(g.faces) == 0 {
+			continue
+		}
+		var positions, colors, indices []byte
+		lo := []float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+		hi := []float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+		for i, v := range g.verts {
+			for j, f := range [3]float32{v.X, v.Y, v.Z} {
+				positions = putFloat32(positions, f)
+				lo[j], hi[j] = min(lo[j], f), max(hi[j], f)
+			}
+			for _, f := range g.colors[i] {
+				colors = putFloat32(colors, f)
+			}
+		}
+		count := 0
+		for _, face := range g.faces {
+			if g.lines {
+				for i := 1; i < len(face); i++ {
+					indices = binary.LittleEndian.AppendUint32(indices, face[i-1])
+					indices = binary.LittleEndian.AppendUint32(indices, face[i])
+					count += 2
+				}
+				continue
+			}
+			for i := 2; i < len(face); i++ {
+				for _, k := range [3]uint32{face[0], face[i-1], face[i]} {
+					indices = binary.LittleEndian.AppendUint32(indices, k)
+				}
+				count += 3
+			}
+		}
+
+		mode := gltfModeTriangles
+		if g.lines {
+			mode = gltfModeLines
+		}
+		primitives = append(primitives, gltfPrimitive{
+			Attributes: map[string]int{
+				"POSITION": accessor(gltfAccessor{BufferView: view(positions, gltfArrayBuffer), ComponentType: gltfFloat, Count: len(g.verts), Type: "VEC3", Min: lo, Max: hi}),
+				"COLOR_0":  accessor(gltfAccessor{BufferView: view(colors, gltfArrayBuffer), ComponentType: gltfFloat, Count: len(g.verts), Type: "VEC4"}),
+			},
+			Indices: accessor(gltfAccessor{BufferView: view(indices, gltfElementBuffer), ComponentType: gltfUnsignedInt, Count: count, Type: "SCALAR"}),
+			Mode:    mode,
+		})
+	}
+	doc.Meshes = []map[string]interface{}{{"name": m.name, "primitives": primitives}}
+	doc.Buffers = []gltfBuffer{{ByteLength: len(bin)}}
+	return &doc, bin
+}
+
+// writeGltf writes the mesh as a glTF JSON file, the buffer is embedded as
+// a base64 data uri so the file stands alone.
+func (m *exportMesh) writeGltf(w *bufio.Writer) error {
+	doc, bin := m.gltf()
+	doc.Buffers[0].Uri = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(bin)
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// writeGlb writes the mesh as a binary glTF file.
+func (m *exportMesh) writeGlb(w *bufio.Writer) error {
+	doc, bin := m.gltf()
+	header, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// chunks are 4 byte aligned, json with spaces and binary with zeros
+	for len(header)%4 != 0 {
+		header = append(header, ' ')
+	}
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	var out []byte
+	out = binary.LittleEndian.AppendUint32(out, gltfMagic)
+	out = binary.LittleEndian.AppendUint32(out, 2)
+	out = binary.LittleEndian.AppendUint32(out, uint32(12+8+len(header)+8+len(bin)))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(header)))
+	out = binary.LittleEndian.AppendUint32(out, gltfChunkJson)
+	out = append(out, header...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(bin)))
+	out = binary.LittleEndian.AppendUint32(out, gltfChunkBin)
+	out = append(out, bin...)
+	_, err = w.Write(out)
+	return err
+}
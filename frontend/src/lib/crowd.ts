@@ -312,7 +312,7 @@ export class DebugCrowd {
     }
 
     async moveAgent(tabId: string, pos: Vector3): Promise<void> {
-        SetAgentTarget(tabId, pos.x, pos.y, pos.z)
+        SetAgentTarget(tabId, pos.x, pos.y, pos.z).catch(err => console.error('set agent target failed:', err))
         this.agentTarget.set(pos.x, pos.y, pos.z)
         this.end.set(pos.x, pos.y, pos.z)
         this.updateTweakpanePositions()
@@ -362,7 +362,7 @@ export class DebugCrowd {
         }
 
         // 执行后端更新
-        UpdateAgents(tabId)
+        UpdateAgents(tabId).catch(err => console.error('update agents failed:', err))
         const info = GetNavMeshInfo(tabId, false)
         if (info) {
             const newAgents = (await info).agents.map(agent => ({
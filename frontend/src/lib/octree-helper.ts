@@ -680,7 +680,7 @@ export class OctreeHelper {
         this.clearPath()
 
         // 调用后端API获取路径
-        const path = await FindPathOctree(this.id,
+        const path = await FindPathOctree(this.id, '',
             { X: this.agentStart.x, Y: this.agentStart.y, Z: this.agentStart.z },
             { X: this.agentEnd.x, Y: this.agentEnd.y, Z: this.agentEnd.z },
         )
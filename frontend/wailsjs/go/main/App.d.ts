@@ -3,50 +3,184 @@
 import {main} from '../models';
 import {frontend} from '../models';
 
-export function AddAgent(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number,arg7:number,arg8:number):Promise<void>;
+export function AddAgent(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number,arg6:number,arg7:number,arg8:number):Promise<number>;
+
+export function AddNavOffMeshConnection(arg1:string,arg2:main.NavOffMeshLink):Promise<number>;
 
 export function AddOctreeItem(arg1:string,arg2:main.OctreeParam,arg3:main.AgentParam,arg4:Array<main.Triangle>):Promise<void>;
 
+export function AddOctreeItemFromObj(arg1:string,arg2:main.FilePath,arg3:main.OctreeParam,arg4:main.AgentParam):Promise<void>;
+
+export function AddOctreeProfile(arg1:string,arg2:string,arg3:main.AgentParam):Promise<void>;
+
+export function ApiServerStatus():Promise<string>;
+
+export function BuildNavMesh(arg1:string,arg2:main.NavBuildParams,arg3:Array<main.Triangle>):Promise<main.NavBuildResult>;
+
+export function BuildNavMeshFromObj(arg1:string,arg2:main.FilePath,arg3:main.NavBuildParams):Promise<main.NavBuildResult>;
+
+export function BuildOctreeFlowField(arg1:string,arg2:main.Vec3):Promise<main.OctreeFlowFieldInfo>;
+
+export function CanEditNavOffMeshConnections(arg1:string):Promise<void>;
+
+export function CancelOctreeBuild(arg1:string):Promise<void>;
+
+export function CheckNavMeshReachability(arg1:string,arg2:Array<main.ReachPoint>,arg3:string,arg4:main.NavQueryFilter):Promise<main.ReachabilityReport>;
+
+export function CheckOctreeReachability(arg1:string,arg2:string,arg3:Array<main.ReachPoint>,arg4:string):Promise<main.ReachabilityReport>;
+
 export function ClearAgent(arg1:string):Promise<void>;
 
 export function CreateRigidKinematic(arg1:number,arg2:main.Vec3):Promise<void>;
 
+export function DiffNavMesh(arg1:string,arg2:string):Promise<main.NavMeshDiff>;
+
 export function ExistOctree(arg1:string):Promise<boolean>;
 
-export function FindPathOctree(arg1:string,arg2:main.Vec3,arg3:main.Vec3):Promise<Array<main.Vec3>>;
+export function ExportNavMesh(arg1:string,arg2:main.FilePath,arg3:string):Promise<void>;
+
+export function ExportOctree(arg1:string,arg2:main.FilePath,arg3:string,arg4:main.OctreeDataFilter):Promise<void>;
+
+export function FindNearestPointNavMesh(arg1:string,arg2:main.Vec3,arg3:main.Vec3):Promise<main.NearestPoint>;
+
+export function FindNearestPointOctree(arg1:string,arg2:main.Vec3,arg3:main.Vec3):Promise<main.NearestPoint>;
+
+export function FindPathNavMesh(arg1:string,arg2:main.Vec3,arg3:main.Vec3,arg4:main.NavQueryFilter):Promise<main.NavPath>;
+
+export function FindPathNavMeshWithPreset(arg1:string,arg2:main.Vec3,arg3:main.Vec3,arg4:string):Promise<main.NavPath>;
+
+export function FindPathOctree(arg1:string,arg2:string,arg3:main.Vec3,arg4:main.Vec3):Promise<Array<main.Vec3>>;
+
+export function FindPathOctreeWithOptions(arg1:string,arg2:main.Vec3,arg3:main.Vec3,arg4:main.OctreePathOptions):Promise<main.OctreePath>;
+
+export function FindPathsOctree(arg1:string,arg2:Array<main.OctreePathRequest>):Promise<Array<main.OctreePathResult>>;
+
+export function GetNavFilterPresets():Promise<Array<main.NavFilterPreset>>;
 
 export function GetNavMeshInfo(arg1:string,arg2:boolean):Promise<main.NavInfo>;
 
+export function GetNavMeshPoly(arg1:string,arg2:number):Promise<main.NavPolyInfo>;
+
+export function GetNavMeshTiles(arg1:string):Promise<Array<main.NavTileInfo>>;
+
+export function GetNavObstacles(arg1:string):Promise<Array<main.NavObstacleInfo>>;
+
+export function GetNavOffMeshConnections(arg1:string):Promise<Array<main.NavOffMeshLink>>;
+
+export function GetOctreeBuildStatus(arg1:string):Promise<main.OctreeBuildProgress>;
+
 export function GetOctreeData(arg1:string):Promise<main.OctreeExport>;
 
+export function GetOctreeFlatData(arg1:string,arg2:main.OctreeDataFilter):Promise<main.OctreeFlatExport>;
+
+export function GetOctreeFlatDataInBounds(arg1:string,arg2:main.OctreeDataFilter,arg3:main.Bounds):Promise<main.OctreeFlatExport>;
+
+export function GetOctreeFlowFieldData(arg1:string):Promise<main.OctreeFlowFieldExport>;
+
+export function GetOctreeObstacles(arg1:string):Promise<Array<string>>;
+
+export function GetOctreeProfiles(arg1:string):Promise<Array<main.OctreeProfileInfo>>;
+
+export function GetOctreeReport(arg1:string):Promise<main.OctreeReport>;
+
 export function InitPhysx(arg1:string,arg2:number):Promise<void>;
 
 export function LoadAndCreateRigidKinematic(arg1:string,arg2:main.Vec3):Promise<void>;
 
 export function LoadNavMesh(arg1:string,arg2:string,arg3:string,arg4:Array<number>):Promise<void>;
 
-export function LoadNavMeshLocal(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+export function LoadNavMeshLocal(arg1:string,arg2:string,arg3:string,arg4:main.FilePath):Promise<void>;
+
+export function LoadOctree(arg1:string,arg2:main.FilePath):Promise<void>;
 
 export function LoadPhysxXml(arg1:string):Promise<void>;
 
 export function LoadPhysxXmlString(arg1:string):Promise<void>;
 
+export function MoveNavOffMeshConnection(arg1:string,arg2:number,arg3:main.Vec3,arg4:main.Vec3):Promise<number>;
+
+export function MoveOctreeObstacle(arg1:string,arg2:string,arg3:main.Vec3):Promise<void>;
+
 export function OpenFileDialog(arg1:string,arg2:Array<frontend.FileFilter>):Promise<string>;
 
+export function OpenNavReplay(arg1:string,arg2:main.FilePath,arg3:main.FilePath):Promise<void>;
+
+export function PauseAgentById(arg1:string,arg2:number,arg3:boolean):Promise<void>;
+
 export function PhysxStep():Promise<void>;
 
 export function ProcessSelectedFiles(arg1:Array<string>):Promise<void>;
 
+export function RaycastNavMesh(arg1:string,arg2:main.Vec3,arg3:main.Vec3):Promise<main.RaycastResult>;
+
+export function RaycastOctree(arg1:string,arg2:main.Vec3,arg3:main.Vec3):Promise<main.RaycastResult>;
+
 export function ReleasePhysx():Promise<void>;
 
+export function RemoveAgentById(arg1:string,arg2:number):Promise<void>;
+
+export function RemoveNavFilterPreset(arg1:string):Promise<void>;
+
 export function RemoveNavMesh(arg1:string):Promise<void>;
 
+export function RemoveNavObstacle(arg1:string,arg2:string):Promise<void>;
+
+export function RemoveNavOffMeshConnection(arg1:string,arg2:number):Promise<void>;
+
+export function RemoveOctreeFlowField(arg1:string):Promise<void>;
+
+export function RemoveOctreeObstacle(arg1:string,arg2:string):Promise<void>;
+
+export function RemoveOctreeProfile(arg1:string,arg2:string):Promise<void>;
+
 export function ResetOctree(arg1:string):Promise<void>;
 
+export function SampleOctreeFlowField(arg1:string,arg2:Array<main.Vec3>):Promise<Array<main.OctreeFlowSample>>;
+
+export function SaveNavMesh(arg1:string,arg2:main.FilePath):Promise<void>;
+
+export function SaveOctree(arg1:string,arg2:main.FilePath):Promise<void>;
+
+export function SeekNavReplay(arg1:string,arg2:number):Promise<main.NavReplayState>;
+
+export function SetAgentFilterById(arg1:string,arg2:number,arg3:string):Promise<void>;
+
+export function SetAgentParamsById(arg1:string,arg2:number,arg3:main.ServerAgentParams):Promise<void>;
+
 export function SetAgentTarget(arg1:string,arg2:number,arg3:number,arg4:number):Promise<void>;
 
+export function SetAgentTargetById(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number):Promise<void>;
+
+export function SetNavFilterPreset(arg1:string,arg2:main.NavQueryFilter):Promise<void>;
+
+export function SetNavObstacle(arg1:string,arg2:string,arg3:main.NavObstacle):Promise<void>;
+
+export function SetNavSimulationParams(arg1:string,arg2:main.NavSimParams):Promise<void>;
+
+export function SetOctreeObstacle(arg1:string,arg2:string,arg3:main.OctreeObstacle):Promise<void>;
+
 export function SetRigidKinematicPosition(arg1:number,arg2:main.Vec3):Promise<void>;
 
+export function StartApiServer(arg1:string,arg2:string):Promise<string>;
+
+export function StartNavRecording(arg1:string,arg2:main.FilePath):Promise<void>;
+
+export function StartNavSimulation(arg1:string,arg2:main.NavSimParams):Promise<void>;
+
+export function StartOctreeBuild(arg1:string,arg2:main.OctreeParam,arg3:main.AgentParam,arg4:Array<main.Triangle>):Promise<string>;
+
+export function StartOctreeBuildFromObj(arg1:string,arg2:main.FilePath,arg3:main.OctreeParam,arg4:main.AgentParam):Promise<string>;
+
+export function StepNavReplay(arg1:string,arg2:number):Promise<main.NavReplayState>;
+
+export function StopApiServer():Promise<void>;
+
+export function StopNavRecording(arg1:string):Promise<void>;
+
+export function StopNavSimulation(arg1:string):Promise<void>;
+
 export function TeleportAgent(arg1:string,arg2:number,arg3:number,arg4:number):Promise<boolean>;
 
+export function TeleportAgentById(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number):Promise<void>;
+
 export function UpdateAgents(arg1:string):Promise<void>;
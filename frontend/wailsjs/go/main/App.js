@@ -6,10 +6,54 @@ export function AddAgent(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
   return window['go']['main']['App']['AddAgent'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
+export function AddNavOffMeshConnection(arg1, arg2) {
+  return window['go']['main']['App']['AddNavOffMeshConnection'](arg1, arg2);
+}
+
 export function AddOctreeItem(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['AddOctreeItem'](arg1, arg2, arg3, arg4);
 }
 
+export function AddOctreeItemFromObj(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AddOctreeItemFromObj'](arg1, arg2, arg3, arg4);
+}
+
+export function AddOctreeProfile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddOctreeProfile'](arg1, arg2, arg3);
+}
+
+export function ApiServerStatus() {
+  return window['go']['main']['App']['ApiServerStatus']();
+}
+
+export function BuildNavMesh(arg1, arg2, arg3) {
+  return window['go']['main']['App']['BuildNavMesh'](arg1, arg2, arg3);
+}
+
+export function BuildNavMeshFromObj(arg1, arg2, arg3) {
+  return window['go']['main']['App']['BuildNavMeshFromObj'](arg1, arg2, arg3);
+}
+
+export function BuildOctreeFlowField(arg1, arg2) {
+  return window['go']['main']['App']['BuildOctreeFlowField'](arg1, arg2);
+}
+
+export function CanEditNavOffMeshConnections(arg1) {
+  return window['go']['main']['App']['CanEditNavOffMeshConnections'](arg1);
+}
+
+export function CancelOctreeBuild(arg1) {
+  return window['go']['main']['App']['CancelOctreeBuild'](arg1);
+}
+
+export function CheckNavMeshReachability(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CheckNavMeshReachability'](arg1, arg2, arg3, arg4);
+}
+
+export function CheckOctreeReachability(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CheckOctreeReachability'](arg1, arg2, arg3, arg4);
+}
+
 export function ClearAgent(arg1) {
   return window['go']['main']['App']['ClearAgent'](arg1);
 }
@@ -18,22 +62,106 @@ export function CreateRigidKinematic(arg1, arg2) {
   return window['go']['main']['App']['CreateRigidKinematic'](arg1, arg2);
 }
 
+export function DiffNavMesh(arg1, arg2) {
+  return window['go']['main']['App']['DiffNavMesh'](arg1, arg2);
+}
+
 export function ExistOctree(arg1) {
   return window['go']['main']['App']['ExistOctree'](arg1);
 }
 
-export function FindPathOctree(arg1, arg2, arg3) {
-  return window['go']['main']['App']['FindPathOctree'](arg1, arg2, arg3);
+export function ExportNavMesh(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportNavMesh'](arg1, arg2, arg3);
+}
+
+export function ExportOctree(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExportOctree'](arg1, arg2, arg3, arg4);
+}
+
+export function FindNearestPointNavMesh(arg1, arg2, arg3) {
+  return window['go']['main']['App']['FindNearestPointNavMesh'](arg1, arg2, arg3);
+}
+
+export function FindNearestPointOctree(arg1, arg2, arg3) {
+  return window['go']['main']['App']['FindNearestPointOctree'](arg1, arg2, arg3);
+}
+
+export function FindPathNavMesh(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FindPathNavMesh'](arg1, arg2, arg3, arg4);
+}
+
+export function FindPathNavMeshWithPreset(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FindPathNavMeshWithPreset'](arg1, arg2, arg3, arg4);
+}
+
+export function FindPathOctree(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FindPathOctree'](arg1, arg2, arg3, arg4);
+}
+
+export function FindPathOctreeWithOptions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FindPathOctreeWithOptions'](arg1, arg2, arg3, arg4);
+}
+
+export function FindPathsOctree(arg1, arg2) {
+  return window['go']['main']['App']['FindPathsOctree'](arg1, arg2);
+}
+
+export function GetNavFilterPresets() {
+  return window['go']['main']['App']['GetNavFilterPresets']();
 }
 
 export function GetNavMeshInfo(arg1, arg2) {
   return window['go']['main']['App']['GetNavMeshInfo'](arg1, arg2);
 }
 
+export function GetNavMeshPoly(arg1, arg2) {
+  return window['go']['main']['App']['GetNavMeshPoly'](arg1, arg2);
+}
+
+export function GetNavMeshTiles(arg1) {
+  return window['go']['main']['App']['GetNavMeshTiles'](arg1);
+}
+
+export function GetNavObstacles(arg1) {
+  return window['go']['main']['App']['GetNavObstacles'](arg1);
+}
+
+export function GetNavOffMeshConnections(arg1) {
+  return window['go']['main']['App']['GetNavOffMeshConnections'](arg1);
+}
+
+export function GetOctreeBuildStatus(arg1) {
+  return window['go']['main']['App']['GetOctreeBuildStatus'](arg1);
+}
+
 export function GetOctreeData(arg1) {
   return window['go']['main']['App']['GetOctreeData'](arg1);
 }
 
+export function GetOctreeFlatData(arg1, arg2) {
+  return window['go']['main']['App']['GetOctreeFlatData'](arg1, arg2);
+}
+
+export function GetOctreeFlatDataInBounds(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetOctreeFlatDataInBounds'](arg1, arg2, arg3);
+}
+
+export function GetOctreeFlowFieldData(arg1) {
+  return window['go']['main']['App']['GetOctreeFlowFieldData'](arg1);
+}
+
+export function GetOctreeObstacles(arg1) {
+  return window['go']['main']['App']['GetOctreeObstacles'](arg1);
+}
+
+export function GetOctreeProfiles(arg1) {
+  return window['go']['main']['App']['GetOctreeProfiles'](arg1);
+}
+
+export function GetOctreeReport(arg1) {
+  return window['go']['main']['App']['GetOctreeReport'](arg1);
+}
+
 export function InitPhysx(arg1, arg2) {
   return window['go']['main']['App']['InitPhysx'](arg1, arg2);
 }
@@ -50,6 +178,10 @@ export function LoadNavMeshLocal(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['LoadNavMeshLocal'](arg1, arg2, arg3, arg4);
 }
 
+export function LoadOctree(arg1, arg2) {
+  return window['go']['main']['App']['LoadOctree'](arg1, arg2);
+}
+
 export function LoadPhysxXml(arg1) {
   return window['go']['main']['App']['LoadPhysxXml'](arg1);
 }
@@ -58,10 +190,26 @@ export function LoadPhysxXmlString(arg1) {
   return window['go']['main']['App']['LoadPhysxXmlString'](arg1);
 }
 
+export function MoveNavOffMeshConnection(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['MoveNavOffMeshConnection'](arg1, arg2, arg3, arg4);
+}
+
+export function MoveOctreeObstacle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveOctreeObstacle'](arg1, arg2, arg3);
+}
+
 export function OpenFileDialog(arg1, arg2) {
   return window['go']['main']['App']['OpenFileDialog'](arg1, arg2);
 }
 
+export function OpenNavReplay(arg1, arg2, arg3) {
+  return window['go']['main']['App']['OpenNavReplay'](arg1, arg2, arg3);
+}
+
+export function PauseAgentById(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PauseAgentById'](arg1, arg2, arg3);
+}
+
 export function PhysxStep() {
   return window['go']['main']['App']['PhysxStep']();
 }
@@ -70,30 +218,150 @@ export function ProcessSelectedFiles(arg1) {
   return window['go']['main']['App']['ProcessSelectedFiles'](arg1);
 }
 
+export function RaycastNavMesh(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RaycastNavMesh'](arg1, arg2, arg3);
+}
+
+export function RaycastOctree(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RaycastOctree'](arg1, arg2, arg3);
+}
+
 export function ReleasePhysx() {
   return window['go']['main']['App']['ReleasePhysx']();
 }
 
+export function RemoveAgentById(arg1, arg2) {
+  return window['go']['main']['App']['RemoveAgentById'](arg1, arg2);
+}
+
+export function RemoveNavFilterPreset(arg1) {
+  return window['go']['main']['App']['RemoveNavFilterPreset'](arg1);
+}
+
 export function RemoveNavMesh(arg1) {
   return window['go']['main']['App']['RemoveNavMesh'](arg1);
 }
 
+export function RemoveNavObstacle(arg1, arg2) {
+  return window['go']['main']['App']['RemoveNavObstacle'](arg1, arg2);
+}
+
+export function RemoveNavOffMeshConnection(arg1, arg2) {
+  return window['go']['main']['App']['RemoveNavOffMeshConnection'](arg1, arg2);
+}
+
+export function RemoveOctreeFlowField(arg1) {
+  return window['go']['main']['App']['RemoveOctreeFlowField'](arg1);
+}
+
+export function RemoveOctreeObstacle(arg1, arg2) {
+  return window['go']['main']['App']['RemoveOctreeObstacle'](arg1, arg2);
+}
+
+export function RemoveOctreeProfile(arg1, arg2) {
+  return window['go']['main']['App']['RemoveOctreeProfile'](arg1, arg2);
+}
+
 export function ResetOctree(arg1) {
   return window['go']['main']['App']['ResetOctree'](arg1);
 }
 
+export function SampleOctreeFlowField(arg1, arg2) {
+  return window['go']['main']['App']['SampleOctreeFlowField'](arg1, arg2);
+}
+
+export function SaveNavMesh(arg1, arg2) {
+  return window['go']['main']['App']['SaveNavMesh'](arg1, arg2);
+}
+
+export function SaveOctree(arg1, arg2) {
+  return window['go']['main']['App']['SaveOctree'](arg1, arg2);
+}
+
+export function SeekNavReplay(arg1, arg2) {
+  return window['go']['main']['App']['SeekNavReplay'](arg1, arg2);
+}
+
+export function SetAgentFilterById(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAgentFilterById'](arg1, arg2, arg3);
+}
+
+export function SetAgentParamsById(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAgentParamsById'](arg1, arg2, arg3);
+}
+
 export function SetAgentTarget(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['SetAgentTarget'](arg1, arg2, arg3, arg4);
 }
 
+export function SetAgentTargetById(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['SetAgentTargetById'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function SetNavFilterPreset(arg1, arg2) {
+  return window['go']['main']['App']['SetNavFilterPreset'](arg1, arg2);
+}
+
+export function SetNavObstacle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetNavObstacle'](arg1, arg2, arg3);
+}
+
+export function SetNavSimulationParams(arg1, arg2) {
+  return window['go']['main']['App']['SetNavSimulationParams'](arg1, arg2);
+}
+
+export function SetOctreeObstacle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetOctreeObstacle'](arg1, arg2, arg3);
+}
+
 export function SetRigidKinematicPosition(arg1, arg2) {
   return window['go']['main']['App']['SetRigidKinematicPosition'](arg1, arg2);
 }
 
+export function StartApiServer(arg1, arg2) {
+  return window['go']['main']['App']['StartApiServer'](arg1, arg2);
+}
+
+export function StartNavRecording(arg1, arg2) {
+  return window['go']['main']['App']['StartNavRecording'](arg1, arg2);
+}
+
+export function StartNavSimulation(arg1, arg2) {
+  return window['go']['main']['App']['StartNavSimulation'](arg1, arg2);
+}
+
+export function StartOctreeBuild(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartOctreeBuild'](arg1, arg2, arg3, arg4);
+}
+
+export function StartOctreeBuildFromObj(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartOctreeBuildFromObj'](arg1, arg2, arg3, arg4);
+}
+
+export function StepNavReplay(arg1, arg2) {
+  return window['go']['main']['App']['StepNavReplay'](arg1, arg2);
+}
+
+export function StopApiServer() {
+  return window['go']['main']['App']['StopApiServer']();
+}
+
+export function StopNavRecording(arg1) {
+  return window['go']['main']['App']['StopNavRecording'](arg1);
+}
+
+export function StopNavSimulation(arg1) {
+  return window['go']['main']['App']['StopNavSimulation'](arg1);
+}
+
 export function TeleportAgent(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['TeleportAgent'](arg1, arg2, arg3, arg4);
 }
 
+export function TeleportAgentById(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['TeleportAgentById'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function UpdateAgents(arg1) {
   return window['go']['main']['App']['UpdateAgents'](arg1);
 }
@@ -132,6 +132,275 @@ export namespace main {
 	        this.vertices = source["vertices"];
 	    }
 	}
+	export class NavBuildParams {
+	    cell_size: number;
+	    cell_height: number;
+	    agent_height: number;
+	    agent_radius: number;
+	    agent_max_climb: number;
+	    agent_max_slope: number;
+	    region_min_size: number;
+	    edge_max_len: number;
+	    edge_max_error: number;
+	    verts_per_poly: number;
+	    tile_size: number;
+	    tile_cache: boolean;
+	    debug: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavBuildParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.cell_size = source["cell_size"];
+	        this.cell_height = source["cell_height"];
+	        this.agent_height = source["agent_height"];
+	        this.agent_radius = source["agent_radius"];
+	        this.agent_max_climb = source["agent_max_climb"];
+	        this.agent_max_slope = source["agent_max_slope"];
+	        this.region_min_size = source["region_min_size"];
+	        this.edge_max_len = source["edge_max_len"];
+	        this.edge_max_error = source["edge_max_error"];
+	        this.verts_per_poly = source["verts_per_poly"];
+	        this.tile_size = source["tile_size"];
+	        this.tile_cache = source["tile_cache"];
+	        this.debug = source["debug"];
+	    }
+	}
+	export class NavBuildStage {
+	    stage: string;
+	    time: number;
+	    debug?: DebugDrawerPrimitive[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NavBuildStage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.stage = source["stage"];
+	        this.time = source["time"];
+	        this.debug = this.convertValues(source["debug"], DebugDrawerPrimitive);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NavBuildResult {
+	    id: string;
+	    params: NavBuildParams;
+	    bounds: Bounds;
+	    tiles: number;
+	    polys: number;
+	    verts: number;
+	    data_size: number;
+	    stages: NavBuildStage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NavBuildResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.params = this.convertValues(source["params"], NavBuildParams);
+	        this.bounds = this.convertValues(source["bounds"], Bounds);
+	        this.tiles = source["tiles"];
+	        this.polys = source["polys"];
+	        this.verts = source["verts"];
+	        this.data_size = source["data_size"];
+	        this.stages = this.convertValues(source["stages"], NavBuildStage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class NavDiffRegion {
+	    kind: string;
+	    polys: number[];
+	    area: number;
+	    bounds: Bounds;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavDiffRegion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.polys = source["polys"];
+	        this.area = source["area"];
+	        this.bounds = this.convertValues(source["bounds"], Bounds);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NavQueryFilter {
+	    include_flags: number;
+	    exclude_flags: number;
+	    area_costs: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NavQueryFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.include_flags = source["include_flags"];
+	        this.exclude_flags = source["exclude_flags"];
+	        this.area_costs = source["area_costs"];
+	    }
+	}
+	export class NavFilterPreset {
+	    name: string;
+	    filter: NavQueryFilter;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavFilterPreset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.filter = this.convertValues(source["filter"], NavQueryFilter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NavObstacle {
+	    pos: Vec3;
+	    radius: number;
+	    height: number;
+	    box?: Bounds;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavObstacle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pos = this.convertValues(source["pos"], Vec3);
+	        this.radius = source["radius"];
+	        this.height = source["height"];
+	        this.box = this.convertValues(source["box"], Bounds);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NavObstacleInfo {
+	    id: string;
+	    obstacle: NavObstacle;
+	    pending: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavObstacleInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.obstacle = this.convertValues(source["obstacle"], NavObstacle);
+	        this.pending = source["pending"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ServerAgentParams {
 	    radius: number;
 	    height: number;
@@ -168,6 +437,7 @@ export namespace main {
 	    primitives: DebugDrawerPrimitive[];
 	    agents: ServerAgent[];
 	    agent_params?: ServerAgentParams;
+	    obstacles: NavObstacleInfo[];
 	
 	    static createFrom(source: any = {}) {
 	        return new NavInfo(source);
@@ -178,6 +448,7 @@ export namespace main {
 	        this.primitives = this.convertValues(source["primitives"], DebugDrawerPrimitive);
 	        this.agents = this.convertValues(source["agents"], ServerAgent);
 	        this.agent_params = this.convertValues(source["agent_params"], ServerAgentParams);
+	        this.obstacles = this.convertValues(source["obstacles"], NavObstacleInfo);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -198,24 +469,42 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class OctreeNodeExport {
-	    bounds: geometry.AABB;
-	    children?: OctreeNodeExport[];
-	    is_leaf: boolean;
-	    is_occupied: boolean;
-	    depth: number;
+	export class NavMeshDiff {
+	    id_a: string;
+	    id_b: string;
+	    tiles_added: number;
+	    tiles_removed: number;
+	    tiles_changed: number;
+	    polys_added: number;
+	    polys_removed: number;
+	    polys_changed: number;
+	    polys_unchanged: number;
+	    added_area: number;
+	    removed_area: number;
+	    changed_area: number;
+	    regions: NavDiffRegion[];
+	    primitives: DebugDrawerPrimitive[];
 	
 	    static createFrom(source: any = {}) {
-	        return new OctreeNodeExport(source);
+	        return new NavMeshDiff(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.bounds = this.convertValues(source["bounds"], geometry.AABB);
-	        this.children = this.convertValues(source["children"], OctreeNodeExport);
-	        this.is_leaf = source["is_leaf"];
-	        this.is_occupied = source["is_occupied"];
-	        this.depth = source["depth"];
+	        this.id_a = source["id_a"];
+	        this.id_b = source["id_b"];
+	        this.tiles_added = source["tiles_added"];
+	        this.tiles_removed = source["tiles_removed"];
+	        this.tiles_changed = source["tiles_changed"];
+	        this.polys_added = source["polys_added"];
+	        this.polys_removed = source["polys_removed"];
+	        this.polys_changed = source["polys_changed"];
+	        this.polys_unchanged = source["polys_unchanged"];
+	        this.added_area = source["added_area"];
+	        this.removed_area = source["removed_area"];
+	        this.changed_area = source["changed_area"];
+	        this.regions = this.convertValues(source["regions"], NavDiffRegion);
+	        this.primitives = this.convertValues(source["primitives"], DebugDrawerPrimitive);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -236,20 +525,32 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class OctreeExport {
-	    root?: OctreeNodeExport;
-	    max_depth: number;
-	    min_size: number;
+	
+	
+	export class NavOffMeshLink {
+	    ref: number;
+	    start: Vec3;
+	    end: Vec3;
+	    radius: number;
+	    bidirectional: boolean;
+	    area: number;
+	    flags: number;
+	    user_id: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new OctreeExport(source);
+	        return new NavOffMeshLink(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.root = this.convertValues(source["root"], OctreeNodeExport);
-	        this.max_depth = source["max_depth"];
-	        this.min_size = source["min_size"];
+	        this.ref = source["ref"];
+	        this.start = this.convertValues(source["start"], Vec3);
+	        this.end = this.convertValues(source["end"], Vec3);
+	        this.radius = source["radius"];
+	        this.bidirectional = source["bidirectional"];
+	        this.area = source["area"];
+	        this.flags = source["flags"];
+	        this.user_id = source["user_id"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -270,23 +571,22 @@ export namespace main {
 		    return a;
 		}
 	}
-	
-	export class OctreeParam {
-	    Bounds: Bounds;
-	    MaxDepth: number;
-	    MinSize: number;
-	    StepSize: number;
+	export class NavPath {
+	    corridor: number[];
+	    points: Vec3[];
+	    flags: number[];
+	    partial: boolean;
 	
 	    static createFrom(source: any = {}) {
-	        return new OctreeParam(source);
+	        return new NavPath(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Bounds = this.convertValues(source["Bounds"], Bounds);
-	        this.MaxDepth = source["MaxDepth"];
-	        this.MinSize = source["MinSize"];
-	        this.StepSize = source["StepSize"];
+	        this.corridor = source["corridor"];
+	        this.points = this.convertValues(source["points"], Vec3);
+	        this.flags = source["flags"];
+	        this.partial = source["partial"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -307,22 +607,50 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class NavPolyLink {
+	    ref: number;
+	    edge: number;
+	    side: number;
 	
+	    static createFrom(source: any = {}) {
+	        return new NavPolyLink(source);
+	    }
 	
-	export class Triangle {
-	    A: Vec3;
-	    B: Vec3;
-	    C: Vec3;
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ref = source["ref"];
+	        this.edge = source["edge"];
+	        this.side = source["side"];
+	    }
+	}
+	export class NavPolyInfo {
+	    ref: number;
+	    tile_ref: number;
+	    index: number;
+	    type: string;
+	    area: number;
+	    flags: number;
+	    verts: Vec3[];
+	    center: Vec3;
+	    links?: NavPolyLink[];
+	    off_mesh?: NavOffMeshLink;
 	
 	    static createFrom(source: any = {}) {
-	        return new Triangle(source);
+	        return new NavPolyInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.A = this.convertValues(source["A"], Vec3);
-	        this.B = this.convertValues(source["B"], Vec3);
-	        this.C = this.convertValues(source["C"], Vec3);
+	        this.ref = source["ref"];
+	        this.tile_ref = source["tile_ref"];
+	        this.index = source["index"];
+	        this.type = source["type"];
+	        this.area = source["area"];
+	        this.flags = source["flags"];
+	        this.verts = this.convertValues(source["verts"], Vec3);
+	        this.center = this.convertValues(source["center"], Vec3);
+	        this.links = this.convertValues(source["links"], NavPolyLink);
+	        this.off_mesh = this.convertValues(source["off_mesh"], NavOffMeshLink);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -343,6 +671,998 @@ export namespace main {
 		    return a;
 		}
 	}
+	
+	
+	export class NavReplayState {
+	    tick: number;
+	    total_ticks: number;
+	    max_error: number;
+	    diverged: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NavReplayState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tick = source["tick"];
+	        this.total_ticks = source["total_ticks"];
+	        this.max_error = source["max_error"];
+	        this.diverged = source["diverged"];
+	    }
+	}
+	export class NavSimParams {
+	    tick_rate: number;
+	    fixed_dt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NavSimParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tick_rate = source["tick_rate"];
+	        this.fixed_dt = source["fixed_dt"];
+	    }
+	}
+	export class NavTileInfo {
+	    ref: number;
+	    x: number;
+	    y: number;
+	    layer: number;
+	    bounds: Bounds;
+	    poly_count: number;
+	    vert_count: number;
+	    detail_mesh_count: number;
+	    bv_node_count: number;
+	    off_mesh_con_count: number;
+	    walkable_height: number;
+	    walkable_radius: number;
+	    walkable_climb: number;
+	    polys: NavPolyInfo[];
+	    off_mesh_links: NavOffMeshLink[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NavTileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ref = source["ref"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.layer = source["layer"];
+	        this.bounds = this.convertValues(source["bounds"], Bounds);
+	        this.poly_count = source["poly_count"];
+	        this.vert_count = source["vert_count"];
+	        this.detail_mesh_count = source["detail_mesh_count"];
+	        this.bv_node_count = source["bv_node_count"];
+	        this.off_mesh_con_count = source["off_mesh_con_count"];
+	        this.walkable_height = source["walkable_height"];
+	        this.walkable_radius = source["walkable_radius"];
+	        this.walkable_climb = source["walkable_climb"];
+	        this.polys = this.convertValues(source["polys"], NavPolyInfo);
+	        this.off_mesh_links = this.convertValues(source["off_mesh_links"], NavOffMeshLink);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NearestPoint {
+	    found: boolean;
+	    position: Vec3;
+	    ref: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new NearestPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.found = source["found"];
+	        this.position = this.convertValues(source["position"], Vec3);
+	        this.ref = source["ref"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeBuildProgress {
+	    job_id: string;
+	    id: string;
+	    status: string;
+	    phase: string;
+	    progress: number;
+	    error?: string;
+	    elapsed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeBuildProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job_id = source["job_id"];
+	        this.id = source["id"];
+	        this.status = source["status"];
+	        this.phase = source["phase"];
+	        this.progress = source["progress"];
+	        this.error = source["error"];
+	        this.elapsed = source["elapsed"];
+	    }
+	}
+	export class OctreeComponentReport {
+	    nodes: number;
+	    volume: number;
+	    bounds: Bounds;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeComponentReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodes = source["nodes"];
+	        this.volume = source["volume"];
+	        this.bounds = this.convertValues(source["bounds"], Bounds);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeDataFilter {
+	    max_depth: number;
+	    skip_free: boolean;
+	    skip_occupied: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeDataFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_depth = source["max_depth"];
+	        this.skip_free = source["skip_free"];
+	        this.skip_occupied = source["skip_occupied"];
+	    }
+	}
+	export class OctreeDepthReport {
+	    depth: number;
+	    nodes: number;
+	    occupied_leaves: number;
+	    free_leaves: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeDepthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.depth = source["depth"];
+	        this.nodes = source["nodes"];
+	        this.occupied_leaves = source["occupied_leaves"];
+	        this.free_leaves = source["free_leaves"];
+	    }
+	}
+	export class OctreeNodeExport {
+	    bounds: geometry.AABB;
+	    children?: OctreeNodeExport[];
+	    is_leaf: boolean;
+	    is_occupied: boolean;
+	    depth: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeNodeExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bounds = this.convertValues(source["bounds"], geometry.AABB);
+	        this.children = this.convertValues(source["children"], OctreeNodeExport);
+	        this.is_leaf = source["is_leaf"];
+	        this.is_occupied = source["is_occupied"];
+	        this.depth = source["depth"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeExport {
+	    root?: OctreeNodeExport;
+	    max_depth: number;
+	    min_size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.root = this.convertValues(source["root"], OctreeNodeExport);
+	        this.max_depth = source["max_depth"];
+	        this.min_size = source["min_size"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeFlatExport {
+	    max_depth: number;
+	    min_size: number;
+	    count: number;
+	    bounds: number[];
+	    flags: number[];
+	    depth: number[];
+	    first_child: number[];
+	    child_mask: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeFlatExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_depth = source["max_depth"];
+	        this.min_size = source["min_size"];
+	        this.count = source["count"];
+	        this.bounds = source["bounds"];
+	        this.flags = source["flags"];
+	        this.depth = source["depth"];
+	        this.first_child = source["first_child"];
+	        this.child_mask = source["child_mask"];
+	    }
+	}
+	export class OctreeFlowFieldExport {
+	    goal: Vec3;
+	    count: number;
+	    max_distance: number;
+	    bounds: number[];
+	    directions: number[];
+	    distances: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeFlowFieldExport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.goal = this.convertValues(source["goal"], Vec3);
+	        this.count = source["count"];
+	        this.max_distance = source["max_distance"];
+	        this.bounds = source["bounds"];
+	        this.directions = source["directions"];
+	        this.distances = source["distances"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeFlowFieldInfo {
+	    goal: Vec3;
+	    nodes: number;
+	    reachable: number;
+	    max_distance: number;
+	    build_time: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeFlowFieldInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.goal = this.convertValues(source["goal"], Vec3);
+	        this.nodes = source["nodes"];
+	        this.reachable = source["reachable"];
+	        this.max_distance = source["max_distance"];
+	        this.build_time = source["build_time"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeFlowSample {
+	    found: boolean;
+	    node: number;
+	    distance: number;
+	    direction: Vec3;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeFlowSample(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.found = source["found"];
+	        this.node = source["node"];
+	        this.distance = source["distance"];
+	        this.direction = this.convertValues(source["direction"], Vec3);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeMemoryReport {
+	    octree: number;
+	    triangles: number;
+	    nav_data: number;
+	    total: number;
+	    heap_alloc: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeMemoryReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.octree = source["octree"];
+	        this.triangles = source["triangles"];
+	        this.nav_data = source["nav_data"];
+	        this.total = source["total"];
+	        this.heap_alloc = source["heap_alloc"];
+	    }
+	}
+	
+	export class Triangle {
+	    A: Vec3;
+	    B: Vec3;
+	    C: Vec3;
+	
+	    static createFrom(source: any = {}) {
+	        return new Triangle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.A = this.convertValues(source["A"], Vec3);
+	        this.B = this.convertValues(source["B"], Vec3);
+	        this.C = this.convertValues(source["C"], Vec3);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeObstacle {
+	    triangles: Triangle[];
+	    box?: Bounds;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeObstacle(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.triangles = this.convertValues(source["triangles"], Triangle);
+	        this.box = this.convertValues(source["box"], Bounds);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeParam {
+	    Bounds: Bounds;
+	    MaxDepth: number;
+	    MinSize: number;
+	    StepSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeParam(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Bounds = this.convertValues(source["Bounds"], Bounds);
+	        this.MaxDepth = source["MaxDepth"];
+	        this.MinSize = source["MinSize"];
+	        this.StepSize = source["StepSize"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreePathStats {
+	    length: number;
+	    expansions: number;
+	    search_time: number;
+	    raw_points: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePathStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.length = source["length"];
+	        this.expansions = source["expansions"];
+	        this.search_time = source["search_time"];
+	        this.raw_points = source["raw_points"];
+	    }
+	}
+	export class OctreePath {
+	    points: Vec3[];
+	    nodes: number[];
+	    partial: boolean;
+	    stats: OctreePathStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePath(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.points = this.convertValues(source["points"], Vec3);
+	        this.nodes = source["nodes"];
+	        this.partial = source["partial"];
+	        this.stats = this.convertValues(source["stats"], OctreePathStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreePathOptions {
+	    profile: string;
+	    prune: boolean;
+	    smooth: string;
+	    segments: number;
+	    max_iterations: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePathOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profile = source["profile"];
+	        this.prune = source["prune"];
+	        this.smooth = source["smooth"];
+	        this.segments = source["segments"];
+	        this.max_iterations = source["max_iterations"];
+	    }
+	}
+	export class OctreePathRequest {
+	    start: Vec3;
+	    end: Vec3;
+	    options: OctreePathOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePathRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], Vec3);
+	        this.end = this.convertValues(source["end"], Vec3);
+	        this.options = this.convertValues(source["options"], OctreePathOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreePathResult {
+	    path?: OctreePath;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePathResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = this.convertValues(source["path"], OctreePath);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class OctreePhaseTime {
+	    phase: string;
+	    time: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreePhaseTime(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.phase = source["phase"];
+	        this.time = source["time"];
+	    }
+	}
+	export class OctreeProfileInfo {
+	    name: string;
+	    agent: AgentParam;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeProfileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.agent = this.convertValues(source["agent"], AgentParam);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeProfileReport {
+	    name: string;
+	    agent: AgentParam;
+	    edges: number;
+	    components: number;
+	    largest: OctreeComponentReport[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeProfileReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.agent = this.convertValues(source["agent"], AgentParam);
+	        this.edges = source["edges"];
+	        this.components = source["components"];
+	        this.largest = this.convertValues(source["largest"], OctreeComponentReport);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OctreeTriangleReport {
+	    total: number;
+	    outside: number;
+	    crossing: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeTriangleReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.outside = source["outside"];
+	        this.crossing = source["crossing"];
+	    }
+	}
+	export class OctreeReport {
+	    id: string;
+	    nodes: number;
+	    depths: OctreeDepthReport[];
+	    occupied_volume: number;
+	    free_volume: number;
+	    triangles: OctreeTriangleReport;
+	    profiles: OctreeProfileReport[];
+	    phases: OctreePhaseTime[];
+	    memory: OctreeMemoryReport;
+	
+	    static createFrom(source: any = {}) {
+	        return new OctreeReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nodes = source["nodes"];
+	        this.depths = this.convertValues(source["depths"], OctreeDepthReport);
+	        this.occupied_volume = source["occupied_volume"];
+	        this.free_volume = source["free_volume"];
+	        this.triangles = this.convertValues(source["triangles"], OctreeTriangleReport);
+	        this.profiles = this.convertValues(source["profiles"], OctreeProfileReport);
+	        this.phases = this.convertValues(source["phases"], OctreePhaseTime);
+	        this.memory = this.convertValues(source["memory"], OctreeMemoryReport);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RaycastResult {
+	    hit: boolean;
+	    position: Vec3;
+	    normal: Vec3;
+	    visited: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RaycastResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hit = source["hit"];
+	        this.position = this.convertValues(source["position"], Vec3);
+	        this.normal = this.convertValues(source["normal"], Vec3);
+	        this.visited = source["visited"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReachIsland {
+	    size: number;
+	    area?: number;
+	    volume?: number;
+	    bounds: Bounds;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReachIsland(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.size = source["size"];
+	        this.area = source["area"];
+	        this.volume = source["volume"];
+	        this.bounds = this.convertValues(source["bounds"], Bounds);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReachPoint {
+	    name: string;
+	    position: Vec3;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReachPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.position = this.convertValues(source["position"], Vec3);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReachabilityReport {
+	    points: string[];
+	    reachable: boolean[][];
+	    lengths: number[][];
+	    all_reachable: boolean;
+	    seed?: string;
+	    unreachable: number;
+	    island_count: number;
+	    islands: ReachIsland[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ReachabilityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.points = source["points"];
+	        this.reachable = source["reachable"];
+	        this.lengths = source["lengths"];
+	        this.all_reachable = source["all_reachable"];
+	        this.seed = source["seed"];
+	        this.unreachable = source["unreachable"];
+	        this.island_count = source["island_count"];
+	        this.islands = this.convertValues(source["islands"], ReachIsland);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
 
 }
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,6 +13,15 @@ import (
 var assets embed.FS
 
 func main() {
+	// Run headless when started with a command, e.g. "workbench octree build"
+	if isCliCommand(os.Args[1:]) {
+		// only the JSON result goes to stdout, the libraries print their
+		// progress there so it is sent to stderr
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		os.Exit(runCli(os.Args[1:], stdout, os.Stderr))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
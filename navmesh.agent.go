@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/o0olele/detour-go/crowd"
+)
+
+// navAgentState is what the workbench remembers about a single crowd agent.
+type navAgentState struct {
+	target    Vec3
+	hasTarget bool
+	paused    bool
+	filter    string // filter preset, empty for the default filter
+}
+
+// getAgent returns the active crowd agent with the given id.
+func (item *NavMeshItem) getAgent(agentId uint32) (*crowd.DtCrowd, *crowd.DtCrowdAgent, error) {
+	c := item.GetCrowd()
+	if c == nil {
+		return nil, nil, errors.New("nav item has no crowd")
+	}
+	agent := c.GetAgent(int(agentId))
+	if agent == nil || !agent.Active {
+		return nil, nil, errors.New("agent not found")
+	}
+	return c, agent, nil
+}
+
+// agentState returns the state of an agent, callers check the agent with
+// getAgent first so a removed id gets no state a later agent would inherit.
+func (item *NavMeshItem) agentState(agentId uint32) *navAgentState {
+	state, ok := item.agents[agentId]
+	if !ok {
+		state = &navAgentState{}
+		item.agents[agentId] = state
+	}
+	return state
+}
+
+func (item *NavMeshItem) requestMoveTarget(agentId uint32, target Vec3) error {
+	c, agent, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+
+	ref, nearest, err := findNearestPoly(c.GetNavMeshQuery(), target, c.GetQueryHalfExtents(), c.GetFilter(int(agent.Params.QueryFilterType)))
+	if err != nil {
+		return err
+	}
+	if !c.RequestMoveTarget(int(agentId), ref, []float32{nearest.X, nearest.Y, nearest.Z}) {
+		return errors.New("request move target failed")
+	}
+	return nil
+}
+
+// setAgentTargetById moves a single agent, a paused agent keeps the target
+// until it is resumed.
+func (item *NavMeshItem) setAgentTargetById(agentId uint32, target Vec3) error {
+	if _, _, err := item.getAgent(agentId); err != nil {
+		return err
+	}
+	state := item.agentState(agentId)
+	state.target = target
+	state.hasTarget = true
+	if state.paused {
+		return nil
+	}
+	return item.requestMoveTarget(agentId, state.target)
+}
+
+// setAgentTarget moves every agent which is not paused to target, paused
+// agents keep it until they are resumed.
+func (item *NavMeshItem) setAgentTarget(target Vec3) error {
+	c := item.GetCrowd()
+	if c == nil {
+		return errors.New("nav item has no crowd")
+	}
+	var errs []error
+	for i := 0; i < c.GetAgentCount(); i++ {
+		if agent := c.GetAgent(i); agent == nil || !agent.Active {
+			continue
+		}
+		if err := item.setAgentTargetById(uint32(i), target); err != nil {
+			errs = append(errs, fmt.Errorf("agent %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// teleportAgentById places a single agent on the navmesh at the given
+// position, its move target is kept.
+func (item *NavMeshItem) teleportAgentById(agentId uint32, pos Vec3) error {
+	c, agent, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+
+	ref, nearest, err := findNearestPoly(c.GetNavMeshQuery(), pos, c.GetQueryHalfExtents(), c.GetFilter(int(agent.Params.QueryFilterType)))
+	if err != nil {
+		return err
+	}
+
+	// same reset as dtCrowd::addAgent does for a new agent
+	npos := [3]float32{nearest.X, nearest.Y, nearest.Z}
+	agent.Corridor.Reset(ref, npos[:])
+	agent.Boundary.Reset()
+	agent.Partial = false
+	agent.Npos = npos
+	agent.Vel = [3]float32{}
+	agent.Dvel = [3]float32{}
+	agent.Nvel = [3]float32{}
+	agent.State = crowd.DT_CROWDAGENT_STATE_WALKING
+
+	state := item.agentState(agentId)
+	if state.hasTarget && !state.paused {
+		return item.requestMoveTarget(agentId, state.target)
+	}
+	return nil
+}
+
+func (item *NavMeshItem) removeAgentById(agentId uint32) error {
+	c, _, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+	c.RemoveAgent(int(agentId))
+	delete(item.agents, agentId)
+	return nil
+}
+
+func (item *NavMeshItem) setAgentParamsById(agentId uint32, params ServerAgentParams) error {
+	c, agent, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+
+	agentParams := agent.Params
+	agentParams.Radius = params.Radius
+	agentParams.Height = params.Height
+	agentParams.MaxSpeed = params.MaxSpeed
+	agentParams.MaxAcceleration = params.MaxAcceleration
+	c.UpdateAgentParameters(int(agentId), &agentParams)
+	return nil
+}
+
+// pauseAgentById stops a single agent in place, resuming it requests its
+// last target again.
+func (item *NavMeshItem) pauseAgentById(agentId uint32, paused bool) error {
+	c, _, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+
+	state := item.agentState(agentId)
+	if state.paused == paused {
+		return nil
+	}
+	state.paused = paused
+
+	if paused {
+		if !c.ResetMoveTarget(int(agentId)) {
+			return errors.New("reset move target failed")
+		}
+		return nil
+	}
+	if state.hasTarget {
+		return item.requestMoveTarget(agentId, state.target)
+	}
+	return nil
+}
+
+func (m *NavMgr) SetAgentTargetById(id string, agentId uint32, x, y, z float32) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.record(navOpSetTargetById, agentId, x, y, z)
+	return item.setAgentTargetById(agentId, Vec3{X: x, Y: y, Z: z})
+}
+
+func (m *NavMgr) TeleportAgentById(id string, agentId uint32, x, y, z float32) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.record(navOpTeleportById, agentId, x, y, z)
+	return item.teleportAgentById(agentId, Vec3{X: x, Y: y, Z: z})
+}
+
+func (m *NavMgr) RemoveAgentById(id string, agentId uint32) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.record(navOpRemoveById, agentId)
+	return item.removeAgentById(agentId)
+}
+
+// SetAgentParamsById changes radius, height, max speed and max acceleration
+// of a single agent.
+func (m *NavMgr) SetAgentParamsById(id string, agentId uint32, params ServerAgentParams) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.record(navOpSetParamsById, agentId, params.Radius, params.Height, params.MaxSpeed, params.MaxAcceleration)
+	return item.setAgentParamsById(agentId, params)
+}
+
+func (m *NavMgr) PauseAgentById(id string, agentId uint32, paused bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.record(navOpPauseById, agentId, boolToFloat(paused))
+	return item.pauseAgentById(agentId, paused)
+}
@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// NavTypeTileMesh is the navType of the tiled navmesh set files the build
+// writes, the same files the navmesh scene loads.
+const NavTypeTileMesh = "tilemesh"
+
+// Stages of a navmesh build in the order they run.
+const (
+	NavBuildStageRasterize = "rasterize"   // voxelize the input triangles
+	NavBuildStageFilter    = "filter"      // remove the spans agents can not stand on
+	NavBuildStageCompact   = "compact"     // open space above the spans, eroded by the agent radius
+	NavBuildStageRegions   = "regions"     // monotone partition of the walkable spans
+	NavBuildStageContours  = "contours"    // simplified region outlines
+	NavBuildStagePolyMesh  = "poly_mesh"   // convex polygons
+	NavBuildStageDetail    = "detail_mesh" // height detail of the polygons
+	NavBuildStageTiles     = "tiles"       // detour tile data
+	NavBuildStageLoad      = "load"        // loading the tiles into the item
+)
+
+const (
+	navMeshSetMagic    = 'M'<<24 | 'S'<<16 | 'E'<<8 | 'T'
+	navMeshSetVersion  = 1
+	navPolyFlagWalk    = 0x01
+	navAreaGround      = 0
+	navMaxVertsPerPoly = 6 // DT_VERTS_PER_POLYGON
+	// cells of a tile with its border, about 600 units a side at the default
+	// cell size
+	navMaxTileCells = 1 << 22
+)
+
+// Primitive types of DebugDrawerPrimitive.
+const (
+	navDrawPoints = iota
+	navDrawLines
+	navDrawTris
+	navDrawQuads
+)
+
+// NavBuildParams are the Recast build settings, lengths are in world units
+// unless noted. Zero values use the defaults of the Recast demo.
+type NavBuildParams struct {
+	CellSize      float32 `json:"cell_size"`
+	CellHeight    float32 `json:"cell_height"`
+	AgentHeight   float32 `json:"agent_height"`
+	AgentRadius   float32 `json:"agent_radius"`
+	AgentMaxClimb float32 `json:"agent_max_climb"`
+	AgentMaxSlope float32 `json:"agent_max_slope"` // degrees
+	RegionMinSize int     `json:"region_min_size"` // cells along a side, smaller islands are removed
+	EdgeMaxLen    float32 `json:"edge_max_len"`    // longest wall edge, 0 after defaults never splits
+	EdgeMaxError  float32 `json:"edge_max_error"`  // cells the outline may deviate from the voxels
+	VertsPerPoly  int     `json:"verts_per_poly"`  // 3 to 6
+	TileSize      int     `json:"tile_size"`       // cells per tile side, 0 builds a single tile
+	TileCache     bool    `json:"tile_cache"`      // keep the eroded tiles for temporary obstacles, else they are made from the polygons
+	Debug         bool    `json:"debug"`           // keep the intermediate geometry of every stage
+}
+
+func (p NavBuildParams) withDefaults() NavBuildParams {
+	defaults := NavBuildParams{
+		CellSize:      0.3,
+		CellHeight:    0.2,
+		AgentHeight:   2,
+		AgentRadius:   0.6,
+		AgentMaxClimb: 0.9,
+		AgentMaxSlope: 45,
+		RegionMinSize: 8,
+		EdgeMaxLen:    12,
+		EdgeMaxError:  1.3,
+		VertsPerPoly:  navMaxVertsPerPoly,
+	}
+	if p.CellSize <= 0 {
+		p.CellSize = defaults.CellSize
+	}
+	if p.CellHeight <= 0 {
+		p.CellHeight = defaults.CellHeight
+	}
+	if p.AgentHeight <= 0 {
+		p.AgentHeight = defaults.AgentHeight
+	}
+	if p.AgentRadius <= 0 {
+		p.AgentRadius = defaults.AgentRadius
+	}
+	if p.AgentMaxClimb <= 0 {
+		p.AgentMaxClimb = defaults.AgentMaxClimb
+	}
+	if p.AgentMaxSlope <= 0 {
+		p.AgentMaxSlope = defaults.AgentMaxSlope
+	}
+	if p.RegionMinSize <= 0 {
+		p.RegionMinSize = defaults.RegionMinSize
+	}
+	if p.EdgeMaxLen <= 0 {
+		p.EdgeMaxLen = defaults.EdgeMaxLen
+	}
+	if p.EdgeMaxError <= 0 {
+		p.EdgeMaxError = defaults.EdgeMaxError
+	}
+	if p.VertsPerPoly <= 0 {
+		p.VertsPerPoly = defaults.VertsPerPoly
+	}
+	p.VertsPerPoly = clampInt(p.VertsPerPoly, 3, navMaxVertsPerPoly)
+	return p
+}
+
+// NavBuildStage is the time spent in one stage, summed over the tiles.
+type NavBuildStage struct {
+	Stage string                  `json:"stage"`
+	Time  float64                 `json:"time"`            // milliseconds
+	Debug []*DebugDrawerPrimitive `json:"debug,omitempty"` // only with NavBuildParams.Debug
+}
+
+// NavBuildResult describes a built navmesh.
+type NavBuildResult struct {
+	Id       string          `json:"id"`
+	Params   NavBuildParams  `json:"params"` // with the defaults filled in
+	Bounds   Bounds          `json:"bounds"`
+	Tiles    int             `json:"tiles"` // tiles with polygons
+	Polys    int             `json:"polys"`
+	Verts    int             `json:"verts"`
+	DataSize int             `json:"data_size"` // bytes of the navmesh file
+	Stages   []NavBuildStage `json:"stages"`
+}
+
+// navBuild is the state of one build shared by its tiles.
+type navBuild struct {
+	params    NavBuildParams
+	triangles [][3]Vec3
+	areas     []uint8
+	bounds    Bounds
+	result    *NavBuildResult
+
+	walkableHeight int
+	walkableClimb  int
+	walkableRadius int
+	borderSize     int
+
+	cache     *navTileCache // with TileCache set
+	areaTypes []navAreaType // detour area and flags by heightfield area - 1, nil for built geometry
+}
+
+// timed runs f and adds its time to stage.
+func (b *navBuild) timed(stage string, f func()) {
+	start := time.Now()
+	f()
+	b.stage(stage).Time += float64(time.Since(start).Microseconds()) / 1000
+}
+
+func (b *navBuild) stage(name string) *NavBuildStage {
+	for i := range b.result.Stages {
+		if b.result.Stages[i].Stage == name {
+			return &b.result.Stages[i]
+		}
+	}
+	b.result.Stages = append(b.result.Stages, NavBuildStage{Stage: name})
+	return &b.result.Stages[len(b.result.Stages)-1]
+}
+
+func (b *navBuild) debug(stage string, primitives ...*DebugDrawerPrimitive) {
+	if !b.params.Debug {
+		return
+	}
+	s := b.stage(stage)
+	for _, p := range primitives {
+		if len(p.Vertices) > 0 {
+			s.Debug = append(s.Debug, p)
+		}
+	}
+}
+
+// navDebugColor is a distinct color per id, like duIntToCol of Recast.
+func navDebugColor(id int, alpha float32) [4]float32 {
+	bit := func(n uint) float32 { return float32(id >> n & 1) }
+	return [4]float32{
+		(bit(1) + bit(3)*2 + 1) * 63 / 255,
+		(bit(2) + bit(4)*2 + 1) * 63 / 255,
+		(bit(0) + bit(5)*2 + 1) * 63 / 255,
+		alpha,
+	}
+}
+
+func debugVertex(x, y, z float32, color [4]float32) [7]float32 {
+	return [7]float32{x, y, z, color[0], color[1], color[2], color[3]}
+}
+
+func debugCellQuad(p *DebugDrawerPrimitive, x0, z0, cs, y float32, color [4]float32) {
+	p.Vertices = append(p.Vertices,
+		debugVertex(x0, y, z0, color),
+		debugVertex(x0, y, z0+cs, color),
+		debugVertex(x0+cs, y, z0+cs, color),
+		debugVertex(x0+cs, y, z0, color))
+}
+
+// debugHeightfield draws the tops of the spans inside the tile, walkable
+// spans in blue.
+func (b *navBuild) debugHeightfield(stage string, hf *navHeightfield) {
+	if !b.params.Debug {
+		return
+	}
+	walkable, blocked := [4]float32{0.25, 0.5, 0.63, 1}, [4]float32{0.25, 0.25, 0.25, 1}
+	p := &DebugDrawerPrimitive{Type: navDrawQuads}
+	bs := b.borderSize
+	for z := bs; z < hf.height-bs; z++ {
+		for x := bs; x < hf.width-bs; x++ {
+			for _, s := range hf.columns[x+z*hf.width] {
+				color := blocked
+				if s.area != navNullArea {
+					color = walkable
+				}
+				debugCellQuad(p, hf.bmin.X+float32(x)*hf.cs, hf.bmin.Z+float32(z)*hf.cs, hf.cs, hf.bmin.Y+float32(s.smax)*hf.ch, color)
+			}
+		}
+	}
+	b.debug(stage, p)
+}
+
+// debugCompact draws the walkable spans, colored by region when regions is
+// set.
+func (b *navBuild) debugCompact(stage string, chf *navCompactHeightfield, regions bool) {
+	if !b.params.Debug {
+		return
+	}
+	p := &DebugDrawerPrimitive{Type: navDrawQuads}
+	bs := b.borderSize
+	for z := bs; z < chf.height-bs; z++ {
+		for x := bs; x < chf.width-bs; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if chf.areas[i] == navNullArea {
+					continue
+				}
+				color := [4]float32{0, 0.75, 1, 1}
+				if regions {
+					color = navDebugColor(int(chf.spans[i].reg), 1)
+				}
+				debugCellQuad(p, chf.bmin.X+float32(x)*chf.cs, chf.bmin.Z+float32(z)*chf.cs, chf.cs, chf.bmin.Y+float32(chf.spans[i].y)*chf.ch, color)
+			}
+		}
+	}
+	b.debug(stage, p)
+}
+
+func (b *navBuild) debugContours(contours []*navContour, pm *navPolyMesh) {
+	if !b.params.Debug {
+		return
+	}
+	p := &DebugDrawerPrimitive{Type: navDrawLines}
+	for _, c := range contours {
+		color := navDebugColor(int(c.reg), 1)
+		n := len(c.verts) / 4
+		for j := 0; j < n; j++ {
+			for _, v := range [2][]int{c.verts[j*4:], c.verts[(j+1)%n*4:]} {
+				p.Vertices = append(p.Vertices, debugVertex(
+					pm.bmin.X+float32(v[0])*pm.cs,
+					pm.bmin.Y+float32(v[1]+1)*pm.ch,
+					pm.bmin.Z+float32(v[2])*pm.cs, color))
+			}
+		}
+	}
+	b.debug(NavBuildStageContours, p)
+}
+
+func (b *navBuild) debugPolyMesh(pm *navPolyMesh) {
+	if !b.params.Debug {
+		return
+	}
+	tris := &DebugDrawerPrimitive{Type: navDrawTris}
+	lines := &DebugDrawerPrimitive{Type: navDrawLines}
+	vertex := func(v uint16, color [4]float32) [7]float32 {
+		x, y, z := pm.verts[int(v)*3], pm.verts[int(v)*3+1], pm.verts[int(v)*3+2]
+		return debugVertex(pm.bmin.X+float32(x)*pm.cs, pm.bmin.Y+float32(y+1)*pm.ch, pm.bmin.Z+float32(z)*pm.cs, color)
+	}
+	for i := 0; i < pm.polyCount(); i++ {
+		p := pm.poly(i)
+		n := countPolyVerts(p, pm.nvp)
+		color := navDebugColor(int(pm.regs[i]), 0.75)
+		for j := 1; j+1 < n; j++ {
+			tris.Vertices = append(tris.Vertices, vertex(p[0], color), vertex(p[j], color), vertex(p[j+1], color))
+		}
+		edge := [4]float32{0, 0.19, 0.25, 1}
+		for j := 0; j < n; j++ {
+			lines.Vertices = append(lines.Vertices, vertex(p[j], edge), vertex(p[(j+1)%n], edge))
+		}
+	}
+	b.debug(NavBuildStagePolyMesh, tris, lines)
+}
+
+func (b *navBuild) debugDetailMesh(dm *navDetailMesh) {
+	if !b.params.Debug {
+		return
+	}
+	p := &DebugDrawerPrimitive{Type: navDrawTris}
+	for m := 0; m+3 < len(dm.meshes); m += 4 {
+		vbase, tbase, tcount := dm.meshes[m], dm.meshes[m+2], dm.meshes[m+3]
+		color := navDebugColor(m/4, 0.75)
+		for t := tbase; t < tbase+tcount; t++ {
+			for k := uint32(0); k < 3; k++ {
+				v := (vbase + uint32(dm.tris[t*4+k])) * 3
+				p.Vertices = append(p.Vertices, debugVertex(dm.verts[v], dm.verts[v+1], dm.verts[v+2], color))
+			}
+		}
+	}
+	b.debug(NavBuildStageDetail, p)
+}
+
+func newNavBuild(params NavBuildParams, triangles []Triangle) (*navBuild, error) {
+	if len(triangles) == 0 {
+		return nil, errors.New("no triangles to build from")
+	}
+	params = params.withDefaults()
+	b := &navBuild{
+		params:         params,
+		triangles:      make([][3]Vec3, len(triangles)),
+		areas:          make([]uint8, len(triangles)),
+		result:         &NavBuildResult{Params: params},
+		walkableHeight: int(math.Ceil(float64(params.AgentHeight / params.CellHeight))),
+		walkableClimb:  int(math.Floor(float64(params.AgentMaxClimb / params.CellHeight))),
+		walkableRadius: int(math.Ceil(float64(params.AgentRadius / params.CellSize))),
+	}
+	if params.TileSize > 0 {
+		b.borderSize = b.walkableRadius + 3
+	}
+
+	for i, tri := range triangles {
+		for _, v := range []Vec3{tri.A, tri.B, tri.C} {
+			if !isFinite(v.X) || !isFinite(v.Y) || !isFinite(v.Z) {
+				return nil, fmt.Errorf("triangle %d has a vertex that is not finite", i)
+			}
+		}
+		b.triangles[i] = [3]Vec3{tri.A, tri.B, tri.C}
+		b.areas[i] = walkableArea(b.triangles[i], params.AgentMaxSlope)
+		bounds := polyBounds(b.triangles[i][:])
+		if i == 0 {
+			b.bounds = bounds
+		} else {
+			b.bounds = boundsUnion(b.bounds, bounds)
+		}
+	}
+	b.result.Bounds = b.bounds
+	if params.TileCache {
+		b.cache = newNavTileCache(b)
+	}
+	return b, nil
+}
+
+func isFinite(f float32) bool {
+	return !math.IsNaN(float64(f)) && !math.IsInf(float64(f), 0)
+}
+
+// buildTile runs the Recast stages on one tile and creates its detour
+// data, nil when the tile has no polygons.
+func (b *navBuild) buildTile(tx, tz, width, height int) ([]byte, *navPolyMesh, error) {
+	p := b.params
+	bs := b.borderSize
+	tileSize := float32(max(p.TileSize, 0)) * p.CellSize
+	bmin := Vec3{
+		X: b.bounds.Min.X + float32(tx)*tileSize - float32(bs)*p.CellSize,
+		Y: b.bounds.Min.Y,
+		Z: b.bounds.Min.Z + float32(tz)*tileSize - float32(bs)*p.CellSize,
+	}
+	bmax := Vec3{
+		X: bmin.X + float32(width+bs*2)*p.CellSize,
+		Y: b.bounds.Max.Y,
+		Z: bmin.Z + float32(height+bs*2)*p.CellSize,
+	}
+	hf := newNavHeightfield(width+bs*2, height+bs*2, bmin, bmax, p.CellSize, p.CellHeight)
+
+	b.timed(NavBuildStageRasterize, func() {
+		for i, tri := range b.triangles {
+			hf.rasterizeTriangle(tri, b.areas[i], b.walkableClimb)
+		}
+	})
+	b.debugHeightfield(NavBuildStageRasterize, hf)
+
+	b.timed(NavBuildStageFilter, func() {
+		hf.filterLowHangingObstacles(b.walkableClimb)
+		hf.filterLedgeSpans(b.walkableHeight, b.walkableClimb)
+		hf.filterLowHeightSpans(b.walkableHeight)
+	})
+	b.debugHeightfield(NavBuildStageFilter, hf)
+
+	var chf *navCompactHeightfield
+	b.timed(NavBuildStageCompact, func() {
+		chf = hf.compact(b.walkableHeight, b.walkableClimb)
+		chf.borderSize = bs
+		chf.erodeWalkableArea(b.walkableRadius)
+	})
+	b.debugCompact(NavBuildStageCompact, chf, false)
+	if b.cache != nil {
+		b.cache.add(tx, tz, chf)
+	}
+	return b.buildTileMesh(chf, tx, tz, nil)
+}
+
+// buildTileMesh runs the stages from the regions on and creates the detour
+// data of a tile with the off-mesh connections links.
+func (b *navBuild) buildTileMesh(chf *navCompactHeightfield, tx, tz int, links []NavOffMeshLink) ([]byte, *navPolyMesh, error) {
+	p := b.params
+	b.timed(NavBuildStageRegions, func() {
+		chf.buildRegionsMonotone(p.RegionMinSize * p.RegionMinSize)
+	})
+	b.debugCompact(NavBuildStageRegions, chf, true)
+
+	var contours []*navContour
+	b.timed(NavBuildStageContours, func() {
+		contours = chf.buildContours(p.EdgeMaxError, int(p.EdgeMaxLen/p.CellSize))
+	})
+
+	var pm *navPolyMesh
+	b.timed(NavBuildStagePolyMesh, func() {
+		pm = chf.buildPolyMesh(contours, p.VertsPerPoly)
+	})
+	b.debugContours(contours, pm)
+	b.debugPolyMesh(pm)
+	if pm.polyCount() == 0 {
+		return nil, pm, nil
+	}
+	if len(pm.verts)/3 >= navMeshNullIdx || pm.polyCount() >= navMeshNullIdx {
+		return nil, nil, fmt.Errorf("tile %d,%d has too many vertices or polygons, use a smaller tile size", tx, tz)
+	}
+
+	var dm *navDetailMesh
+	b.timed(NavBuildStageDetail, func() {
+		dm = pm.buildDetailMesh()
+	})
+	b.debugDetailMesh(dm)
+
+	var data []byte
+	var err error
+	b.timed(NavBuildStageTiles, func() {
+		data, err = b.createTileData(pm, dm, tx, tz, links)
+	})
+	return data, pm, err
+}
+
+// createTileData converts the meshes of a tile into detour tile data.
+func (b *navBuild) createTileData(pm *navPolyMesh, dm *navDetailMesh, tx, tz int, links []NavOffMeshLink) ([]byte, error) {
+	npolys := pm.polyCount()
+	params := &detour.DtNavMeshCreateParams{
+		Verts:            pm.verts,
+		VertCount:        len(pm.verts) / 3,
+		Polys:            pm.polys,
+		PolyFlags:        make([]uint16, npolys),
+		PolyAreas:        make([]uint8, npolys),
+		PolyCount:        npolys,
+		Nvp:              pm.nvp,
+		DetailMeshes:     dm.meshes,
+		DetailVerts:      dm.verts,
+		DetailVertsCount: len(dm.verts) / 3,
+		DetailTris:       dm.tris,
+		DetailTriCount:   len(dm.tris) / 4,
+		TileX:            int32(tx),
+		TileY:            int32(tz),
+		Bmin:             [3]float32{pm.bmin.X, pm.bmin.Y, pm.bmin.Z},
+		Bmax:             [3]float32{pm.bmax.X, pm.bmax.Y, pm.bmax.Z},
+		WalkableHeight:   b.params.AgentHeight,
+		WalkableRadius:   b.params.AgentRadius,
+		WalkableClimb:    b.params.AgentMaxClimb,
+		Cs:               pm.cs,
+		Ch:               pm.ch,
+		BuildBvTree:      true,
+	}
+	for i := 0; i < npolys; i++ {
+		if at, ok := b.areaType(pm.areas[i]); ok {
+			params.PolyAreas[i] = at.area
+			params.PolyFlags[i] = at.flags
+		}
+	}
+	addOffMeshParams(params, links)
+
+	var data []byte
+	var dataSize int
+	if !detour.DtCreateNavMeshData(params, &data, &dataSize) {
+		return nil, fmt.Errorf("create navmesh data of tile %d,%d failed", tx, tz)
+	}
+	return data[:dataSize], nil
+}
+
+// navMeshSetHeader and navMeshTileHeader are the layout of the tiled
+// navmesh files of the Recast demo, little endian.
+type navMeshSetHeader struct {
+	Magic      int32
+	Version    int32
+	NumTiles   int32
+	Orig       [3]float32
+	TileWidth  float32
+	TileHeight float32
+	MaxTiles   int32
+	MaxPolys   int32
+}
+
+type navMeshTileHeader struct {
+	TileRef  uint32
+	DataSize int32
+}
+
+func nextPow2(v int) int {
+	if v <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(v-1))
+}
+
+// writeNavMeshSet packs tiles into a NavTypeTileMesh file, refs are the
+// tile refs to restore on load and may be nil for new tiles.
+func writeNavMeshSet(header navMeshSetHeader, tiles [][]byte, refs []detour.DtTileRef) []byte {
+	var buf bytes.Buffer
+	header.Magic = navMeshSetMagic
+	header.Version = navMeshSetVersion
+	header.NumTiles = int32(len(tiles))
+	binary.Write(&buf, binary.LittleEndian, &header)
+	for i, tile := range tiles {
+		tileHeader := navMeshTileHeader{DataSize: int32(len(tile))}
+		if refs != nil {
+			tileHeader.TileRef = uint32(refs[i])
+		}
+		binary.Write(&buf, binary.LittleEndian, &tileHeader)
+		buf.Write(tile)
+	}
+	return buf.Bytes()
+}
+
+// buildNavMesh builds a NavTypeTileMesh file from triangles, with its tile
+// cache when params.TileCache is set.
+func buildNavMesh(params NavBuildParams, triangles []Triangle) ([]byte, *NavBuildResult, *navTileCache, error) {
+	b, err := newNavBuild(params, triangles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	p := b.params
+	for _, stage := range []string{NavBuildStageRasterize, NavBuildStageFilter, NavBuildStageCompact, NavBuildStageRegions,
+		NavBuildStageContours, NavBuildStagePolyMesh, NavBuildStageDetail, NavBuildStageTiles} {
+		b.stage(stage)
+	}
+
+	// the grid is checked in float64 so that huge bounds can't overflow
+	gridW := math.Max(math.Round((float64(b.bounds.Max.X)-float64(b.bounds.Min.X))/float64(p.CellSize)), 1)
+	gridH := math.Max(math.Round((float64(b.bounds.Max.Z)-float64(b.bounds.Min.Z))/float64(p.CellSize)), 1)
+	tileCells := (gridW + float64(2*b.borderSize)) * (gridH + float64(2*b.borderSize))
+	if p.TileSize > 0 {
+		tileSize := float64(p.TileSize)
+		tileCells = (tileSize + float64(2*b.borderSize)) * (tileSize + float64(2*b.borderSize))
+		// polygon refs have 22 bits for the tile and polygon index
+		if math.Ceil(gridW/tileSize)*math.Ceil(gridH/tileSize) > 1<<22 {
+			return nil, nil, nil, errors.New("too many tiles for 32 bit polygon refs, raise tile_size")
+		}
+	}
+	if tileCells > navMaxTileCells {
+		return nil, nil, nil, fmt.Errorf("a tile of %.0f cells is over the limit of %d, set tile_size to build in smaller tiles", tileCells, navMaxTileCells)
+	}
+
+	gw, gh := int(gridW), int(gridH)
+	tileW, tileH, tw, th := gw, gh, 1, 1
+	if p.TileSize > 0 {
+		tileW, tileH = p.TileSize, p.TileSize
+		tw, th = (gw+p.TileSize-1)/p.TileSize, (gh+p.TileSize-1)/p.TileSize
+	}
+
+	var tiles [][]byte
+	maxPolys := 0
+	for tz := 0; tz < th; tz++ {
+		for tx := 0; tx < tw; tx++ {
+			data, pm, err := b.buildTile(tx, tz, tileW, tileH)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if data == nil {
+				continue
+			}
+			tiles = append(tiles, data)
+			maxPolys = max(maxPolys, pm.polyCount())
+			b.result.Polys += pm.polyCount()
+			b.result.Verts += len(pm.verts) / 3
+		}
+	}
+	if len(tiles) == 0 {
+		return nil, nil, nil, errors.New("the build produced no polygons")
+	}
+
+	// polygon refs have 22 bits for the tile and polygon index
+	tileBits := bits.Len(uint(nextPow2(tw*th) - 1))
+	polyBits := bits.Len(uint(nextPow2(maxPolys) - 1))
+	if tileBits+polyBits > 22 {
+		return nil, nil, nil, errors.New("too many tiles and polygons per tile for 32 bit polygon refs")
+	}
+
+	data := writeNavMeshSet(navMeshSetHeader{
+		Orig:       [3]float32{b.bounds.Min.X, b.bounds.Min.Y, b.bounds.Min.Z},
+		TileWidth:  float32(tileW) * p.CellSize,
+		TileHeight: float32(tileH) * p.CellSize,
+		MaxTiles:   int32(nextPow2(tw * th)),
+		MaxPolys:   int32(nextPow2(maxPolys)),
+	}, tiles, nil)
+	b.result.Tiles = len(tiles)
+	b.result.DataSize = len(data)
+	return data, b.result, b.cache, nil
+}
+
+// Build builds a navmesh from triangles and loads it as item id, an
+// existing item with that id is replaced.
+func (m *NavMgr) Build(id string, params NavBuildParams, triangles []Triangle) (*NavBuildResult, error) {
+	data, result, cache, err := buildNavMesh(params, triangles)
+	if err != nil {
+		return nil, err
+	}
+	result.Id = id
+
+	start := time.Now()
+	item, err := newNavMeshItem(id, NavTypeTileMesh, data)
+	if err != nil {
+		return nil, err
+	}
+	item.tileCache = cache
+	result.Stages = append(result.Stages, NavBuildStage{
+		Stage: NavBuildStageLoad,
+		Time:  float64(time.Since(start).Microseconds()) / 1000,
+	})
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if old, ok := m.navItems[id]; ok {
+		old.stopSimulation()
+		old.stopRecording()
+	}
+	m.navItems[id] = item
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// the obj scenes are 10 by 10 units, the lower half at y = 0 and the upper
+// half at the height of the variant
+const (
+	navTestLowerObj = "v -5 0 -5\nv -5 0 5\nv 0 0 5\nv 0 0 -5\n"
+	navTestFlatObj  = navTestLowerObj + "v 5 0 5\nv 5 0 -5\nf 1 2 3 4\nf 4 3 5 6\n"
+	navTestRampObj  = navTestLowerObj + "v 5 2 5\nv 5 2 -5\nf 1 2 3 4\nf 4 3 5 6\n"
+	// the riser between the halves is a vertical face
+	navTestStepObj  = navTestLowerObj + "v 0 0.4 -5\nv 0 0.4 5\nv 5 0.4 5\nv 5 0.4 -5\nf 1 2 3 4\nf 4 3 6 5\nf 5 6 7 8\n"
+	navTestCliffObj = navTestLowerObj + "v 0 1.5 -5\nv 0 1.5 5\nv 5 1.5 5\nv 5 1.5 -5\nf 1 2 3 4\nf 4 3 6 5\nf 5 6 7 8\n"
+)
+
+// buildTestNavMesh builds an obj scene as the item "scene" of a new NavMgr.
+func buildTestNavMesh(t *testing.T, obj string, params NavBuildParams) (*NavMgr, *NavBuildResult) {
+	t.Helper()
+	mesh, err := ParseObj(strings.NewReader(obj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewNavMgr()
+	result, err := m.Build("scene", params, mesh.Triangles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, result
+}
+
+func TestNavBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     string
+		params  NavBuildParams
+		tiles   int
+		polys   [2]int     // min and max
+		area    [2]float32 // min and max of the polygons in the xz plane
+		end     Vec3
+		partial bool
+	}{
+		// the 10 by 10 floor loses about the agent radius of 0.6 at each side,
+		// 11 cells split its 33 into 3 tiles a side
+		{name: "flat plane", obj: navTestFlatObj, tiles: 1, polys: [2]int{1, 1}, area: [2]float32{70, 80}, end: Vec3{X: 3.5, Z: 3.5}},
+		{name: "flat plane in tiles", obj: navTestFlatObj, params: NavBuildParams{TileSize: 11}, tiles: 9, polys: [2]int{9, 40}, area: [2]float32{70, 80}, end: Vec3{X: 3.5, Z: 3.5}},
+		{name: "ramp", obj: navTestRampObj, tiles: 1, polys: [2]int{1, 12}, area: [2]float32{70, 80}, end: Vec3{X: 3.5, Y: 1.4, Z: 3.5}},
+		{name: "step", obj: navTestStepObj, tiles: 1, polys: [2]int{1, 12}, area: [2]float32{70, 80}, end: Vec3{X: 3.5, Y: 0.4, Z: 3.5}},
+		// too high to climb, both halves are eroded at the cliff
+		{name: "cliff", obj: navTestCliffObj, tiles: 1, polys: [2]int{2, 12}, area: [2]float32{55, 75}, end: Vec3{X: 3.5, Y: 1.5, Z: 3.5}, partial: true},
+	}
+	start := Vec3{X: -3.5, Z: -3.5}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, result := buildTestNavMesh(t, test.obj, test.params)
+			if result.Tiles != test.tiles {
+				t.Fatalf("got %d tiles, want %d", result.Tiles, test.tiles)
+			}
+			if result.Polys < test.polys[0] || result.Polys > test.polys[1] {
+				t.Fatalf("got %d polygons, want %d to %d", result.Polys, test.polys[0], test.polys[1])
+			}
+
+			// the detour data loaded with the polygons of the build
+			var polys int
+			var area float32
+			err := m.navItems["scene"].forEachPoly(func(ref detour.DtPolyRef, tile *detour.DtMeshTile, poly *detour.DtPoly) {
+				polys++
+				verts := polyVerts(tile, poly)
+				for i := range verts {
+					a, b := verts[i], verts[(i+1)%len(verts)]
+					area += (a.X*b.Z - b.X*a.Z) / 2
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if polys != result.Polys {
+				t.Fatalf("loaded %d polygons, the build made %d", polys, result.Polys)
+			}
+			if area < 0 {
+				area = -area
+			}
+			if area < test.area[0] || area > test.area[1] {
+				t.Fatalf("got a walkable area of %v, want %v to %v", area, test.area[0], test.area[1])
+			}
+
+			path, err := m.FindPath("scene", start, test.end, NavQueryFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path.Partial != test.partial {
+				t.Fatalf("got partial %v, want %v", path.Partial, test.partial)
+			}
+			last := path.Points[len(path.Points)-1]
+			if reached := abs32(last.X-test.end.X) < 0.1 && abs32(last.Z-test.end.Z) < 0.1; reached == test.partial {
+				t.Fatalf("path ends at %v, partial %v", last, test.partial)
+			}
+		})
+	}
+}
+
+func TestNavBuildInvalid(t *testing.T) {
+	floor := []Triangle{
+		{A: Vec3{X: -5, Z: -5}, B: Vec3{X: -5, Z: 5}, C: Vec3{X: 5, Z: 5}},
+		{A: Vec3{X: -5, Z: -5}, B: Vec3{X: 5, Z: 5}, C: Vec3{X: 5, Z: -5}},
+	}
+	withVertex := func(v Vec3) []Triangle {
+		return append([]Triangle{{A: Vec3{}, B: Vec3{X: 1}, C: v}}, floor...)
+	}
+	tests := []struct {
+		name      string
+		params    NavBuildParams
+		triangles []Triangle
+		err       string
+	}{
+		{name: "no triangles", err: "no triangles"},
+		{name: "nan vertex", triangles: withVertex(Vec3{Y: float32(math.NaN())}), err: "triangle 0 has a vertex that is not finite"},
+		{name: "infinite vertex", triangles: withVertex(Vec3{X: float32(math.Inf(-1))}), err: "not finite"},
+		// 10 units are 2500 cells a side
+		{name: "single tile too large", params: NavBuildParams{CellSize: 0.004}, triangles: floor, err: "set tile_size"},
+		{name: "tile size too large", params: NavBuildParams{TileSize: 5000}, triangles: floor, err: "set tile_size"},
+		{name: "huge bounds", params: NavBuildParams{TileSize: 64}, triangles: withVertex(Vec3{X: 3e38, Z: -3e38}), err: "too many tiles"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewNavMgr()
+			if _, err := m.Build("scene", test.params, test.triangles); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %v, want %q", err, test.err)
+			}
+			if _, ok := m.navItems["scene"]; ok {
+				t.Fatal("the failed build added the item")
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+// Flags on the fourth value of contour vertices, the low 16 bits hold the
+// region on the other side of the edge starting at the vertex.
+const (
+	navContourRegMask = 0xffff
+	navBorderVertex   = 0x10000 // on the tile border between two regions
+	navAreaBorder     = 0x20000 // the edge separates two areas
+)
+
+// navContour is the simplified outline of a region in cell coordinates,
+// x, y, z and flags per vertex.
+type navContour struct {
+	verts []int
+	raw   []int
+	reg   uint16
+	area  uint8
+}
+
+// cornerHeight is the height of the corner between dir and the next
+// direction, the highest of the 4 spans around it.
+func (chf *navCompactHeightfield) cornerHeight(x, z, i, dir int) (int, bool) {
+	s := &chf.spans[i]
+	height := int(s.y)
+	dirp := (dir + 1) & 3
+
+	var regs [4]uint32
+	regs[0] = uint32(s.reg) | uint32(chf.areas[i])<<16
+	if ni := chf.neighbour(x, z, i, dir); ni >= 0 {
+		nx, nz := x+navDirX[dir], z+navDirZ[dir]
+		height = max(height, int(chf.spans[ni].y))
+		regs[1] = uint32(chf.spans[ni].reg) | uint32(chf.areas[ni])<<16
+		if nni := chf.neighbour(nx, nz, ni, dirp); nni >= 0 {
+			height = max(height, int(chf.spans[nni].y))
+			regs[2] = uint32(chf.spans[nni].reg) | uint32(chf.areas[nni])<<16
+		}
+	}
+	if ni := chf.neighbour(x, z, i, dirp); ni >= 0 {
+		nx, nz := x+navDirX[dirp], z+navDirZ[dirp]
+		height = max(height, int(chf.spans[ni].y))
+		regs[3] = uint32(chf.spans[ni].reg) | uint32(chf.areas[ni])<<16
+		if nni := chf.neighbour(nx, nz, ni, dir); nni >= 0 {
+			height = max(height, int(chf.spans[nni].y))
+			regs[2] = uint32(chf.spans[nni].reg) | uint32(chf.areas[nni])<<16
+		}
+	}
+
+	// two cells of the same border region next to two interior cells of
+	// the same area
+	for j := 0; j < 4; j++ {
+		a, b, c, d := regs[j], regs[(j+1)&3], regs[(j+2)&3], regs[(j+3)&3]
+		twoSameExts := a&b&navBorderReg != 0 && a == b
+		twoInts := (c|d)&navBorderReg == 0
+		intsSameArea := c>>16 == d>>16
+		noZeros := a != 0 && b != 0 && c != 0 && d != 0
+		if twoSameExts && twoInts && intsSameArea && noZeros {
+			return height, true
+		}
+	}
+	return height, false
+}
+
+// walkContour follows the region border clockwise from span i and returns
+// the raw contour, clearing the walked edges from flags.
+func (chf *navCompactHeightfield) walkContour(x, z, i int, flags []uint8) []int {
+	dir := 0
+	for flags[i]&(1<<uint(dir)) == 0 {
+		dir++
+	}
+	startDir, starti := dir, i
+	area := chf.areas[i]
+
+	var points []int
+	for iter := 0; iter < 40000; iter++ {
+		if flags[i]&(1<<uint(dir)) != 0 {
+			height, borderVertex := chf.cornerHeight(x, z, i, dir)
+			px, pz := x, z
+			switch dir {
+			case 0:
+				pz++
+			case 1:
+				px++
+				pz++
+			case 2:
+				px++
+			}
+			r := 0
+			if ni := chf.neighbour(x, z, i, dir); ni >= 0 {
+				r = int(chf.spans[ni].reg)
+				if area != chf.areas[ni] {
+					r |= navAreaBorder
+				}
+			}
+			if borderVertex {
+				r |= navBorderVertex
+			}
+			points = append(points, px, height, pz, r)
+			flags[i] &^= 1 << uint(dir)
+			dir = (dir + 1) & 3 // rotate clockwise
+		} else {
+			ni := chf.neighbour(x, z, i, dir)
+			if ni < 0 {
+				// a border edge without a flag, should not happen
+				return points
+			}
+			x, z, i = x+navDirX[dir], z+navDirZ[dir], ni
+			dir = (dir + 3) & 3 // rotate counter clockwise
+		}
+		if starti == i && startDir == dir {
+			break
+		}
+	}
+	return points
+}
+
+// distancePtSeg2D is the squared distance from (x, z) to the segment p-q.
+func distancePtSeg2D(x, z, px, pz, qx, qz int) float32 {
+	pqx, pqz := float32(qx-px), float32(qz-pz)
+	dx, dz := float32(x-px), float32(z-pz)
+	d := pqx*pqx + pqz*pqz
+	t := pqx*dx + pqz*dz
+	if d > 0 {
+		t /= d
+	}
+	t = max(0, min(t, 1))
+	dx = float32(px) + t*pqx - float32(x)
+	dz = float32(pz) + t*pqz - float32(z)
+	return dx*dx + dz*dz
+}
+
+// simplifyContour keeps the vertices where the neighbour region changes and
+// adds raw vertices until the outline is within maxError of the raw one.
+// Wall edges longer than maxEdgeLen are split. The fourth value of the
+// simplified vertices is the index of the raw vertex while it runs.
+func simplifyContour(points []int, maxError float32, maxEdgeLen int) []int {
+	pn := len(points) / 4
+	var simplified []int
+
+	hasConnections := false
+	for i := 0; i < pn; i++ {
+		if points[i*4+3]&navContourRegMask != 0 {
+			hasConnections = true
+			break
+		}
+	}
+	if hasConnections {
+		for i := 0; i < pn; i++ {
+			ii := (i + 1) % pn
+			differentRegs := points[i*4+3]&navContourRegMask != points[ii*4+3]&navContourRegMask
+			areaBorders := points[i*4+3]&navAreaBorder != points[ii*4+3]&navAreaBorder
+			if differentRegs || areaBorders {
+				simplified = append(simplified, points[i*4], points[i*4+1], points[i*4+2], i)
+			}
+		}
+	}
+	if len(simplified) == 0 {
+		// an island, start with the lower left and upper right vertices
+		ll, ur := 0, 0
+		for i := 0; i < pn; i++ {
+			x, z := points[i*4], points[i*4+2]
+			if x < points[ll*4] || (x == points[ll*4] && z < points[ll*4+2]) {
+				ll = i
+			}
+			if x > points[ur*4] || (x == points[ur*4] && z > points[ur*4+2]) {
+				ur = i
+			}
+		}
+		simplified = append(simplified, points[ll*4], points[ll*4+1], points[ll*4+2], ll)
+		simplified = append(simplified, points[ur*4], points[ur*4+1], points[ur*4+2], ur)
+	}
+
+	insert := func(at, raw int) {
+		v := []int{points[raw*4], points[raw*4+1], points[raw*4+2], raw}
+		simplified = append(simplified[:at*4], append(v, simplified[at*4:]...)...)
+	}
+
+	for i := 0; i < len(simplified)/4; {
+		ii := (i + 1) % (len(simplified) / 4)
+		ax, az, ai := simplified[i*4], simplified[i*4+2], simplified[i*4+3]
+		bx, bz, bi := simplified[ii*4], simplified[ii*4+2], simplified[ii*4+3]
+
+		// walk the segment in lexicographic order so that opposite
+		// segments of two regions are simplified the same way
+		var ci, cinc, endi int
+		if bx > ax || (bx == ax && bz > az) {
+			cinc = 1
+			ci = (ai + cinc) % pn
+			endi = bi
+		} else {
+			cinc = pn - 1
+			ci = (bi + cinc) % pn
+			endi = ai
+			ax, bx = bx, ax
+			az, bz = bz, az
+		}
+
+		maxd, maxi := float32(0), -1
+		// only outer edges and edges between areas are tessellated
+		if points[ci*4+3]&navContourRegMask == 0 || points[ci*4+3]&navAreaBorder != 0 {
+			for ci != endi {
+				if d := distancePtSeg2D(points[ci*4], points[ci*4+2], ax, az, bx, bz); d > maxd {
+					maxd, maxi = d, ci
+				}
+				ci = (ci + cinc) % pn
+			}
+		}
+		if maxi != -1 && maxd > maxError*maxError {
+			insert(i+1, maxi)
+		} else {
+			i++
+		}
+	}
+
+	if maxEdgeLen > 0 {
+		for i := 0; i < len(simplified)/4; {
+			ii := (i + 1) % (len(simplified) / 4)
+			ax, az, ai := simplified[i*4], simplified[i*4+2], simplified[i*4+3]
+			bx, bz, bi := simplified[ii*4], simplified[ii*4+2], simplified[ii*4+3]
+
+			maxi := -1
+			ci := (ai + 1) % pn
+			if points[ci*4+3]&navContourRegMask == 0 {
+				dx, dz := bx-ax, bz-az
+				if dx*dx+dz*dz > maxEdgeLen*maxEdgeLen {
+					n := bi - ai
+					if bi < ai {
+						n = bi + pn - ai
+					}
+					if n > 1 {
+						if bx > ax || (bx == ax && bz > az) {
+							maxi = (ai + n/2) % pn
+						} else {
+							maxi = (ai + (n+1)/2) % pn
+						}
+					}
+				}
+			}
+			if maxi != -1 {
+				insert(i+1, maxi)
+			} else {
+				i++
+			}
+		}
+	}
+
+	// the neighbour region comes from the next raw vertex, the border
+	// vertex flag from the vertex itself
+	for i := 0; i < len(simplified)/4; i++ {
+		ai := (simplified[i*4+3] + 1) % pn
+		bi := simplified[i*4+3]
+		simplified[i*4+3] = points[ai*4+3]&(navContourRegMask|navAreaBorder) | points[bi*4+3]&navBorderVertex
+	}
+	return simplified
+}
+
+// removeDegenerateSegments drops vertices equal to the next one in 2D.
+func removeDegenerateSegments(verts []int) []int {
+	for i := 0; i < len(verts)/4; i++ {
+		ni := (i + 1) % (len(verts) / 4)
+		if verts[i*4] == verts[ni*4] && verts[i*4+2] == verts[ni*4+2] {
+			verts = append(verts[:i*4], verts[i*4+4:]...)
+			i--
+		}
+	}
+	return verts
+}
+
+// buildContours outlines every region, the border cells of a tile are
+// removed from the coordinates.
+func (chf *navCompactHeightfield) buildContours(maxError float32, maxEdgeLen int) []*navContour {
+	flags := make([]uint8, len(chf.spans))
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				reg := chf.spans[i].reg
+				if reg == 0 || reg&navBorderReg != 0 {
+					continue
+				}
+				connected := uint8(0)
+				for dir := 0; dir < 4; dir++ {
+					if ni := chf.neighbour(x, z, i, dir); ni >= 0 && chf.spans[ni].reg == reg {
+						connected |= 1 << uint(dir)
+					}
+				}
+				flags[i] = connected ^ 0xf // the edges on the region border
+			}
+		}
+	}
+
+	var contours []*navContour
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if flags[i] == 0 || flags[i] == 0xf {
+					flags[i] = 0
+					continue
+				}
+				reg := chf.spans[i].reg
+				if reg == 0 || reg&navBorderReg != 0 {
+					continue
+				}
+
+				raw := chf.walkContour(x, z, i, flags)
+				if len(raw) < 12 {
+					continue
+				}
+				verts := removeDegenerateSegments(simplifyContour(raw, maxError, maxEdgeLen))
+				if len(verts) < 12 {
+					continue
+				}
+				if bs := chf.borderSize; bs > 0 {
+					for _, v := range [][]int{verts, raw} {
+						for j := 0; j < len(v); j += 4 {
+							v[j] -= bs
+							v[j+2] -= bs
+						}
+					}
+				}
+				contours = append(contours, &navContour{
+					verts: verts,
+					raw:   raw,
+					reg:   reg,
+					area:  chf.areas[i],
+				})
+			}
+		}
+	}
+	return contours
+}
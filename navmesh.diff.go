@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// Kinds of NavDiffRegion.
+const (
+	NavDiffAdded   = "added"
+	NavDiffRemoved = "removed"
+	NavDiffChanged = "changed" // same outline, different area or flags
+
+	navDiffUnchanged = "unchanged"
+)
+
+// navDiffPrecision is the grid polygon vertices are snapped to before they
+// are compared, in meters.
+const navDiffPrecision = 0.01
+
+// Overlay colors of the diff kinds.
+var navDiffColors = map[string][4]float32{
+	NavDiffAdded:   {0.2, 0.9, 0.3, 0.5},
+	NavDiffRemoved: {0.95, 0.2, 0.2, 0.5},
+	NavDiffChanged: {1, 0.8, 0.1, 0.5},
+}
+
+// NavDiffRegion is a set of connected polygons with the same kind of
+// change. Removed polygons are refs of the first navmesh, added and changed
+// ones refs of the second.
+type NavDiffRegion struct {
+	Kind   string   `json:"kind"`
+	Polys  []uint64 `json:"polys"`
+	Area   float32  `json:"area"` // square meters
+	Bounds Bounds   `json:"bounds"`
+}
+
+// NavMeshDiff is the difference from navmesh IdA to navmesh IdB.
+type NavMeshDiff struct {
+	IdA            string                  `json:"id_a"`
+	IdB            string                  `json:"id_b"`
+	TilesAdded     int                     `json:"tiles_added"`
+	TilesRemoved   int                     `json:"tiles_removed"`
+	TilesChanged   int                     `json:"tiles_changed"`
+	PolysAdded     int                     `json:"polys_added"`
+	PolysRemoved   int                     `json:"polys_removed"`
+	PolysChanged   int                     `json:"polys_changed"`
+	PolysUnchanged int                     `json:"polys_unchanged"`
+	AddedArea      float32                 `json:"added_area"`
+	RemovedArea    float32                 `json:"removed_area"`
+	ChangedArea    float32                 `json:"changed_area"`
+	Regions        []NavDiffRegion         `json:"regions"`
+	Primitives     []*DebugDrawerPrimitive `json:"primitives"`
+}
+
+type navDiffPoly struct {
+	ref   detour.DtPolyRef
+	tile  *detour.DtMeshTile
+	poly  *detour.DtPoly
+	verts []Vec3
+	key   string
+	kind  string
+}
+
+type navDiffTile struct {
+	polys []*navDiffPoly
+	byKey map[string][]*navDiffPoly
+}
+
+// navDiffKey is the outline of a polygon snapped to navDiffPrecision,
+// starting at its smallest vertex so the same outline has the same key in
+// both navmeshes.
+func navDiffKey(verts []Vec3) string {
+	snapped := make([]string, len(verts))
+	first := 0
+	for i, v := range verts {
+		snapped[i] = fmt.Sprintf("%d,%d,%d",
+			int64(math.Round(float64(v.X)/navDiffPrecision)),
+			int64(math.Round(float64(v.Y)/navDiffPrecision)),
+			int64(math.Round(float64(v.Z)/navDiffPrecision)))
+		if snapped[i] < snapped[first] {
+			first = i
+		}
+	}
+	return strings.Join(append(snapped[first:], snapped[:first]...), " ")
+}
+
+// diffTiles collects the ground polygons of the item per tile position.
+func (item *NavMeshItem) diffTiles() (map[[3]int32]*navDiffTile, map[detour.DtPolyRef]*navDiffPoly, error) {
+	tiles := make(map[[3]int32]*navDiffTile)
+	polys := make(map[detour.DtPolyRef]*navDiffPoly)
+	err := item.forEachPoly(func(ref detour.DtPolyRef, tile *detour.DtMeshTile, poly *detour.DtPoly) {
+		if poly.GetType() == detour.DT_POLYTYPE_OFFMESH_CONNECTION {
+			return
+		}
+		pos := [3]int32{tile.Header.X, tile.Header.Y, tile.Header.Layer}
+		t, ok := tiles[pos]
+		if !ok {
+			t = &navDiffTile{byKey: make(map[string][]*navDiffPoly)}
+			tiles[pos] = t
+		}
+		p := &navDiffPoly{ref: ref, tile: tile, poly: poly, verts: polyVerts(tile, poly)}
+		p.key = navDiffKey(p.verts)
+		t.polys = append(t.polys, p)
+		t.byKey[p.key] = append(t.byKey[p.key], p)
+		polys[ref] = p
+	})
+	return tiles, polys, err
+}
+
+// diffRegions groups the polygons of kind connected by links into regions.
+func diffRegions(polys map[detour.DtPolyRef]*navDiffPoly, kind string) []NavDiffRegion {
+	var refs []detour.DtPolyRef
+	for ref, p := range polys {
+		if p.kind == kind {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+
+	var regions []NavDiffRegion
+	visited := make(map[detour.DtPolyRef]bool)
+	for _, start := range refs {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		region := NavDiffRegion{Kind: kind, Bounds: polyBounds(polys[start].verts)}
+		stack := []detour.DtPolyRef{start}
+		for len(stack) > 0 {
+			p := polys[stack[len(stack)-1]]
+			stack = stack[:len(stack)-1]
+			region.Polys = append(region.Polys, uint64(p.ref))
+			region.Area += polyArea(p.verts)
+			region.Bounds = boundsUnion(region.Bounds, polyBounds(p.verts))
+			for l := p.poly.FirstLink; l != detour.DT_NULL_LINK; l = p.tile.Links[l].Next {
+				next := p.tile.Links[l].Ref
+				if n, ok := polys[next]; ok && n.kind == kind && !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		sort.Slice(region.Polys, func(i, j int) bool { return region.Polys[i] < region.Polys[j] })
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// debugDiffPolys draws the polygons of kind slightly above the navmesh,
+// filled and outlined.
+func debugDiffPolys(polys map[detour.DtPolyRef]*navDiffPoly, kind string) []*DebugDrawerPrimitive {
+	const lift = 0.05
+	fill := navDiffColors[kind]
+	line := fill
+	line[3] = 1
+
+	tris := &DebugDrawerPrimitive{Type: navDrawTris}
+	lines := &DebugDrawerPrimitive{Type: navDrawLines}
+	for _, p := range polys {
+		if p.kind != kind {
+			continue
+		}
+		for i := 2; i < len(p.verts); i++ {
+			for _, v := range [3]Vec3{p.verts[0], p.verts[i-1], p.verts[i]} {
+				tris.Vertices = append(tris.Vertices, debugVertex(v.X, v.Y+lift, v.Z, fill))
+			}
+		}
+		for i, v := range p.verts {
+			n := p.verts[(i+1)%len(p.verts)]
+			lines.Vertices = append(lines.Vertices, debugVertex(v.X, v.Y+lift, v.Z, line), debugVertex(n.X, n.Y+lift, n.Z, line))
+		}
+	}
+	if len(tris.Vertices) == 0 {
+		return nil
+	}
+	return []*DebugDrawerPrimitive{tris, lines}
+}
+
+// diffNavMesh compares the tiles at the same position polygon by polygon.
+// Polygons with the same outline match, so a rebuild which splits an
+// unchanged surface into other polygons shows as removed and added.
+func diffNavMesh(a, b *NavMeshItem) (*NavMeshDiff, error) {
+	tilesA, polysA, err := a.diffTiles()
+	if err != nil {
+		return nil, err
+	}
+	tilesB, polysB, err := b.diffTiles()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &NavMeshDiff{Regions: []NavDiffRegion{}, Primitives: []*DebugDrawerPrimitive{}}
+	for pos, ta := range tilesA {
+		tb, ok := tilesB[pos]
+		if !ok {
+			diff.TilesRemoved++
+			for _, p := range ta.polys {
+				p.kind = NavDiffRemoved
+			}
+			continue
+		}
+		changed := false
+		for _, p := range ta.polys {
+			if len(tb.byKey[p.key]) == 0 {
+				p.kind = NavDiffRemoved
+				changed = true
+				continue
+			}
+			// the first unmatched polygon with the outline in b
+			match := tb.byKey[p.key][0]
+			tb.byKey[p.key] = tb.byKey[p.key][1:]
+			match.kind = navDiffUnchanged
+			if p.poly.GetArea() != match.poly.GetArea() || p.poly.Flags != match.poly.Flags {
+				match.kind = NavDiffChanged
+				changed = true
+			}
+		}
+		for _, p := range tb.polys {
+			if p.kind == "" {
+				p.kind = NavDiffAdded
+				changed = true
+			}
+		}
+		if changed {
+			diff.TilesChanged++
+		}
+	}
+	for pos, tb := range tilesB {
+		if _, ok := tilesA[pos]; !ok {
+			diff.TilesAdded++
+			for _, p := range tb.polys {
+				p.kind = NavDiffAdded
+			}
+		}
+	}
+
+	for _, p := range polysA {
+		if p.kind == NavDiffRemoved {
+			diff.PolysRemoved++
+			diff.RemovedArea += polyArea(p.verts)
+		}
+	}
+	for _, p := range polysB {
+		switch p.kind {
+		case NavDiffAdded:
+			diff.PolysAdded++
+			diff.AddedArea += polyArea(p.verts)
+		case NavDiffChanged:
+			diff.PolysChanged++
+			diff.ChangedArea += polyArea(p.verts)
+		default:
+			diff.PolysUnchanged++
+		}
+	}
+
+	diff.Regions = append(diff.Regions, diffRegions(polysA, NavDiffRemoved)...)
+	diff.Regions = append(diff.Regions, diffRegions(polysB, NavDiffAdded)...)
+	diff.Regions = append(diff.Regions, diffRegions(polysB, NavDiffChanged)...)
+	diff.Primitives = append(diff.Primitives, debugDiffPolys(polysA, NavDiffRemoved)...)
+	diff.Primitives = append(diff.Primitives, debugDiffPolys(polysB, NavDiffAdded)...)
+	diff.Primitives = append(diff.Primitives, debugDiffPolys(polysB, NavDiffChanged)...)
+	return diff, nil
+}
+
+// Diff compares two loaded navmeshes, see NavMeshDiff.
+func (m *NavMgr) Diff(idA, idB string) (*NavMeshDiff, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	a, err := m.getItem(idA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := m.getItem(idB)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffNavMesh(a, b)
+	if err != nil {
+		return nil, err
+	}
+	diff.IdA, diff.IdB = idA, idB
+	return diff, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestNavDiffKey(t *testing.T) {
+	outline := []Vec3{
+		{X: 1.5, Y: 0.2, Z: -2},
+		{X: -3.25, Y: 0.2, Z: -1},
+		{X: -1, Y: 0.4, Z: 2.5},
+		{X: 2, Y: 0.3, Z: 1},
+		{X: 10, Y: 0.2, Z: -0.5},
+	}
+	want := navDiffKey(outline)
+
+	// every rotation is the same polygon in another tile layout
+	for i := range outline {
+		rotated := append(append([]Vec3(nil), outline[i:]...), outline[:i]...)
+		if got := navDiffKey(rotated); got != want {
+			t.Fatalf("rotation %d: got key %q, want %q", i, got, want)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		shift Vec3
+		same  bool
+	}{
+		{name: "below the precision", shift: Vec3{X: navDiffPrecision / 4, Y: -navDiffPrecision / 4}, same: true},
+		{name: "one grid step", shift: Vec3{Z: navDiffPrecision * 2}},
+		{name: "moved", shift: Vec3{X: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := range outline {
+				shifted := make([]Vec3, len(outline))
+				for j, v := range outline {
+					shifted[(j+i)%len(outline)] = Vec3{X: v.X + test.shift.X, Y: v.Y + test.shift.Y, Z: v.Z + test.shift.Z}
+				}
+				if got := navDiffKey(shifted); (got == want) != test.same {
+					t.Fatalf("rotation %d: got key %q for %q, same %v", i, got, want, test.same)
+				}
+			}
+		})
+	}
+
+	// the vertex order is part of the outline
+	reversed := make([]Vec3, len(outline))
+	for i, v := range outline {
+		reversed[len(outline)-1-i] = v
+	}
+	if navDiffKey(reversed) == want {
+		t.Fatal("reversed outline has the same key")
+	}
+}
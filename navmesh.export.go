@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// navAreaColor is the export color of an area, ground and walkable polygons
+// get the usual navmesh blue.
+func navAreaColor(area uint8) [4]float32 {
+	if area == 0 || area == navWalkableArea {
+		return [4]float32{0, 0.75, 1, 1}
+	}
+	return navDebugColor(int(area), 1)
+}
+
+// navExportMesh has a group of polygons per area and the off-mesh
+// connections as line segments.
+func (item *NavMeshItem) navExportMesh(name string) (*exportMesh, error) {
+	mesh := &exportMesh{name: name}
+	err := item.forEachPoly(func(ref detour.DtPolyRef, tile *detour.DtMeshTile, poly *detour.DtPoly) {
+		if poly.GetType() == detour.DT_POLYTYPE_OFFMESH_CONNECTION {
+			return
+		}
+		area := poly.GetArea()
+		mesh.group(fmt.Sprintf("area_%d", area), false).addFace(polyVerts(tile, poly), navAreaColor(area))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item.forEachTile(func(_ *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		for j := 0; j < int(tile.Header.OffMeshConCount); j++ {
+			con := &tile.OffMeshCons[j]
+			start := Vec3{X: con.Pos[0], Y: con.Pos[1], Z: con.Pos[2]}
+			end := Vec3{X: con.Pos[3], Y: con.Pos[4], Z: con.Pos[5]}
+			mesh.group("offmesh", true).addFace([]Vec3{start, end}, [4]float32{1, 1, 1, 1})
+		}
+	})
+	return mesh, nil
+}
+
+// Export writes the polygons of a navmesh to path as OBJ or glTF,
+// colored and grouped by area.
+func (m *NavMgr) Export(id, path, format string) error {
+	if _, err := exportFormat(path, format); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	item, err := m.getItem(id)
+	var mesh *exportMesh
+	if err == nil {
+		mesh, err = item.navExportMesh(id)
+	}
+	m.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return mesh.write(path, format)
+}
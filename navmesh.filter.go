@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/o0olele/detour-go/crowd"
+	"github.com/o0olele/detour-go/detour"
+)
+
+// NavFilterPreset is a named query filter shared by all navmesh items, e.g.
+// one preset per agent type with its own costs for water, road and grass.
+type NavFilterPreset struct {
+	Name   string         `json:"name"`
+	Filter NavQueryFilter `json:"filter"`
+}
+
+func (f *NavQueryFilter) validate() error {
+	if len(f.AreaCosts) > detour.DT_MAX_AREAS {
+		return fmt.Errorf("at most %d area costs", detour.DT_MAX_AREAS)
+	}
+	for area, cost := range f.AreaCosts {
+		if math.IsNaN(float64(cost)) || math.IsInf(float64(cost), 1) {
+			return fmt.Errorf("cost for area %d is not finite", area)
+		}
+		if cost < 0 {
+			return fmt.Errorf("negative cost for area %d", area)
+		}
+	}
+	return nil
+}
+
+func (f *NavQueryFilter) equal(other *NavQueryFilter) bool {
+	if f.IncludeFlags != other.IncludeFlags || f.ExcludeFlags != other.ExcludeFlags {
+		return false
+	}
+	for area := 0; area < max(len(f.AreaCosts), len(other.AreaCosts)); area++ {
+		if f.areaCost(area) != other.areaCost(area) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *NavQueryFilter) areaCost(area int) float32 {
+	if area < len(f.AreaCosts) {
+		return f.AreaCosts[area]
+	}
+	return 1
+}
+
+// recordArgs flattens the filter for a recorded event, filterFromArgs reads
+// it back.
+func (f *NavQueryFilter) recordArgs() []float32 {
+	args := []float32{float32(f.IncludeFlags), float32(f.ExcludeFlags)}
+	return append(args, f.AreaCosts...)
+}
+
+func filterFromArgs(args []float32) NavQueryFilter {
+	var filter NavQueryFilter
+	if len(args) >= 2 {
+		filter.IncludeFlags = uint16(args[0])
+		filter.ExcludeFlags = uint16(args[1])
+		filter.AreaCosts = append([]float32(nil), args[2:]...)
+	}
+	return filter
+}
+
+// filterSlot returns the crowd filter slot holding filter, storing it in a
+// slot no agent uses when there is none. Slot 0 is the default filter of
+// the crowd and is never changed.
+func (item *NavMeshItem) filterSlot(c *crowd.DtCrowd, filter *NavQueryFilter) (uint8, error) {
+	if filter.equal(&NavQueryFilter{}) {
+		return 0, nil
+	}
+
+	used := make([]bool, crowd.DT_CROWD_MAX_QUERY_FILTER_TYPE)
+	for agentId := range item.agents {
+		if agent := c.GetAgent(int(agentId)); agent != nil && agent.Active {
+			used[agent.Params.QueryFilterType] = true
+		}
+	}
+	free := -1
+	for slot := 1; slot < len(item.filters); slot++ {
+		if item.filters[slot] != nil && item.filters[slot].equal(filter) {
+			return uint8(slot), nil
+		}
+		if free < 0 && !used[slot] {
+			free = slot
+		}
+	}
+	if free < 0 {
+		return 0, fmt.Errorf("all %d crowd filters are in use", crowd.DT_CROWD_MAX_QUERY_FILTER_TYPE-1)
+	}
+
+	stored := *filter
+	stored.AreaCosts = append([]float32(nil), filter.AreaCosts...)
+	stored.apply(c.GetEditableFilter(free))
+	item.filters[free] = &stored
+	return uint8(free), nil
+}
+
+// setAgentFilterById makes a single agent plan its paths with filter, preset
+// is only remembered to update the agent when the preset changes. Recorded
+// filters come back through here on replay, so filter is checked again.
+func (item *NavMeshItem) setAgentFilterById(agentId uint32, preset string, filter NavQueryFilter) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	c, agent, err := item.getAgent(agentId)
+	if err != nil {
+		return err
+	}
+	slot, err := item.filterSlot(c, &filter)
+	if err != nil {
+		return err
+	}
+
+	state := item.agentState(agentId)
+	state.filter = preset
+	if agent.Params.QueryFilterType == slot {
+		return nil
+	}
+	agentParams := agent.Params
+	agentParams.QueryFilterType = slot
+	c.UpdateAgentParameters(int(agentId), &agentParams)
+
+	// the current corridor was planned with the old costs
+	if state.hasTarget && !state.paused {
+		return item.requestMoveTarget(agentId, state.target)
+	}
+	return nil
+}
+
+// updatePresetAgents applies filter to every agent using the preset, they
+// remember next as their preset afterwards.
+func (item *NavMeshItem) updatePresetAgents(preset, next string, filter NavQueryFilter) {
+	var agentIds []uint32
+	for agentId, state := range item.agents {
+		if state.filter == preset {
+			agentIds = append(agentIds, agentId)
+		}
+	}
+	sort.Slice(agentIds, func(i, j int) bool { return agentIds[i] < agentIds[j] })
+	for _, agentId := range agentIds {
+		item.record(navOpSetFilterById, agentId, filter.recordArgs()...)
+		item.setAgentFilterById(agentId, next, filter)
+	}
+}
+
+// getFilterPreset returns the filter of a preset, the empty name is the
+// default filter. The caller holds the mutex.
+func (m *NavMgr) getFilterPreset(name string) (NavQueryFilter, error) {
+	if name == "" {
+		return NavQueryFilter{}, nil
+	}
+	filter, ok := m.filters[name]
+	if !ok {
+		return NavQueryFilter{}, errors.New("filter preset not found: " + name)
+	}
+	return filter, nil
+}
+
+// SetFilterPreset adds or replaces a preset, agents using it are updated on
+// every item.
+func (m *NavMgr) SetFilterPreset(name string, filter NavQueryFilter) error {
+	if name == "" {
+		return errors.New("filter preset without a name")
+	}
+	if err := filter.validate(); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	filter.AreaCosts = append([]float32(nil), filter.AreaCosts...)
+	m.filters[name] = filter
+	for _, item := range m.navItems {
+		item.updatePresetAgents(name, name, filter)
+	}
+	return nil
+}
+
+// RemoveFilterPreset deletes a preset, agents using it go back to the
+// default filter.
+func (m *NavMgr) RemoveFilterPreset(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.filters[name]; !ok {
+		return errors.New("filter preset not found: " + name)
+	}
+	delete(m.filters, name)
+	for _, item := range m.navItems {
+		item.updatePresetAgents(name, "", NavQueryFilter{})
+	}
+	return nil
+}
+
+// GetFilterPresets lists the presets sorted by name.
+func (m *NavMgr) GetFilterPresets() []NavFilterPreset {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	presets := make([]NavFilterPreset, 0, len(m.filters))
+	for name, filter := range m.filters {
+		presets = append(presets, NavFilterPreset{Name: name, Filter: filter})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// SetAgentFilterById makes a single agent use a preset, the empty name puts
+// it back on the default filter. An agent with a target plans its path
+// again.
+func (m *NavMgr) SetAgentFilterById(id string, agentId uint32, preset string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	filter, err := m.getFilterPreset(preset)
+	if err != nil {
+		return err
+	}
+	item.record(navOpSetFilterById, agentId, filter.recordArgs()...)
+	return item.setAgentFilterById(agentId, preset, filter)
+}
+
+// FindPathWithPreset is FindPath with the filter of a preset.
+func (m *NavMgr) FindPathWithPreset(id string, start, end Vec3, preset string) (*NavPath, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := m.getFilterPreset(preset)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	return item.findPath(start, end, filter.toDetour())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+func TestNavFilterValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter NavQueryFilter
+		err    string
+	}{
+		{name: "default"},
+		{name: "costs", filter: NavQueryFilter{IncludeFlags: 1, AreaCosts: []float32{1, 2.5, 0}}},
+		{name: "negative cost", filter: NavQueryFilter{AreaCosts: []float32{1, -1}}, err: "negative cost for area 1"},
+		{name: "nan cost", filter: NavQueryFilter{AreaCosts: []float32{float32(math.NaN())}}, err: "cost for area 0 is not finite"},
+		{name: "infinite cost", filter: NavQueryFilter{AreaCosts: []float32{1, 1, float32(math.Inf(1))}}, err: "cost for area 2 is not finite"},
+		{name: "negative infinite cost", filter: NavQueryFilter{AreaCosts: []float32{float32(math.Inf(-1))}}, err: "negative cost for area 0"},
+		{name: "too many costs", filter: NavQueryFilter{AreaCosts: make([]float32, detour.DT_MAX_AREAS+1)}, err: "area costs"},
+	}
+	m := NewNavMgr()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the filter is checked before the item is looked up
+			calls := map[string]func() error{
+				"FindPath": func() error {
+					_, err := m.FindPath("missing", Vec3{}, Vec3{}, test.filter)
+					return err
+				},
+				"CheckReachability": func() error {
+					_, err := m.CheckReachability("missing", nil, "", test.filter)
+					return err
+				},
+			}
+			for name, call := range calls {
+				err := call()
+				if test.err == "" {
+					if err == nil || !strings.Contains(err.Error(), "not found") {
+						t.Fatalf("%s: got error %v, want the missing item", name, err)
+					}
+				} else if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("%s: got error %v, want %q", name, err, test.err)
+				}
+			}
+		})
+	}
+}
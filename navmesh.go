@@ -3,19 +3,56 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 
+	"github.com/o0olele/detour-go/crowd"
 	"github.com/o0olele/detour-go/debugger"
+	"github.com/o0olele/detour-go/detour"
 )
 
+// NavMeshItem is a loaded navmesh with the query state the workbench keeps
+// next to it.
+type NavMeshItem struct {
+	*debugger.NavItem
+	name      string
+	navType   string
+	data      []byte
+	query     *detour.DtNavMeshQuery
+	agents    map[uint32]*navAgentState
+	filters   [crowd.DT_CROWD_MAX_QUERY_FILTER_TYPE]*NavQueryFilter // crowd filter slots set by presets
+	sim       *navSim
+	recorder  *navRecorder
+	replay    *navReplay
+	tileCache *navTileCache // from the build with NavBuildParams.TileCache, or made on the first obstacle
+}
+
+func newNavMeshItem(name, navType string, data []byte) (*NavMeshItem, error) {
+	item := debugger.NewNavItem(name)
+
+	err := item.Load(navType, data)
+	if err != nil {
+		return nil, err
+	}
+	return &NavMeshItem{
+		NavItem: item,
+		name:    name,
+		navType: navType,
+		data:    data,
+		agents:  make(map[uint32]*navAgentState),
+	}, nil
+}
+
 type NavMgr struct {
 	mutex    sync.Mutex
-	navItems map[string]*debugger.NavItem
+	navItems map[string]*NavMeshItem
+	filters  map[string]NavQueryFilter // filter presets by name
 }
 
 func NewNavMgr() *NavMgr {
 	return &NavMgr{
-		navItems: make(map[string]*debugger.NavItem),
+		navItems: make(map[string]*NavMeshItem),
+		filters:  make(map[string]NavQueryFilter),
 	}
 }
 
@@ -33,9 +70,7 @@ func (m *NavMgr) LoadItem(id, name, navType string, data []byte) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	item := debugger.NewNavItem(name)
-
-	err := item.Load(navType, data)
+	item, err := newNavMeshItem(name, navType, data)
 	if err != nil {
 		return err
 	}
@@ -47,35 +82,59 @@ func (m *NavMgr) LoadItem(id, name, navType string, data []byte) error {
 func (m *NavMgr) RemoveItem(id string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if item, ok := m.navItems[id]; ok {
+		item.stopSimulation()
+		item.stopRecording()
+	}
 	delete(m.navItems, id)
 	fmt.Println("remove item", id)
 
 }
 
-func (m *NavMgr) AddAgent(id string, x, y, z, r, h, speed, acc float32) error {
+// Save writes the navmesh data of the item to filename, in the format of
+// its navType.
+func (m *NavMgr) Save(id, filename string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, item.data, 0644)
+}
+
+func (m *NavMgr) AddAgent(id string, x, y, z, r, h, speed, acc float32) (uint32, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	item, ok := m.navItems[id]
 	if !ok {
-		return errors.New("nav item not found")
+		return 0, errors.New("nav item not found")
 	}
 	agentId := item.AddAgent(x, y, z, r, h, speed, acc)
 	if agentId < 0 {
-		return errors.New("add agent failed")
+		return 0, errors.New("add agent failed")
 	}
-	return nil
+	// the id may be of an agent removed without RemoveAgentById
+	delete(item.agents, uint32(agentId))
+	item.record(navOpAddAgent, uint32(agentId), x, y, z, r, h, speed, acc)
+	return uint32(agentId), nil
 }
 
-func (m *NavMgr) UpdateAgents(id string) {
+// UpdateAgents moves the crowd one step. The agents move even when some
+// obstacle tiles failed to rebuild, the error says which.
+func (m *NavMgr) UpdateAgents(id string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	item, ok := m.navItems[id]
-	if !ok {
-		return
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
 	}
-	item.UpdateAgents()
+	err = item.tick(0)
+	item.recordTick(navOpUpdate)
+	return err
 }
 
 func (m *NavMgr) ClearAgent(id string) {
@@ -87,17 +146,22 @@ func (m *NavMgr) ClearAgent(id string) {
 		return
 	}
 	item.ClearAgent()
+	item.agents = make(map[uint32]*navAgentState)
+	item.record(navOpClearAgent, 0)
 }
 
-func (m *NavMgr) SetAgentTarget(id string, x, y, z float32) {
+// SetAgentTarget moves every agent to the target, it fails when the target
+// is off the navmesh.
+func (m *NavMgr) SetAgentTarget(id string, x, y, z float32) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	item, ok := m.navItems[id]
-	if !ok {
-		return
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
 	}
-	item.SetAgentTarget(x, y, z)
+	item.record(navOpSetTarget, 0, x, y, z)
+	return item.setAgentTarget(Vec3{X: x, Y: y, Z: z})
 }
 
 func (m *NavMgr) TeleportAgent(id string, x, y, z float32) bool {
@@ -108,9 +172,19 @@ func (m *NavMgr) TeleportAgent(id string, x, y, z float32) bool {
 	if !ok {
 		return false
 	}
+	item.record(navOpTeleport, 0, x, y, z)
 	return item.TeleportAgent(x, y, z)
 }
 
+// getItem returns the item with the given id, the caller holds the mutex.
+func (m *NavMgr) getItem(id string) (*NavMeshItem, error) {
+	item, ok := m.navItems[id]
+	if !ok {
+		return nil, errors.New("nav item not found")
+	}
+	return item, nil
+}
+
 func (m *NavMgr) GetInfo(id string, addMesh bool) *debugger.NavInfo {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// navPortalSides maps the portal sides of detour polygons back to the
+// portal directions of the poly mesh.
+var navPortalSides = map[uint16]uint16{4: 0, 2: 1, 0: 2, 6: 3}
+
+// tileCreateParams rebuilds the create params of a tile from its data,
+// without the off-mesh connections. The vertices are quantized again with
+// the cell size of the tile and a height step fine enough to keep them.
+func tileCreateParams(tile *detour.DtMeshTile) (*detour.DtNavMeshCreateParams, error) {
+	h := tile.Header
+	if h.BvQuantFactor <= 0 {
+		return nil, errors.New("tile without cell size")
+	}
+	cs := 1 / h.BvQuantFactor
+	ch := max((h.Bmax[1]-h.Bmin[1])/60000, 0.001)
+
+	npolys := int(h.OffMeshBase)
+	nverts := int(h.VertCount) - 2*int(h.OffMeshConCount)
+	nvp := detour.DT_VERTS_PER_POLYGON
+	params := &detour.DtNavMeshCreateParams{
+		Verts:          make([]uint16, 0, nverts*3),
+		VertCount:      nverts,
+		Polys:          make([]uint16, 0, npolys*nvp*2),
+		PolyFlags:      make([]uint16, npolys),
+		PolyAreas:      make([]uint8, npolys),
+		PolyCount:      npolys,
+		Nvp:            nvp,
+		UserId:         h.UserId,
+		TileX:          h.X,
+		TileY:          h.Y,
+		TileLayer:      h.Layer,
+		Bmin:           h.Bmin,
+		Bmax:           h.Bmax,
+		WalkableHeight: h.WalkableHeight,
+		WalkableRadius: h.WalkableRadius,
+		WalkableClimb:  h.WalkableClimb,
+		Cs:             cs,
+		Ch:             ch,
+		BuildBvTree:    h.BvNodeCount > 0,
+	}
+	for i := 0; i < nverts; i++ {
+		v := tile.Verts[i*3:]
+		for j, step := range [3]float32{cs, ch, cs} {
+			params.Verts = append(params.Verts, uint16(math.Round(float64((v[j]-h.Bmin[j])/step))))
+		}
+	}
+
+	for i := 0; i < npolys; i++ {
+		poly := &tile.Polys[i]
+		params.PolyFlags[i] = poly.Flags
+		params.PolyAreas[i] = poly.GetArea()
+		for j := 0; j < nvp; j++ {
+			if j < int(poly.VertCount) {
+				params.Polys = append(params.Polys, poly.Verts[j])
+			} else {
+				params.Polys = append(params.Polys, navMeshNullIdx)
+			}
+		}
+		for j := 0; j < nvp; j++ {
+			nei := uint16(navMeshNullIdx)
+			if n := poly.Neis[j]; j < int(poly.VertCount) && n != 0 {
+				if n&detour.DT_EXT_LINK != 0 {
+					nei = 0x8000 | navPortalSides[n&0xff]
+				} else {
+					nei = n - 1
+				}
+			}
+			params.Polys = append(params.Polys, nei)
+		}
+	}
+
+	// detail meshes list the polygon vertices before their own ones
+	if int(h.DetailMeshCount) >= npolys && npolys > 0 {
+		params.DetailMeshes = make([]uint32, 0, npolys*4)
+		for i := 0; i < npolys; i++ {
+			poly := &tile.Polys[i]
+			dm := &tile.DetailMeshes[i]
+			vertBase := uint32(len(params.DetailVerts) / 3)
+			params.DetailMeshes = append(params.DetailMeshes, vertBase, uint32(poly.VertCount)+uint32(dm.VertCount),
+				uint32(len(params.DetailTris)/4), uint32(dm.TriCount))
+			for j := 0; j < int(poly.VertCount); j++ {
+				params.DetailVerts = append(params.DetailVerts, tile.Verts[int(poly.Verts[j])*3:int(poly.Verts[j])*3+3]...)
+			}
+			params.DetailVerts = append(params.DetailVerts, tile.DetailVerts[dm.VertBase*3:(dm.VertBase+uint32(dm.VertCount))*3]...)
+			params.DetailTris = append(params.DetailTris, tile.DetailTris[dm.TriBase*4:(dm.TriBase+uint32(dm.TriCount))*4]...)
+		}
+		params.DetailVertsCount = len(params.DetailVerts) / 3
+		params.DetailTriCount = len(params.DetailTris) / 4
+	}
+	return params, nil
+}
+
+// tileOffMeshLinks lists the off-mesh connections stored in tile.
+func tileOffMeshLinks(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) []NavOffMeshLink {
+	links := make([]NavOffMeshLink, 0, tile.Header.OffMeshConCount)
+	for j := 0; j < int(tile.Header.OffMeshConCount); j++ {
+		links = append(links, offMeshLink(mesh, tile, &tile.OffMeshCons[j]))
+	}
+	return links
+}
+
+// offMeshTile is the tile an off-mesh connection starting at pos is stored
+// in, the one containing pos with the closest height range.
+func (item *NavMeshItem) offMeshTile(pos Vec3) (*detour.DtMeshTile, error) {
+	var best *detour.DtMeshTile
+	bestDist := float32(math.MaxFloat32)
+	item.forEachTile(func(_ *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		h := tile.Header
+		if pos.X < h.Bmin[0] || pos.X > h.Bmax[0] || pos.Z < h.Bmin[2] || pos.Z > h.Bmax[2] {
+			return
+		}
+		dist := max(h.Bmin[1]-pos.Y, pos.Y-h.Bmax[1], 0)
+		if dist <= h.WalkableClimb && dist < bestDist {
+			best, bestDist = tile, dist
+		}
+	})
+	if best == nil {
+		return nil, fmt.Errorf("no navmesh tile under the off-mesh connection start %v", pos)
+	}
+	return best, nil
+}
+
+// findOffMeshLink returns the tile and index of the off-mesh connection
+// stored as polygon ref.
+func (item *NavMeshItem) findOffMeshLink(ref uint64) (*detour.DtMeshTile, int, error) {
+	mesh := item.GetNavMesh()
+	if mesh == nil {
+		return nil, 0, errors.New("nav item has no navmesh")
+	}
+	var tile *detour.DtMeshTile
+	var poly *detour.DtPoly
+	if detour.DtStatusFailed(mesh.GetTileAndPolyByRef(detour.DtPolyRef(ref), &tile, &poly)) ||
+		poly.GetType() != detour.DT_POLYTYPE_OFFMESH_CONNECTION {
+		return nil, 0, errors.New("off-mesh connection not found")
+	}
+	index := int(detour.DtPolyRef(ref) - mesh.GetPolyRefBase(tile))
+	for j := 0; j < int(tile.Header.OffMeshConCount); j++ {
+		if int(tile.OffMeshCons[j].Poly) == index {
+			return tile, j, nil
+		}
+	}
+	return nil, 0, errors.New("off-mesh connection not found")
+}
+
+// addOffMeshParams adds links to the off-mesh connections of params.
+func addOffMeshParams(params *detour.DtNavMeshCreateParams, links []NavOffMeshLink) {
+	for _, link := range links {
+		params.OffMeshConVerts = append(params.OffMeshConVerts, link.Start.X, link.Start.Y, link.Start.Z, link.End.X, link.End.Y, link.End.Z)
+		params.OffMeshConRad = append(params.OffMeshConRad, link.Radius)
+		params.OffMeshConFlags = append(params.OffMeshConFlags, link.Flags)
+		params.OffMeshConAreas = append(params.OffMeshConAreas, link.Area)
+		dir := uint8(0)
+		if link.Bidirectional {
+			dir = detour.DT_OFFMESH_CON_BIDIR
+		}
+		params.OffMeshConDir = append(params.OffMeshConDir, dir)
+		params.OffMeshConUserID = append(params.OffMeshConUserID, link.UserId)
+	}
+	params.OffMeshConCount = len(links)
+}
+
+// rebuildTile replaces the off-mesh connections of tile with links and
+// adds the tile again under its old ref, which connects it to its
+// neighbours. It returns the tile as added, on error the tile is kept.
+func (item *NavMeshItem) rebuildTile(tile *detour.DtMeshTile, links []NavOffMeshLink) (*detour.DtMeshTile, error) {
+	params, err := tileCreateParams(tile)
+	if err != nil {
+		return nil, err
+	}
+	addOffMeshParams(params, links)
+
+	var data []byte
+	var dataSize int
+	if !detour.DtCreateNavMeshData(params, &data, &dataSize) {
+		return nil, fmt.Errorf("create navmesh data of tile %d,%d failed", tile.Header.X, tile.Header.Y)
+	}
+	old := append([]byte(nil), tile.Data[:tile.DataSize]...)
+	if tile, err = item.setTileData(tile, data[:dataSize]); err != nil {
+		return nil, err
+	}
+	if int(tile.Header.OffMeshConCount) != len(links) {
+		err = errors.New("an off-mesh connection start is outside of its tile")
+		if _, restoreErr := item.setTileData(tile, old); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("restore navmesh tile: %v", restoreErr))
+		}
+		return nil, err
+	}
+	return tile, nil
+}
+
+// setTileData replaces the data of tile under its ref and returns the
+// tile as added. When the new data can't be added the old tile is put back.
+func (item *NavMeshItem) setTileData(tile *detour.DtMeshTile, data []byte) (*detour.DtMeshTile, error) {
+	mesh := item.GetNavMesh()
+	old := append([]byte(nil), tile.Data[:tile.DataSize]...)
+	ref := mesh.GetTileRef(tile)
+	if detour.DtStatusFailed(mesh.RemoveTile(ref, nil, nil)) {
+		return nil, errors.New("remove navmesh tile failed")
+	}
+	var added detour.DtTileRef
+	if detour.DtStatusFailed(mesh.AddTile(data, len(data), detour.DT_TILE_FREE_DATA, ref, &added)) {
+		// put the old tile back, the navmesh stays as it was
+		err := errors.New("add navmesh tile failed")
+		if detour.DtStatusFailed(mesh.AddTile(old, len(old), detour.DT_TILE_FREE_DATA, ref, &added)) {
+			err = errors.Join(err, errors.New("restore navmesh tile failed"))
+		}
+		return nil, err
+	}
+	tile = mesh.GetTileByRef(added)
+	item.recordTile(tile.Header.X, tile.Header.Y, tile.Header.Layer, added, data)
+	return tile, nil
+}
+
+// saveNavMeshSet stores the edited tiles as the data of the item, in the
+// navmesh set layout it was loaded from.
+func (item *NavMeshItem) saveNavMeshSet() {
+	mesh := item.GetNavMesh()
+	params := mesh.GetParams()
+	var tiles [][]byte
+	var refs []detour.DtTileRef
+	item.forEachTile(func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		tiles = append(tiles, tile.Data[:tile.DataSize])
+		refs = append(refs, mesh.GetTileRef(tile))
+	})
+	item.data = writeNavMeshSet(navMeshSetHeader{
+		Orig:       params.Orig,
+		TileWidth:  params.TileWidth,
+		TileHeight: params.TileHeight,
+		MaxTiles:   int32(params.MaxTiles),
+		MaxPolys:   int32(params.MaxPolys),
+	}, tiles, refs)
+}
+
+// editOffMeshLinks checks that the item can be saved after an edit. Only
+// the navmesh set layout is written back, so the navTypes loaded from other
+// layouts, like solo meshes, can't be edited.
+func (item *NavMeshItem) editOffMeshLinks() error {
+	if item.GetNavMesh() == nil {
+		return errors.New("nav item has no navmesh")
+	}
+	if len(item.data) < 4 || binary.LittleEndian.Uint32(item.data) != navMeshSetMagic {
+		return fmt.Errorf("off-mesh connections of navType %s can't be edited, only built navmeshes and navmesh set files", item.navType)
+	}
+	// the tiles carved by obstacles would be saved with the holes
+	if item.tileCache != nil && item.tileCache.busy() {
+		return errors.New("remove the temporary obstacles before editing off-mesh connections")
+	}
+	return nil
+}
+
+// moveOffMeshLink removes the connection at index of tile and, unless link
+// is nil, stores link in the tile under its start. It returns the new ref.
+// Either both tiles are updated and the data of the item is saved, or the
+// navmesh is left as it was.
+func (item *NavMeshItem) moveOffMeshLink(tile *detour.DtMeshTile, index int, link *NavOffMeshLink) (uint64, error) {
+	mesh := item.GetNavMesh()
+	if link == nil {
+		links := tileOffMeshLinks(mesh, tile)
+		links = append(links[:index], links[index+1:]...)
+		if _, err := item.rebuildTile(tile, links); err != nil {
+			return 0, err
+		}
+		item.saveNavMeshSet()
+		return 0, nil
+	}
+
+	target, err := item.offMeshTile(link.Start)
+	if err != nil {
+		return 0, err
+	}
+	moved := tile != nil && tile != target
+	links := tileOffMeshLinks(mesh, target)
+	at := len(links)
+	if tile == target {
+		at = index
+		links[at] = *link
+	} else {
+		links = append(links, *link)
+	}
+	// the target is rebuilt first, the link is only dropped from its old
+	// tile once it has a new one
+	old := append([]byte(nil), target.Data[:target.DataSize]...)
+	if target, err = item.rebuildTile(target, links); err != nil {
+		return 0, err
+	}
+	if moved {
+		links := tileOffMeshLinks(mesh, tile)
+		links = append(links[:index], links[index+1:]...)
+		if _, err := item.rebuildTile(tile, links); err != nil {
+			if _, restoreErr := item.setTileData(target, old); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("restore navmesh tile: %v", restoreErr))
+			}
+			return 0, err
+		}
+	}
+	item.saveNavMeshSet()
+	return uint64(mesh.GetPolyRefBase(target) | detour.DtPolyRef(target.OffMeshCons[at].Poly)), nil
+}
+
+// CanEditOffMeshConnections returns the error the edits of the off-mesh
+// connections of the item fail with, nil when they can be edited.
+func (m *NavMgr) CanEditOffMeshConnections(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	return item.editOffMeshLinks()
+}
+
+// GetOffMeshConnections lists the off-mesh connections of a navmesh.
+func (m *NavMgr) GetOffMeshConnections(id string) ([]NavOffMeshLink, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	links := []NavOffMeshLink{}
+	err = item.forEachTile(func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		links = append(links, tileOffMeshLinks(mesh, tile)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// AddOffMeshConnection adds link to the tile under its start and returns
+// the ref of its polygon, link.Ref is ignored. The data of the item is
+// updated, so Save writes the edited navmesh.
+func (m *NavMgr) AddOffMeshConnection(id string, link NavOffMeshLink) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return 0, err
+	}
+	if err = item.editOffMeshLinks(); err != nil {
+		return 0, err
+	}
+	if link.Radius <= 0 {
+		return 0, errors.New("off-mesh connection radius must be positive")
+	}
+	if int(link.Area) >= detour.DT_MAX_AREAS {
+		return 0, fmt.Errorf("off-mesh connection area must be below %d", detour.DT_MAX_AREAS)
+	}
+	return item.moveOffMeshLink(nil, 0, &link)
+}
+
+// MoveOffMeshConnection moves the endpoints of the connection stored as
+// polygon ref and returns its new ref, the other settings are kept.
+func (m *NavMgr) MoveOffMeshConnection(id string, ref uint64, start, end Vec3) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return 0, err
+	}
+	if err = item.editOffMeshLinks(); err != nil {
+		return 0, err
+	}
+	tile, index, err := item.findOffMeshLink(ref)
+	if err != nil {
+		return 0, err
+	}
+	link := offMeshLink(item.GetNavMesh(), tile, &tile.OffMeshCons[index])
+	link.Start, link.End = start, end
+	return item.moveOffMeshLink(tile, index, &link)
+}
+
+// RemoveOffMeshConnection deletes the connection stored as polygon ref.
+func (m *NavMgr) RemoveOffMeshConnection(id string, ref uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	if err = item.editOffMeshLinks(); err != nil {
+		return err
+	}
+	tile, index, err := item.findOffMeshLink(ref)
+	if err != nil {
+		return err
+	}
+	_, err = item.moveOffMeshLink(tile, index, nil)
+	return err
+}
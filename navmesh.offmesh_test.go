@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// TestNavOffMeshConnections edits a connection up the cliff of a tiled
+// navmesh and checks the refs, the paths over it and the saved data.
+func TestNavOffMeshConnections(t *testing.T) {
+	m, _ := buildTestNavMesh(t, navTestCliffObj, NavBuildParams{TileSize: 11})
+	start, end := Vec3{X: -3.5, Z: -3.5}, Vec3{X: 3.5, Y: 1.5, Z: 3.5}
+	tileOf := func(pos Vec3) int32 {
+		tile, err := m.navItems["scene"].offMeshTile(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tile.Header.Y
+	}
+
+	var ref uint64
+	steps := []struct {
+		name    string
+		edit    func() (uint64, error)
+		links   []NavOffMeshLink // without their refs
+		partial bool
+	}{
+		{
+			name: "add",
+			edit: func() (uint64, error) {
+				return m.AddOffMeshConnection("scene", NavOffMeshLink{Start: Vec3{X: -1.5, Z: 0}, End: Vec3{X: 1.5, Y: 1.5, Z: 0}, Radius: 0.5, Bidirectional: true, Flags: navPolyFlagWalk})
+			},
+			links: []NavOffMeshLink{{Start: Vec3{X: -1.5, Z: 0}, End: Vec3{X: 1.5, Y: 1.5, Z: 0}, Radius: 0.5, Bidirectional: true, Flags: navPolyFlagWalk}},
+		},
+		{
+			name: "move across tiles",
+			edit: func() (uint64, error) {
+				if tileOf(Vec3{X: -1.5, Z: 0}) == tileOf(Vec3{X: -1.5, Z: 2.5}) {
+					t.Fatal("the starts are in the same tile")
+				}
+				return m.MoveOffMeshConnection("scene", ref, Vec3{X: -1.5, Z: 2.5}, Vec3{X: 1.5, Y: 1.5, Z: 2.5})
+			},
+			links: []NavOffMeshLink{{Start: Vec3{X: -1.5, Z: 2.5}, End: Vec3{X: 1.5, Y: 1.5, Z: 2.5}, Radius: 0.5, Bidirectional: true, Flags: navPolyFlagWalk}},
+		},
+		{
+			name:    "remove",
+			edit:    func() (uint64, error) { return 0, m.RemoveOffMeshConnection("scene", ref) },
+			partial: true,
+		},
+	}
+	for _, step := range steps {
+		old := ref
+		var err error
+		if ref, err = step.edit(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if ref != 0 {
+			poly, err := m.GetPoly("scene", ref)
+			if err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+			if poly.Type != NavPolyOffMesh || poly.OffMesh == nil || poly.OffMesh.Start != step.links[0].Start {
+				t.Fatalf("%s: ref %d is %+v", step.name, ref, poly)
+			}
+		}
+		if old != 0 && old != ref {
+			if err := m.RemoveOffMeshConnection("scene", old); err == nil {
+				t.Fatalf("%s: the old ref %d still removes a connection", step.name, old)
+			}
+		}
+
+		links, err := m.GetOffMeshConnections("scene")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range links {
+			links[i].Ref = 0
+		}
+		if len(links) != len(step.links) || len(links) > 0 && !reflect.DeepEqual(links, step.links) {
+			t.Fatalf("%s: got connections %+v, want %+v", step.name, links, step.links)
+		}
+
+		path, err := m.FindPath("scene", start, end, NavQueryFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path.Partial != step.partial {
+			t.Fatalf("%s: got partial %v, want %v", step.name, path.Partial, step.partial)
+		}
+
+		// the edit is saved in the navmesh set layout the item loads from
+		navFile := filepath.Join(t.TempDir(), "scene.navmesh")
+		if err = m.Save("scene", navFile); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(navFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.RemoveItem("loaded")
+		if err = m.LoadItem("loaded", "loaded", NavTypeTileMesh, data); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := m.GetOffMeshConnections("loaded")
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved, _ := m.GetOffMeshConnections("scene")
+		if !reflect.DeepEqual(loaded, saved) {
+			t.Fatalf("%s: loaded connections %+v, want %+v", step.name, loaded, saved)
+		}
+	}
+}
+
+func TestNavOffMeshConnectionsNotSet(t *testing.T) {
+	m, _ := buildTestNavMesh(t, navTestFlatObj, NavBuildParams{})
+	// a single tile as loaded from a solo mesh file
+	item := m.navItems["scene"]
+	item.forEachTile(func(_ *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		item.data = append([]byte(nil), tile.Data[:tile.DataSize]...)
+	})
+	item.navType = "solomesh"
+
+	link := NavOffMeshLink{Start: Vec3{X: -1, Z: 0}, End: Vec3{X: 1, Z: 0}, Radius: 0.5, Flags: navPolyFlagWalk}
+	if _, err := m.AddOffMeshConnection("scene", link); err == nil || !strings.Contains(err.Error(), "can't be edited") {
+		t.Fatalf("got error %v, want the navType rejected", err)
+	}
+	if err := m.CanEditOffMeshConnections("scene"); err == nil {
+		t.Fatal("a solo mesh can be edited")
+	}
+}
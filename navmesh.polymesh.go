@@ -0,0 +1,471 @@
+package main
+
+import (
+	"errors"
+)
+
+const (
+	navMeshNullIdx = 0xffff
+	navPortalFlag  = 0x8000 // neighbour of an edge on the tile border, the low bits are the side
+	navDiagonal    = 0x40000000
+)
+
+// navPolyMesh holds the convex polygons in cell coordinates. Every polygon
+// has nvp vertex indices followed by nvp neighbour polygons, unused slots are
+// navMeshNullIdx.
+type navPolyMesh struct {
+	verts      []uint16 // x, y, z per vertex
+	polys      []uint16
+	regs       []uint16
+	areas      []uint8
+	nvp        int
+	bmin, bmax Vec3
+	cs, ch     float32
+	width      int // tile size in cells, portal edges lie on its sides
+	height     int
+}
+
+func (pm *navPolyMesh) polyCount() int {
+	return len(pm.regs)
+}
+
+func (pm *navPolyMesh) poly(i int) []uint16 {
+	return pm.polys[i*pm.nvp*2 : (i+1)*pm.nvp*2]
+}
+
+func countPolyVerts(p []uint16, nvp int) int {
+	for i := 0; i < nvp; i++ {
+		if p[i] == navMeshNullIdx {
+			return i
+		}
+	}
+	return nvp
+}
+
+// 2D predicates on the x and z of vertices with a stride of 4
+
+func area2(verts []int, a, b, c int) int {
+	return (verts[b*4]-verts[a*4])*(verts[c*4+2]-verts[a*4+2]) - (verts[c*4]-verts[a*4])*(verts[b*4+2]-verts[a*4+2])
+}
+
+func left(verts []int, a, b, c int) bool      { return area2(verts, a, b, c) < 0 }
+func leftOn(verts []int, a, b, c int) bool    { return area2(verts, a, b, c) <= 0 }
+func collinear(verts []int, a, b, c int) bool { return area2(verts, a, b, c) == 0 }
+
+func intersectProp(verts []int, a, b, c, d int) bool {
+	if collinear(verts, a, b, c) || collinear(verts, a, b, d) || collinear(verts, c, d, a) || collinear(verts, c, d, b) {
+		return false
+	}
+	return left(verts, a, b, c) != left(verts, a, b, d) && left(verts, c, d, a) != left(verts, c, d, b)
+}
+
+// between tells whether c lies on the closed segment a-b.
+func between(verts []int, a, b, c int) bool {
+	if !collinear(verts, a, b, c) {
+		return false
+	}
+	axis := 0
+	if verts[a*4] == verts[b*4] {
+		axis = 2
+	}
+	va, vb, vc := verts[a*4+axis], verts[b*4+axis], verts[c*4+axis]
+	return (va <= vc && vc <= vb) || (va >= vc && vc >= vb)
+}
+
+func intersectSeg(verts []int, a, b, c, d int) bool {
+	return intersectProp(verts, a, b, c, d) ||
+		between(verts, a, b, c) || between(verts, a, b, d) ||
+		between(verts, c, d, a) || between(verts, c, d, b)
+}
+
+func vequal(verts []int, a, b int) bool {
+	return verts[a*4] == verts[b*4] && verts[a*4+2] == verts[b*4+2]
+}
+
+// navPolygon is a polygon under ear clipping, indices point into verts and
+// may carry the navDiagonal flag.
+type navPolygon struct {
+	verts   []int
+	indices []int
+}
+
+func (p *navPolygon) v(i int) int { return p.indices[i] &^ navDiagonal }
+func (p *navPolygon) next(i int) int {
+	return (i + 1) % len(p.indices)
+}
+func (p *navPolygon) prev(i int) int {
+	return (i + len(p.indices) - 1) % len(p.indices)
+}
+
+// diagonalie tells whether i-j crosses no edge of the polygon, loose
+// allows touching edges.
+func (p *navPolygon) diagonalie(i, j int, loose bool) bool {
+	d0, d1 := p.v(i), p.v(j)
+	for k := range p.indices {
+		k1 := p.next(k)
+		if k == i || k1 == i || k == j || k1 == j {
+			continue
+		}
+		p0, p1 := p.v(k), p.v(k1)
+		if vequal(p.verts, d0, p0) || vequal(p.verts, d1, p0) || vequal(p.verts, d0, p1) || vequal(p.verts, d1, p1) {
+			continue
+		}
+		if loose && intersectProp(p.verts, d0, d1, p0, p1) || !loose && intersectSeg(p.verts, d0, d1, p0, p1) {
+			return false
+		}
+	}
+	return true
+}
+
+// inCone tells whether i-j lies inside the polygon near i.
+func (p *navPolygon) inCone(i, j int, loose bool) bool {
+	pi, pj, pi1, pin1 := p.v(i), p.v(j), p.v(p.next(i)), p.v(p.prev(i))
+	test := left
+	if loose {
+		test = leftOn
+	}
+	// convex vertex
+	if leftOn(p.verts, pin1, pi, pi1) {
+		return test(p.verts, pi, pj, pin1) && test(p.verts, pj, pi, pi1)
+	}
+	return !(leftOn(p.verts, pi, pj, pi1) && leftOn(p.verts, pj, pi, pin1))
+}
+
+func (p *navPolygon) diagonal(i, j int, loose bool) bool {
+	return p.inCone(i, j, loose) && p.diagonalie(i, j, loose)
+}
+
+// triangulate ear clips the polygon, always cutting the shortest diagonal.
+// It returns the triangles as vertex index triples, the error tells the
+// polygon could not be fully triangulated.
+func triangulate(verts []int, n int) ([]int, error) {
+	p := &navPolygon{verts: verts, indices: make([]int, n)}
+	for i := range p.indices {
+		p.indices[i] = i
+	}
+	for i := range p.indices {
+		i2 := p.next(p.next(i))
+		if p.diagonal(i, i2, false) {
+			p.indices[p.next(i)] |= navDiagonal
+		}
+	}
+
+	var tris []int
+	for len(p.indices) > 3 {
+		minLen, mini := -1, -1
+		for i := range p.indices {
+			i1 := p.next(i)
+			if p.indices[i1]&navDiagonal == 0 {
+				continue
+			}
+			p0, p2 := p.v(i), p.v(p.next(i1))
+			dx, dz := verts[p2*4]-verts[p0*4], verts[p2*4+2]-verts[p0*4+2]
+			if length := dx*dx + dz*dz; minLen < 0 || length < minLen {
+				minLen, mini = length, i
+			}
+		}
+		if mini == -1 {
+			// overlapping segments, try the loose diagonals
+			for i := range p.indices {
+				i1 := p.next(i)
+				i2 := p.next(i1)
+				if !p.diagonal(i, i2, true) {
+					continue
+				}
+				p0, p2 := p.v(i), p.v(i2)
+				dx, dz := verts[p2*4]-verts[p0*4], verts[p2*4+2]-verts[p0*4+2]
+				if length := dx*dx + dz*dz; minLen < 0 || length < minLen {
+					minLen, mini = length, i
+				}
+			}
+			if mini == -1 {
+				return tris, errors.New("contour triangulation failed")
+			}
+		}
+
+		i := mini
+		i1 := p.next(i)
+		i2 := p.next(i1)
+		tris = append(tris, p.v(i), p.v(i1), p.v(i2))
+
+		// remove the ear tip and update the diagonals around it
+		p.indices = append(p.indices[:i1], p.indices[i1+1:]...)
+		if i1 >= len(p.indices) {
+			i1 = 0
+		}
+		i = p.prev(i1)
+		for _, k := range [2]int{i, i1} {
+			if p.diagonal(p.prev(k), p.next(k), false) {
+				p.indices[k] |= navDiagonal
+			} else {
+				p.indices[k] &^= navDiagonal
+			}
+		}
+	}
+	tris = append(tris, p.v(0), p.v(1), p.v(2))
+	return tris, nil
+}
+
+// uleft is left for the uint16 vertices of the poly mesh.
+func uleft(verts []uint16, a, b, c uint16) bool {
+	ax, az := int(verts[int(a)*3]), int(verts[int(a)*3+2])
+	bx, bz := int(verts[int(b)*3]), int(verts[int(b)*3+2])
+	cx, cz := int(verts[int(c)*3]), int(verts[int(c)*3+2])
+	return (bx-ax)*(cz-az)-(cx-ax)*(bz-az) < 0
+}
+
+// polyMergeValue is the squared length of the edge shared by pa and pb when
+// merging them gives a convex polygon of at most nvp vertices, -1 otherwise.
+func polyMergeValue(pa, pb, verts []uint16, nvp int) (int, int, int) {
+	na, nb := countPolyVerts(pa, nvp), countPolyVerts(pb, nvp)
+	if na+nb-2 > nvp {
+		return -1, 0, 0
+	}
+
+	ea, eb := -1, -1
+	for i := 0; i < na && ea < 0; i++ {
+		va0, va1 := pa[i], pa[(i+1)%na]
+		if va0 > va1 {
+			va0, va1 = va1, va0
+		}
+		for j := 0; j < nb; j++ {
+			vb0, vb1 := pb[j], pb[(j+1)%nb]
+			if vb0 > vb1 {
+				vb0, vb1 = vb1, vb0
+			}
+			if va0 == vb0 && va1 == vb1 {
+				ea, eb = i, j
+				break
+			}
+		}
+	}
+	if ea < 0 {
+		return -1, 0, 0
+	}
+
+	// the merged polygon stays convex at both ends of the shared edge
+	if !uleft(verts, pa[(ea+na-1)%na], pa[ea], pb[(eb+2)%nb]) {
+		return -1, 0, 0
+	}
+	if !uleft(verts, pb[(eb+nb-1)%nb], pb[eb], pa[(ea+2)%na]) {
+		return -1, 0, 0
+	}
+
+	va, vb := int(pa[ea]), int(pa[(ea+1)%na])
+	dx := int(verts[va*3]) - int(verts[vb*3])
+	dz := int(verts[va*3+2]) - int(verts[vb*3+2])
+	return dx*dx + dz*dz, ea, eb
+}
+
+// mergePolyVerts replaces pa with the union of pa and pb.
+func mergePolyVerts(pa, pb []uint16, ea, eb, nvp int) {
+	na, nb := countPolyVerts(pa, nvp), countPolyVerts(pb, nvp)
+	merged := make([]uint16, 0, nvp)
+	for i := 0; i < na-1; i++ {
+		merged = append(merged, pa[(ea+1+i)%na])
+	}
+	for i := 0; i < nb-1; i++ {
+		merged = append(merged, pb[(eb+1+i)%nb])
+	}
+	for i := range pa[:nvp] {
+		pa[i] = navMeshNullIdx
+		if i < len(merged) {
+			pa[i] = merged[i]
+		}
+	}
+}
+
+// addVertex returns the index of a vertex, vertices at the same x and z
+// within 2 cells of height are shared.
+func (pm *navPolyMesh) addVertex(x, y, z int, buckets map[[2]int][]int) int {
+	key := [2]int{x, z}
+	for _, i := range buckets[key] {
+		if absInt(int(pm.verts[i*3+1])-y) <= 2 {
+			return i
+		}
+	}
+	i := len(pm.verts) / 3
+	pm.verts = append(pm.verts, uint16(x), uint16(y), uint16(z))
+	buckets[key] = append(buckets[key], i)
+	return i
+}
+
+// buildAdjacency links the polygons sharing an edge and marks the open
+// edges on the tile sides as portals.
+func (pm *navPolyMesh) buildAdjacency(borderSize int) {
+	nvp := pm.nvp
+	type edge struct {
+		poly, edge int
+	}
+	open := make(map[[2]uint16]edge)
+	for i := 0; i < pm.polyCount(); i++ {
+		p := pm.poly(i)
+		n := countPolyVerts(p, nvp)
+		for j := 0; j < n; j++ {
+			v0, v1 := p[j], p[(j+1)%n]
+			if v0 < v1 {
+				open[[2]uint16{v0, v1}] = edge{i, j}
+			}
+		}
+	}
+	for i := 0; i < pm.polyCount(); i++ {
+		p := pm.poly(i)
+		n := countPolyVerts(p, nvp)
+		for j := 0; j < n; j++ {
+			v0, v1 := p[j], p[(j+1)%n]
+			if v0 <= v1 {
+				continue
+			}
+			if e, ok := open[[2]uint16{v1, v0}]; ok {
+				delete(open, [2]uint16{v1, v0})
+				p[nvp+j] = uint16(e.poly)
+				pm.poly(e.poly)[nvp+e.edge] = uint16(i)
+			}
+		}
+	}
+
+	if borderSize == 0 {
+		return
+	}
+	for i := 0; i < pm.polyCount(); i++ {
+		p := pm.poly(i)
+		n := countPolyVerts(p, nvp)
+		for j := 0; j < n; j++ {
+			if p[nvp+j] != navMeshNullIdx {
+				continue
+			}
+			va, vb := pm.verts[int(p[j])*3:], pm.verts[int(p[(j+1)%n])*3:]
+			switch {
+			case va[0] == 0 && vb[0] == 0:
+				p[nvp+j] = navPortalFlag | 0
+			case int(va[2]) == pm.height && int(vb[2]) == pm.height:
+				p[nvp+j] = navPortalFlag | 1
+			case int(va[0]) == pm.width && int(vb[0]) == pm.width:
+				p[nvp+j] = navPortalFlag | 2
+			case va[2] == 0 && vb[2] == 0:
+				p[nvp+j] = navPortalFlag | 3
+			}
+		}
+	}
+}
+
+// buildPolyMesh triangulates the contours and merges the triangles of
+// every contour into convex polygons of at most nvp vertices. Contours
+// that can not be fully triangulated keep the triangles found.
+func (chf *navCompactHeightfield) buildPolyMesh(contours []*navContour, nvp int) *navPolyMesh {
+	bs := chf.borderSize
+	pm := &navPolyMesh{
+		nvp:    nvp,
+		bmin:   chf.bmin,
+		bmax:   chf.bmax,
+		cs:     chf.cs,
+		ch:     chf.ch,
+		width:  chf.width - bs*2,
+		height: chf.height - bs*2,
+	}
+	pm.bmin.X += float32(bs) * chf.cs
+	pm.bmin.Z += float32(bs) * chf.cs
+	pm.bmax.X -= float32(bs) * chf.cs
+	pm.bmax.Z -= float32(bs) * chf.cs
+
+	buckets := make(map[[2]int][]int)
+	for _, cont := range contours {
+		n := len(cont.verts) / 4
+		if n < 3 {
+			continue
+		}
+		tris, _ := triangulate(cont.verts, n)
+
+		indices := make([]uint16, n)
+		for j := 0; j < n; j++ {
+			v := cont.verts[j*4:]
+			indices[j] = uint16(pm.addVertex(v[0], v[1], v[2], buckets))
+		}
+
+		var polys [][]uint16
+		for t := 0; t+2 < len(tris); t += 3 {
+			a, b, c := indices[tris[t]], indices[tris[t+1]], indices[tris[t+2]]
+			if a == b || a == c || b == c {
+				continue
+			}
+			p := make([]uint16, nvp)
+			for k := range p {
+				p[k] = navMeshNullIdx
+			}
+			p[0], p[1], p[2] = a, b, c
+			polys = append(polys, p)
+		}
+
+		// merge the pair sharing the longest edge until no pair is left
+		for nvp > 3 {
+			best, bestA, bestB, bestEa, bestEb := 0, 0, 0, 0, 0
+			for j := 0; j < len(polys)-1; j++ {
+				for k := j + 1; k < len(polys); k++ {
+					if v, ea, eb := polyMergeValue(polys[j], polys[k], pm.verts, nvp); v > best {
+						best, bestA, bestB, bestEa, bestEb = v, j, k, ea, eb
+					}
+				}
+			}
+			if best <= 0 {
+				break
+			}
+			mergePolyVerts(polys[bestA], polys[bestB], bestEa, bestEb, nvp)
+			polys[bestB] = polys[len(polys)-1]
+			polys = polys[:len(polys)-1]
+		}
+
+		for _, p := range polys {
+			pm.polys = append(pm.polys, p...)
+			for k := 0; k < nvp; k++ {
+				pm.polys = append(pm.polys, navMeshNullIdx)
+			}
+			pm.regs = append(pm.regs, cont.reg)
+			pm.areas = append(pm.areas, cont.area)
+		}
+	}
+
+	pm.buildAdjacency(bs)
+	return pm
+}
+
+// navDetailMesh is the height detail of the polygons, triangle fans over
+// the polygon vertices in world coordinates. Every mesh is vertex base,
+// vertex count, triangle base and triangle count, every triangle is 3
+// vertex indices local to its mesh and the edge flags.
+type navDetailMesh struct {
+	meshes []uint32
+	verts  []float32
+	tris   []uint8
+}
+
+const navDetailEdgeBoundary = 0x1
+
+// buildDetailMesh triangulates every polygon as a fan, the edges on the
+// polygon outline are flagged for the detour edge queries.
+func (pm *navPolyMesh) buildDetailMesh() *navDetailMesh {
+	dm := &navDetailMesh{}
+	for i := 0; i < pm.polyCount(); i++ {
+		p := pm.poly(i)
+		n := countPolyVerts(p, pm.nvp)
+		dm.meshes = append(dm.meshes, uint32(len(dm.verts)/3), uint32(n), uint32(len(dm.tris)/4), uint32(n-2))
+		for j := 0; j < n; j++ {
+			v := pm.verts[int(p[j])*3:]
+			dm.verts = append(dm.verts,
+				pm.bmin.X+float32(v[0])*pm.cs,
+				pm.bmin.Y+float32(v[1])*pm.ch,
+				pm.bmin.Z+float32(v[2])*pm.cs)
+		}
+		for j := 1; j+1 < n; j++ {
+			// edges 0-j, j-(j+1) and (j+1)-0, two bits per edge
+			flags := uint8(navDetailEdgeBoundary << 2)
+			if j == 1 {
+				flags |= navDetailEdgeBoundary
+			}
+			if j+1 == n-1 {
+				flags |= navDetailEdgeBoundary << 4
+			}
+			dm.tris = append(dm.tris, 0, uint8(j), uint8(j+1), flags)
+		}
+	}
+	return dm
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+const (
+	navMaxQueryNodes = 2048
+	navMaxPathPolys  = 256
+)
+
+// navHalfExtents is the search box used to snap query points onto the mesh.
+var navHalfExtents = []float32{2, 4, 2}
+
+// NavQueryFilter selects which polygons a query may visit. The zero value
+// accepts every polygon with the default area costs.
+type NavQueryFilter struct {
+	IncludeFlags uint16    `json:"include_flags"` // 0 means all flags
+	ExcludeFlags uint16    `json:"exclude_flags"`
+	AreaCosts    []float32 `json:"area_costs"` // cost per area id, missing entries keep the default
+}
+
+// NavPath is the result of a navmesh path query.
+type NavPath struct {
+	Corridor []uint64 `json:"corridor"` // polygon refs from start to end
+	Points   []Vec3   `json:"points"`   // straightened waypoints
+	Flags    []uint8  `json:"flags"`    // straight path flags per waypoint
+	Partial  bool     `json:"partial"`  // the goal was unreachable, the path ends at the closest polygon
+}
+
+func (f *NavQueryFilter) toDetour() *detour.DtQueryFilter {
+	filter := detour.DtAllocQueryFilter()
+	f.apply(filter)
+	return filter
+}
+
+// apply overwrites every setting of filter, area costs missing from f are
+// reset to the default.
+func (f *NavQueryFilter) apply(filter *detour.DtQueryFilter) {
+	if f.IncludeFlags != 0 {
+		filter.SetIncludeFlags(f.IncludeFlags)
+	} else {
+		filter.SetIncludeFlags(0xffff)
+	}
+	filter.SetExcludeFlags(f.ExcludeFlags)
+	for area := 0; area < detour.DT_MAX_AREAS; area++ {
+		cost := float32(1)
+		if area < len(f.AreaCosts) {
+			cost = f.AreaCosts[area]
+		}
+		filter.SetAreaCost(area, cost)
+	}
+}
+
+// getQuery returns the navmesh query of the item, creating it on first use.
+func (item *NavMeshItem) getQuery() (*detour.DtNavMeshQuery, error) {
+	if item.query != nil {
+		return item.query, nil
+	}
+
+	mesh := item.GetNavMesh()
+	if mesh == nil {
+		return nil, errors.New("nav item has no navmesh")
+	}
+
+	query := detour.DtAllocNavMeshQuery()
+	if detour.DtStatusFailed(query.Init(mesh, navMaxQueryNodes)) {
+		return nil, errors.New("init navmesh query failed")
+	}
+	item.query = query
+	return query, nil
+}
+
+// findNearestPoly snaps pos onto the navmesh.
+func findNearestPoly(query *detour.DtNavMeshQuery, pos Vec3, halfExtents []float32, filter *detour.DtQueryFilter) (detour.DtPolyRef, Vec3, error) {
+	var ref detour.DtPolyRef
+	nearest := make([]float32, 3)
+	status := query.FindNearestPoly([]float32{pos.X, pos.Y, pos.Z}, halfExtents, filter, &ref, nearest)
+	if detour.DtStatusFailed(status) || ref == 0 {
+		return 0, pos, errors.New("no polygon found near position")
+	}
+	return ref, Vec3{X: nearest[0], Y: nearest[1], Z: nearest[2]}, nil
+}
+
+// FindPath finds the polygon corridor from start to end and straightens it
+// into waypoints.
+func (m *NavMgr) FindPath(id string, start, end Vec3, filter NavQueryFilter) (*NavPath, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return nil, errors.New("nav item not found")
+	}
+	return item.findPath(start, end, filter.toDetour())
+}
+
+func (item *NavMeshItem) findPath(start, end Vec3, filter *detour.DtQueryFilter) (*NavPath, error) {
+	query, err := item.getQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	startRef, startPos, err := findNearestPoly(query, start, navHalfExtents, filter)
+	if err != nil {
+		return nil, err
+	}
+	endRef, endPos, err := findNearestPoly(query, end, navHalfExtents, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	startArr := []float32{startPos.X, startPos.Y, startPos.Z}
+	endArr := []float32{endPos.X, endPos.Y, endPos.Z}
+
+	polys := make([]detour.DtPolyRef, navMaxPathPolys)
+	var polyCount int
+	status := query.FindPath(startRef, endRef, startArr, endArr, filter, polys, &polyCount, navMaxPathPolys)
+	if detour.DtStatusFailed(status) || polyCount == 0 {
+		return nil, errors.New("find path failed")
+	}
+
+	result := &NavPath{
+		Corridor: make([]uint64, polyCount),
+		Partial:  detour.DtStatusDetail(status, detour.DT_PARTIAL_RESULT),
+	}
+	for i := 0; i < polyCount; i++ {
+		result.Corridor[i] = uint64(polys[i])
+	}
+
+	// for a partial path the end point is clamped onto the last polygon
+	// by FindStraightPath itself
+	straight := make([]float32, navMaxPathPolys*3)
+	straightFlags := make([]detour.DtStraightPathFlags, navMaxPathPolys)
+	straightRefs := make([]detour.DtPolyRef, navMaxPathPolys)
+	var straightCount int
+	status = query.FindStraightPath(startArr, endArr, polys, polyCount, straight, straightFlags, straightRefs, &straightCount, navMaxPathPolys, 0)
+	if detour.DtStatusFailed(status) {
+		return nil, errors.New("find straight path failed")
+	}
+
+	result.Points = make([]Vec3, straightCount)
+	result.Flags = make([]uint8, straightCount)
+	for i := 0; i < straightCount; i++ {
+		result.Points[i] = Vec3{X: straight[i*3], Y: straight[i*3+1], Z: straight[i*3+2]}
+		result.Flags[i] = uint8(straightFlags[i])
+	}
+	return result, nil
+}
+
+// RaycastResult is the result of a raycast on a navmesh or an octree item.
+type RaycastResult struct {
+	Hit      bool     `json:"hit"`
+	Position Vec3     `json:"position"` // hit position, or the end point when nothing was hit
+	Normal   Vec3     `json:"normal"`
+	Visited  []uint64 `json:"visited"` // polygon refs or octree node ids along the ray
+}
+
+// NearestPoint is the closest navigable point to a query position.
+type NearestPoint struct {
+	Found    bool   `json:"found"`
+	Position Vec3   `json:"position"`
+	Ref      uint64 `json:"ref"` // polygon ref or octree node id
+}
+
+// Raycast walks the navmesh surface from start towards end and reports the
+// first wall hit.
+func (m *NavMgr) Raycast(id string, start, end Vec3) (*RaycastResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return nil, errors.New("nav item not found")
+	}
+
+	query, err := item.getQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := (&NavQueryFilter{}).toDetour()
+	startRef, startPos, err := findNearestPoly(query, start, navHalfExtents, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var t float32
+	var polyCount int
+	normal := make([]float32, 3)
+	polys := make([]detour.DtPolyRef, navMaxPathPolys)
+	status := query.Raycast(startRef, []float32{startPos.X, startPos.Y, startPos.Z}, []float32{end.X, end.Y, end.Z}, filter, &t, normal, polys, &polyCount, navMaxPathPolys)
+	if detour.DtStatusFailed(status) {
+		return nil, errors.New("raycast failed")
+	}
+
+	result := &RaycastResult{
+		Position: end,
+		Visited:  make([]uint64, polyCount),
+	}
+	for i := 0; i < polyCount; i++ {
+		result.Visited[i] = uint64(polys[i])
+	}
+
+	// t is FLT_MAX when the ray reached the end point
+	if t <= 1 {
+		result.Hit = true
+		result.Position = Vec3{
+			X: startPos.X + (end.X-startPos.X)*t,
+			Y: startPos.Y + (end.Y-startPos.Y)*t,
+			Z: startPos.Z + (end.Z-startPos.Z)*t,
+		}
+		result.Normal = Vec3{X: normal[0], Y: normal[1], Z: normal[2]}
+	}
+	return result, nil
+}
+
+// FindNearestPoint finds the closest point on the navmesh within the search
+// box pos +- extents.
+func (m *NavMgr) FindNearestPoint(id string, pos, extents Vec3) (*NearestPoint, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return nil, errors.New("nav item not found")
+	}
+
+	query, err := item.getQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	ref, nearest, err := findNearestPoly(query, pos, []float32{extents.X, extents.Y, extents.Z}, (&NavQueryFilter{}).toDetour())
+	if err != nil {
+		return &NearestPoint{Position: pos}, nil
+	}
+	return &NearestPoint{Found: true, Position: nearest, Ref: uint64(ref)}, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"math"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// navPolyGraph is the polygon adjacency of a navmesh, limited to the
+// polygons a query filter accepts.
+type navPolyGraph struct {
+	refs   []detour.DtPolyRef
+	index  map[detour.DtPolyRef]int
+	links  [][]int // outgoing links, off-mesh connections may be one way
+	areas  []float32
+	bounds []Bounds
+}
+
+// polyArea is the surface area of a polygon, off-mesh connections have none.
+func polyArea(verts []Vec3) float32 {
+	var area float64
+	for i := 2; i < len(verts); i++ {
+		a, b, c := verts[0], verts[i-1], verts[i]
+		ux, uy, uz := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+		vx, vy, vz := c.X-a.X, c.Y-a.Y, c.Z-a.Z
+		cx, cy, cz := uy*vz-uz*vy, uz*vx-ux*vz, ux*vy-uy*vx
+		area += 0.5 * math.Sqrt(float64(cx*cx+cy*cy+cz*cz))
+	}
+	return float32(area)
+}
+
+func polyBounds(verts []Vec3) Bounds {
+	var b Bounds
+	for i, v := range verts {
+		if i == 0 {
+			b = Bounds{Min: v, Max: v}
+			continue
+		}
+		b = boundsUnion(b, Bounds{Min: v, Max: v})
+	}
+	return b
+}
+
+func boundsUnion(a, b Bounds) Bounds {
+	return Bounds{
+		Min: Vec3{X: min(a.Min.X, b.Min.X), Y: min(a.Min.Y, b.Min.Y), Z: min(a.Min.Z, b.Min.Z)},
+		Max: Vec3{X: max(a.Max.X, b.Max.X), Y: max(a.Max.Y, b.Max.Y), Z: max(a.Max.Z, b.Max.Z)},
+	}
+}
+
+// polyGraph collects the polygons passing filter and their links.
+func (item *NavMeshItem) polyGraph(filter *detour.DtQueryFilter) (*navPolyGraph, error) {
+	graph := &navPolyGraph{index: make(map[detour.DtPolyRef]int)}
+	type polyLinks struct {
+		tile *detour.DtMeshTile
+		poly *detour.DtPoly
+	}
+	var polys []polyLinks
+	err := item.forEachPoly(func(ref detour.DtPolyRef, tile *detour.DtMeshTile, poly *detour.DtPoly) {
+		if !filter.PassFilter(ref, tile, poly) {
+			return
+		}
+		graph.index[ref] = len(graph.refs)
+		graph.refs = append(graph.refs, ref)
+		verts := polyVerts(tile, poly)
+		graph.areas = append(graph.areas, polyArea(verts))
+		graph.bounds = append(graph.bounds, polyBounds(verts))
+		polys = append(polys, polyLinks{tile, poly})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph.links = make([][]int, len(graph.refs))
+	for i, p := range polys {
+		for l := p.poly.FirstLink; l != detour.DT_NULL_LINK; l = p.tile.Links[l].Next {
+			if to, ok := graph.index[p.tile.Links[l].Ref]; ok {
+				graph.links[i] = append(graph.links[i], to)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// reach marks the polygons reachable from start.
+func (g *navPolyGraph) reach(start int) []bool {
+	visited := make([]bool, len(g.refs))
+	visited[start] = true
+	stack := []int{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range g.links[current] {
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return visited
+}
+
+// islands groups the polygons not in reached, links count both ways.
+func (g *navPolyGraph) islands(reached []bool) []ReachIsland {
+	undirected := make([][]int, len(g.refs))
+	for from, links := range g.links {
+		for _, to := range links {
+			undirected[from] = append(undirected[from], to)
+			undirected[to] = append(undirected[to], from)
+		}
+	}
+
+	var islands []ReachIsland
+	visited := append([]bool(nil), reached...)
+	for i := range g.refs {
+		if visited[i] {
+			continue
+		}
+		island := ReachIsland{Bounds: g.bounds[i]}
+		visited[i] = true
+		stack := []int{i}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			island.Size++
+			island.Area += g.areas[current]
+			island.Bounds = boundsUnion(island.Bounds, g.bounds[current])
+			for _, next := range undirected[current] {
+				if !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		islands = append(islands, island)
+	}
+	return islands
+}
+
+// CheckReachability finds which points reach each other on the polygons
+// filter accepts. With a seed point it also lists the islands of polygons
+// the seed can not reach.
+func (m *NavMgr) CheckReachability(id string, points []ReachPoint, seed string, filter NavQueryFilter) (*ReachabilityReport, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return nil, errors.New("nav item not found")
+	}
+	query, err := item.getQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	report, seedIndex, err := newReachabilityReport(points, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	dtFilter := filter.toDetour()
+	graph, err := item.polyGraph(dtFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// the flood fill decides reachability, the path query is limited to
+	// navMaxPathPolys and only gives the length
+	reached := make([][]bool, len(points))
+	polys := make([]int, len(points))
+	snapped := make([]bool, len(points))
+	for i, point := range points {
+		ref, _, err := findNearestPoly(query, point.Position, navHalfExtents, dtFilter)
+		if err != nil {
+			continue
+		}
+		if polys[i], snapped[i] = graph.index[ref]; snapped[i] {
+			reached[i] = graph.reach(polys[i])
+		}
+	}
+
+	report.fill(snapped, func(from, to int) (float32, bool) {
+		if !reached[from][polys[to]] {
+			return 0, false
+		}
+		path, err := item.findPath(points[from].Position, points[to].Position, dtFilter)
+		if err != nil || path.Partial || path.Corridor[len(path.Corridor)-1] != uint64(graph.refs[polys[to]]) {
+			return -1, true
+		}
+		var length float32
+		for k := 1; k < len(path.Points); k++ {
+			a, b := path.Points[k-1], path.Points[k]
+			dx, dy, dz := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+			length += float32(math.Sqrt(float64(dx*dx + dy*dy + dz*dz)))
+		}
+		return length, true
+	})
+
+	if seedIndex >= 0 {
+		seedReached := reached[seedIndex]
+		if seedReached == nil {
+			seedReached = make([]bool, len(graph.refs))
+		}
+		report.setIslands(graph.islands(seedReached))
+	}
+	return report, nil
+}
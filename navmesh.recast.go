@@ -0,0 +1,646 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// Voxelization and region building of the navmesh build, following the
+// Recast steps with the same cell units.
+const (
+	navSpanMaxHeight = 1<<13 - 1 // span heights are 13 bits in Recast
+	navMaxHeight     = 0xffff
+	navNullArea      = 0
+	navWalkableArea  = 63
+	navNotConnected  = 0x3f
+	navMaxLayers     = navNotConnected - 1
+	navBorderReg     = 0x8000 // region of the tile border cells
+)
+
+// neighbour offsets of the 4 directions, in Recast order
+var (
+	navDirX = [4]int{-1, 0, 1, 0}
+	navDirZ = [4]int{0, 1, 0, -1}
+)
+
+type navSpan struct {
+	smin, smax uint16
+	area       uint8
+}
+
+// navHeightfield is the solid voxel grid, one sorted span list per column.
+type navHeightfield struct {
+	width, height int
+	bmin, bmax    Vec3
+	cs, ch        float32
+	columns       [][]navSpan
+}
+
+func newNavHeightfield(width, height int, bmin, bmax Vec3, cs, ch float32) *navHeightfield {
+	return &navHeightfield{
+		width:   width,
+		height:  height,
+		bmin:    bmin,
+		bmax:    bmax,
+		cs:      cs,
+		ch:      ch,
+		columns: make([][]navSpan, width*height),
+	}
+}
+
+// addSpan inserts a span into a column, merging it with the spans it
+// overlaps. The area of the top most span wins when the tops are within
+// mergeThr.
+func (hf *navHeightfield) addSpan(x, z int, s navSpan, mergeThr int) {
+	column := hf.columns[x+z*hf.width]
+	merged := column[:0:0]
+	for _, cur := range column {
+		if cur.smin > s.smax || cur.smax < s.smin {
+			merged = append(merged, cur)
+			continue
+		}
+		s.smin = min(s.smin, cur.smin)
+		s.smax = max(s.smax, cur.smax)
+		if absInt(int(s.smax)-int(cur.smax)) <= mergeThr {
+			s.area = max(s.area, cur.area)
+		}
+	}
+
+	i := 0
+	for i < len(merged) && merged[i].smin < s.smin {
+		i++
+	}
+	merged = append(merged, navSpan{})
+	copy(merged[i+1:], merged[i:])
+	merged[i] = s
+	hf.columns[x+z*hf.width] = merged
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func clampInt(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+// dividePoly splits a convex polygon by the plane of axis at x, below is the
+// part with smaller coordinates.
+func dividePoly(in []Vec3, x float32, axis int) (below, above []Vec3) {
+	coord := func(v Vec3) float32 {
+		if axis == 0 {
+			return v.X
+		}
+		return v.Z
+	}
+	d := make([]float32, len(in))
+	for i, v := range in {
+		d[i] = x - coord(v)
+	}
+	for i, j := 0, len(in)-1; i < len(in); j, i = i, i+1 {
+		ina, inb := d[j] >= 0, d[i] >= 0
+		if ina != inb {
+			s := d[j] / (d[j] - d[i])
+			p := Vec3{
+				X: in[j].X + (in[i].X-in[j].X)*s,
+				Y: in[j].Y + (in[i].Y-in[j].Y)*s,
+				Z: in[j].Z + (in[i].Z-in[j].Z)*s,
+			}
+			below = append(below, p)
+			above = append(above, p)
+			// points on the dividing line were added above
+			if d[i] > 0 {
+				below = append(below, in[i])
+			} else if d[i] < 0 {
+				above = append(above, in[i])
+			}
+			continue
+		}
+		if d[i] >= 0 {
+			below = append(below, in[i])
+			if d[i] != 0 {
+				continue
+			}
+		}
+		above = append(above, in[i])
+	}
+	return below, above
+}
+
+// rasterizeTriangle adds the spans a triangle covers.
+func (hf *navHeightfield) rasterizeTriangle(tri [3]Vec3, area uint8, mergeThr int) {
+	tmin, tmax := tri[0], tri[0]
+	for _, v := range tri[1:] {
+		tmin = Vec3{X: min(tmin.X, v.X), Y: min(tmin.Y, v.Y), Z: min(tmin.Z, v.Z)}
+		tmax = Vec3{X: max(tmax.X, v.X), Y: max(tmax.Y, v.Y), Z: max(tmax.Z, v.Z)}
+	}
+	if tmin.X > hf.bmax.X || tmax.X < hf.bmin.X || tmin.Y > hf.bmax.Y || tmax.Y < hf.bmin.Y || tmin.Z > hf.bmax.Z || tmax.Z < hf.bmin.Z {
+		return
+	}
+
+	ics, ich := 1/hf.cs, 1/hf.ch
+	by := hf.bmax.Y - hf.bmin.Y
+	z0 := int(math.Floor(float64((tmin.Z - hf.bmin.Z) * ics)))
+	z1 := clampInt(int(math.Floor(float64((tmax.Z-hf.bmin.Z)*ics))), 0, hf.height-1)
+
+	// the parts outside the grid are clipped away, not clamped onto the
+	// border cells
+	rest := tri[:]
+	if z0 < 0 {
+		_, rest = dividePoly(rest, hf.bmin.Z, 2)
+		z0 = 0
+	}
+	for z := z0; z <= z1; z++ {
+		var row []Vec3
+		row, rest = dividePoly(rest, hf.bmin.Z+float32(z+1)*hf.cs, 2)
+		if len(row) < 3 {
+			continue
+		}
+		minX, maxX := row[0].X, row[0].X
+		for _, v := range row[1:] {
+			minX, maxX = min(minX, v.X), max(maxX, v.X)
+		}
+		x0 := int(math.Floor(float64((minX - hf.bmin.X) * ics)))
+		x1 := int(math.Floor(float64((maxX - hf.bmin.X) * ics)))
+		if x1 < 0 || x0 >= hf.width {
+			continue
+		}
+		cells := row
+		if x0 < 0 {
+			_, cells = dividePoly(cells, hf.bmin.X, 0)
+			x0 = 0
+		}
+		x1 = min(x1, hf.width-1)
+
+		for x := x0; x <= x1; x++ {
+			var cell []Vec3
+			cell, cells = dividePoly(cells, hf.bmin.X+float32(x+1)*hf.cs, 0)
+			if len(cell) < 3 {
+				continue
+			}
+			smin, smax := cell[0].Y, cell[0].Y
+			for _, v := range cell[1:] {
+				smin, smax = min(smin, v.Y), max(smax, v.Y)
+			}
+			smin -= hf.bmin.Y
+			smax -= hf.bmin.Y
+			if smax < 0 || smin > by {
+				continue
+			}
+			smin, smax = max(smin, 0), min(smax, by)
+			ismin := clampInt(int(math.Floor(float64(smin*ich))), 0, navSpanMaxHeight)
+			ismax := clampInt(int(math.Ceil(float64(smax*ich))), ismin+1, navSpanMaxHeight)
+			hf.addSpan(x, z, navSpan{smin: uint16(ismin), smax: uint16(ismax), area: area}, mergeThr)
+		}
+	}
+}
+
+// walkableArea marks triangles flatter than the slope angle as walkable.
+func walkableArea(tri [3]Vec3, slopeAngle float32) uint8 {
+	e0 := Vec3{X: tri[1].X - tri[0].X, Y: tri[1].Y - tri[0].Y, Z: tri[1].Z - tri[0].Z}
+	e1 := Vec3{X: tri[2].X - tri[0].X, Y: tri[2].Y - tri[0].Y, Z: tri[2].Z - tri[0].Z}
+	nx := e0.Y*e1.Z - e0.Z*e1.Y
+	ny := e0.Z*e1.X - e0.X*e1.Z
+	nz := e0.X*e1.Y - e0.Y*e1.X
+	length := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz)))
+	if length == 0 || ny/length <= float32(math.Cos(float64(slopeAngle)*math.Pi/180)) {
+		return navNullArea
+	}
+	return navWalkableArea
+}
+
+// filterLowHangingObstacles lets agents step up on low obstacles like curbs.
+func (hf *navHeightfield) filterLowHangingObstacles(walkableClimb int) {
+	for _, column := range hf.columns {
+		previousWalkable := false
+		var previous navSpan
+		for i := range column {
+			s := &column[i]
+			walkable := s.area != navNullArea
+			if !walkable && previousWalkable && absInt(int(s.smax)-int(previous.smax)) <= walkableClimb {
+				s.area = previous.area
+			}
+			previousWalkable = walkable
+			previous = *s
+		}
+	}
+}
+
+// spanTop is the floor of the next span above, the open space of span i
+// ends there.
+func spanTop(column []navSpan, i int) int {
+	if i+1 < len(column) {
+		return int(column[i+1].smin)
+	}
+	return navMaxHeight
+}
+
+// filterLedgeSpans removes walkable spans next to drops higher than
+// walkableClimb and on steep steps.
+func (hf *navHeightfield) filterLedgeSpans(walkableHeight, walkableClimb int) {
+	for z := 0; z < hf.height; z++ {
+		for x := 0; x < hf.width; x++ {
+			column := hf.columns[x+z*hf.width]
+			for i := range column {
+				s := &column[i]
+				if s.area == navNullArea {
+					continue
+				}
+				bot, top := int(s.smax), spanTop(column, i)
+				minh := navMaxHeight
+				asmin, asmax := bot, bot
+
+				for dir := 0; dir < 4; dir++ {
+					nx, nz := x+navDirX[dir], z+navDirZ[dir]
+					if nx < 0 || nz < 0 || nx >= hf.width || nz >= hf.height {
+						minh = min(minh, -walkableClimb-bot)
+						continue
+					}
+					neighbours := hf.columns[nx+nz*hf.width]
+
+					// the space below the first span of the neighbour
+					nbot, ntop := -walkableClimb, navMaxHeight
+					if len(neighbours) > 0 {
+						ntop = int(neighbours[0].smin)
+					}
+					if min(top, ntop)-max(bot, nbot) > walkableHeight {
+						minh = min(minh, nbot-bot)
+					}
+					for k := range neighbours {
+						nbot, ntop = int(neighbours[k].smax), spanTop(neighbours, k)
+						if min(top, ntop)-max(bot, nbot) > walkableHeight {
+							minh = min(minh, nbot-bot)
+							if absInt(nbot-bot) <= walkableClimb {
+								asmin, asmax = min(asmin, nbot), max(asmax, nbot)
+							}
+						}
+					}
+				}
+
+				if minh < -walkableClimb || asmax-asmin > walkableClimb {
+					s.area = navNullArea
+				}
+			}
+		}
+	}
+}
+
+// filterLowHeightSpans removes walkable spans without room for the agent.
+func (hf *navHeightfield) filterLowHeightSpans(walkableHeight int) {
+	for _, column := range hf.columns {
+		for i := range column {
+			if spanTop(column, i)-int(column[i].smax) <= walkableHeight {
+				column[i].area = navNullArea
+			}
+		}
+	}
+}
+
+type navCompactCell struct {
+	index, count int
+}
+
+type navCompactSpan struct {
+	y   uint16
+	reg uint16
+	con uint32 // 6 bits per direction, the layer of the neighbour span
+	h   uint8
+}
+
+func (s *navCompactSpan) getCon(dir int) int {
+	return int(s.con>>(uint(dir)*6)) & 0x3f
+}
+
+func (s *navCompactSpan) setCon(dir, layer int) {
+	shift := uint(dir) * 6
+	s.con = (s.con &^ (0x3f << shift)) | uint32(layer)<<shift
+}
+
+// navCompactHeightfield is the open space above the walkable spans with
+// the links between neighbour spans.
+type navCompactHeightfield struct {
+	width, height  int
+	borderSize     int
+	walkableHeight int
+	walkableClimb  int
+	bmin, bmax     Vec3
+	cs, ch         float32
+	cells          []navCompactCell
+	spans          []navCompactSpan
+	areas          []uint8
+}
+
+// neighbour returns the span index next to span i of cell (x, z) in dir,
+// -1 when they are not connected.
+func (chf *navCompactHeightfield) neighbour(x, z, i, dir int) int {
+	con := chf.spans[i].getCon(dir)
+	if con == navNotConnected {
+		return -1
+	}
+	nx, nz := x+navDirX[dir], z+navDirZ[dir]
+	return chf.cells[nx+nz*chf.width].index + con
+}
+
+func (hf *navHeightfield) compact(walkableHeight, walkableClimb int) *navCompactHeightfield {
+	chf := &navCompactHeightfield{
+		width:          hf.width,
+		height:         hf.height,
+		walkableHeight: walkableHeight,
+		walkableClimb:  walkableClimb,
+		bmin:           hf.bmin,
+		bmax:           hf.bmax,
+		cs:             hf.cs,
+		ch:             hf.ch,
+		cells:          make([]navCompactCell, hf.width*hf.height),
+	}
+	chf.bmax.Y += float32(walkableHeight) * hf.ch
+
+	for c, column := range hf.columns {
+		chf.cells[c].index = len(chf.spans)
+		for i, s := range column {
+			if s.area == navNullArea {
+				continue
+			}
+			bot, top := int(s.smax), spanTop(column, i)
+			chf.spans = append(chf.spans, navCompactSpan{
+				y: uint16(clampInt(bot, 0, 0xffff)),
+				h: uint8(clampInt(top-bot, 0, 0xff)),
+			})
+			chf.areas = append(chf.areas, s.area)
+			chf.cells[c].count++
+		}
+	}
+
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				s := &chf.spans[i]
+				for dir := 0; dir < 4; dir++ {
+					s.setCon(dir, navNotConnected)
+					nx, nz := x+navDirX[dir], z+navDirZ[dir]
+					if nx < 0 || nz < 0 || nx >= chf.width || nz >= chf.height {
+						continue
+					}
+					ncell := chf.cells[nx+nz*chf.width]
+					for k := ncell.index; k < ncell.index+ncell.count; k++ {
+						ns := &chf.spans[k]
+						bot := max(int(s.y), int(ns.y))
+						top := min(int(s.y)+int(s.h), int(ns.y)+int(ns.h))
+						if top-bot >= walkableHeight && absInt(int(ns.y)-int(s.y)) <= walkableClimb {
+							if layer := k - ncell.index; layer <= navMaxLayers {
+								s.setCon(dir, layer)
+							}
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return chf
+}
+
+// erodeWalkableArea removes the spans closer than radius cells to a wall
+// or ledge, so the mesh keeps the agent radius away from them.
+func (chf *navCompactHeightfield) erodeWalkableArea(radius int) {
+	dist := make([]uint8, len(chf.spans))
+	for i := range dist {
+		dist[i] = 0xff
+	}
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if chf.areas[i] == navNullArea {
+					dist[i] = 0
+					continue
+				}
+				neighbours := 0
+				for dir := 0; dir < 4; dir++ {
+					if ni := chf.neighbour(x, z, i, dir); ni >= 0 && chf.areas[ni] != navNullArea {
+						neighbours++
+					}
+				}
+				if neighbours != 4 {
+					dist[i] = 0
+				}
+			}
+		}
+	}
+
+	relax := func(i, ni int, cost uint8) {
+		if d := int(dist[ni]) + int(cost); d < int(dist[i]) {
+			dist[i] = uint8(d)
+		}
+	}
+	// chamfer distance, 2 for straight and 3 for diagonal steps, the
+	// diagonal is reached by turning from dir to the previous direction
+	pass := func(x, z, i int, dirs [2]int) {
+		for _, dir := range dirs {
+			ni := chf.neighbour(x, z, i, dir)
+			if ni < 0 {
+				continue
+			}
+			relax(i, ni, 2)
+			nx, nz := x+navDirX[dir], z+navDirZ[dir]
+			if nni := chf.neighbour(nx, nz, ni, (dir+3)&3); nni >= 0 {
+				relax(i, nni, 3)
+			}
+		}
+	}
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				pass(x, z, i, [2]int{0, 3})
+			}
+		}
+	}
+	for z := chf.height - 1; z >= 0; z-- {
+		for x := chf.width - 1; x >= 0; x-- {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				pass(x, z, i, [2]int{2, 1})
+			}
+		}
+	}
+
+	threshold := uint8(min(radius*2, 0xff))
+	for i := range chf.spans {
+		if dist[i] < threshold {
+			chf.areas[i] = navNullArea
+		}
+	}
+}
+
+// navSweep is a run of spans on one row of the monotone partition.
+type navSweep struct {
+	id  uint16 // final region
+	ns  int    // spans linked to the region of the row above
+	nei uint16 // region of the row above, navNullNei when there are several
+}
+
+const navNullNei = 0xffff
+
+func (chf *navCompactHeightfield) paintRectRegion(minx, maxx, minz, maxz int, reg uint16, regions []uint16) {
+	for z := minz; z < maxz; z++ {
+		for x := minx; x < maxx; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if chf.areas[i] != navNullArea {
+					regions[i] = reg
+				}
+			}
+		}
+	}
+}
+
+// buildRegionsMonotone partitions the walkable spans into regions by
+// sweeping the rows, a run of spans continues the region above when it is
+// the only run below that region. The regions have no holes, so their
+// contours need no hole merging. Groups of connected regions smaller than
+// minRegionArea spans are removed unless they reach the tile border.
+func (chf *navCompactHeightfield) buildRegionsMonotone(minRegionArea int) {
+	w, h, bs := chf.width, chf.height, chf.borderSize
+	regions := make([]uint16, len(chf.spans))
+	id := 1
+	if bs > 0 {
+		bw, bh := min(w, bs), min(h, bs)
+		for _, rect := range [][4]int{{0, bw, 0, h}, {w - bw, w, 0, h}, {0, w, 0, bh}, {0, w, h - bh, h}} {
+			chf.paintRectRegion(rect[0], rect[1], rect[2], rect[3], uint16(id)|navBorderReg, regions)
+			id++
+		}
+	}
+
+	sweeps := make([]navSweep, w+1)
+	for z := bs; z < h-bs; z++ {
+		prev := make([]int, id+1)
+		rid := 1
+		for x := bs; x < w-bs; x++ {
+			cell := chf.cells[x+z*w]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if chf.areas[i] == navNullArea {
+					continue
+				}
+				previd := uint16(0)
+				if ni := chf.neighbour(x, z, i, 0); ni >= 0 && regions[ni]&navBorderReg == 0 && chf.areas[ni] == chf.areas[i] {
+					previd = regions[ni]
+				}
+				if previd == 0 {
+					previd = uint16(rid)
+					rid++
+					sweeps[previd] = navSweep{}
+				}
+				regions[i] = previd
+
+				if ni := chf.neighbour(x, z, i, 3); ni >= 0 && regions[ni] != 0 && regions[ni]&navBorderReg == 0 && chf.areas[ni] == chf.areas[i] {
+					nr := regions[ni]
+					sweep := &sweeps[previd]
+					if sweep.nei == 0 || sweep.nei == nr {
+						sweep.nei = nr
+						sweep.ns++
+						prev[nr]++
+					} else {
+						sweep.nei = navNullNei
+					}
+				}
+			}
+		}
+
+		for i := 1; i < rid; i++ {
+			sweep := &sweeps[i]
+			if sweep.nei != navNullNei && sweep.nei != 0 && prev[sweep.nei] == sweep.ns {
+				sweep.id = sweep.nei
+			} else {
+				sweep.id = uint16(id)
+				id++
+			}
+		}
+		for x := bs; x < w-bs; x++ {
+			cell := chf.cells[x+z*w]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				if regions[i] > 0 && int(regions[i]) < rid {
+					regions[i] = sweeps[regions[i]].id
+				}
+			}
+		}
+	}
+
+	chf.filterSmallRegions(regions, id, minRegionArea)
+	for i := range chf.spans {
+		chf.spans[i].reg = regions[i]
+	}
+}
+
+// filterSmallRegions removes the small islands and numbers the remaining
+// regions from 1.
+func (chf *navCompactHeightfield) filterSmallRegions(regions []uint16, count, minRegionArea int) {
+	spanCount := make([]int, count)
+	toBorder := make([]bool, count)
+	links := make([][]uint16, count)
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				r := regions[i]
+				if r == 0 || r&navBorderReg != 0 {
+					continue
+				}
+				spanCount[r]++
+				for dir := 0; dir < 4; dir++ {
+					ni := chf.neighbour(x, z, i, dir)
+					if ni < 0 || regions[ni] == r || regions[ni] == 0 {
+						continue
+					}
+					if regions[ni]&navBorderReg != 0 {
+						toBorder[r] = true
+						continue
+					}
+					if !slices.Contains(links[r], regions[ni]) {
+						links[r] = append(links[r], regions[ni])
+					}
+				}
+			}
+		}
+	}
+
+	remap := make([]uint16, count)
+	visited := make([]bool, count)
+	next := uint16(1)
+	for r := 1; r < count; r++ {
+		if visited[r] || spanCount[r] == 0 {
+			continue
+		}
+		group := []uint16{uint16(r)}
+		visited[r] = true
+		area, border := 0, false
+		for k := 0; k < len(group); k++ {
+			current := group[k]
+			area += spanCount[current]
+			border = border || toBorder[current]
+			for _, nr := range links[current] {
+				if !visited[nr] {
+					visited[nr] = true
+					group = append(group, nr)
+				}
+			}
+		}
+		if area < minRegionArea && !border {
+			continue
+		}
+		for _, current := range group {
+			remap[current] = next
+			next++
+		}
+	}
+
+	for i, r := range regions {
+		if r != 0 && r&navBorderReg == 0 {
+			regions[i] = remap[r]
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// navRecordVersion 2 added the agents alive at the start and the tile
+// events, version 1 recordings are still read.
+const navRecordVersion = 2
+
+// Recorded operations, one per mutating NavMgr call.
+const (
+	navOpAddAgent      = "add_agent"
+	navOpClearAgent    = "clear_agent"
+	navOpSetTarget     = "set_target"
+	navOpTeleport      = "teleport"
+	navOpUpdate        = "update"
+	navOpStep          = "step"
+	navOpSetTargetById = "set_target_by_id"
+	navOpTeleportById  = "teleport_by_id"
+	navOpRemoveById    = "remove_by_id"
+	navOpSetParamsById = "set_params_by_id"
+	navOpPauseById     = "pause_by_id"
+	navOpSetFilterById = "set_filter_by_id"
+	navOpSetTile       = "set_tile" // a tile replaced by an edit, removed when Data is empty
+)
+
+// NavRecordHeader is the first line of a recording.
+type NavRecordHeader struct {
+	Version  int    `json:"version"`
+	Name     string `json:"name"`
+	NavType  string `json:"nav_type"`
+	DataHash string `json:"data_hash"` // sha256 of the navmesh data when the recording started
+}
+
+// NavRecordEvent is one recorded call. Tick is the number of crowd updates
+// done before the call, update and step events also hold the agent
+// positions after the update in the NavSimSnapshot layout. Tile events hold
+// the tile position in Args and its ref and data.
+type NavRecordEvent struct {
+	Tick    uint64    `json:"tick"`
+	Op      string    `json:"op"`
+	AgentId uint32    `json:"agent_id,omitempty"`
+	Args    []float32 `json:"args,omitempty"`
+	Agents  []float32 `json:"agents,omitempty"`
+	Ref     uint64    `json:"ref,omitempty"`
+	Data    []byte    `json:"data,omitempty"`
+}
+
+type navRecorder struct {
+	file   *os.File
+	writer *bufio.Writer
+	tick   uint64
+	err    error // the first failed write, returned when the recording stops
+}
+
+func navDataHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func boolToFloat(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (r *navRecorder) write(v interface{}) {
+	if r.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		_, err = r.writer.Write(append(data, '\n'))
+	}
+	r.err = err
+}
+
+// record logs a mutating call if the item is being recorded.
+func (item *NavMeshItem) record(op string, agentId uint32, args ...float32) {
+	if item.recorder == nil {
+		return
+	}
+	item.recorder.write(&NavRecordEvent{
+		Tick:    item.recorder.tick,
+		Op:      op,
+		AgentId: agentId,
+		Args:    args,
+	})
+}
+
+// recordTile logs a tile replaced outside of the recorded calls, so the
+// replay changes the navmesh the same way.
+func (item *NavMeshItem) recordTile(x, y, layer int32, ref detour.DtTileRef, data []byte) {
+	if item.recorder == nil {
+		return
+	}
+	item.recorder.write(&NavRecordEvent{
+		Tick: item.recorder.tick,
+		Op:   navOpSetTile,
+		Args: []float32{float32(x), float32(y), float32(layer)},
+		Ref:  uint64(ref),
+		Data: data,
+	})
+}
+
+// recordAgents logs the agents alive when the recording starts as if they
+// were added then, so a replay from the navmesh alone has them too. Free
+// ids below the last agent are added and removed again to keep the ids.
+// Velocities are not kept, the agents start the replay at rest.
+func (item *NavMeshItem) recordAgents() {
+	c := item.GetCrowd()
+	if c == nil {
+		return
+	}
+	last := -1
+	for i := 0; i < c.GetAgentCount(); i++ {
+		if agent := c.GetAgent(i); agent != nil && agent.Active {
+			last = i
+		}
+	}
+
+	var free []uint32
+	for i := 0; i <= last; i++ {
+		agent := c.GetAgent(i)
+		if !agent.Active {
+			// a stand-in at the last agent, which is on the navmesh
+			agent = c.GetAgent(last)
+			free = append(free, uint32(i))
+		}
+		p := &agent.Params
+		item.record(navOpAddAgent, uint32(i), agent.Npos[0], agent.Npos[1], agent.Npos[2], p.Radius, p.Height, p.MaxSpeed, p.MaxAcceleration)
+	}
+	for _, agentId := range free {
+		item.record(navOpRemoveById, agentId)
+	}
+
+	for i := 0; i <= last; i++ {
+		agent := c.GetAgent(i)
+		if !agent.Active {
+			continue
+		}
+		agentId := uint32(i)
+		if slot := agent.Params.QueryFilterType; slot != 0 && item.filters[slot] != nil {
+			item.record(navOpSetFilterById, agentId, item.filters[slot].recordArgs()...)
+		}
+		state, ok := item.agents[agentId]
+		if !ok {
+			continue
+		}
+		if state.hasTarget {
+			item.record(navOpSetTargetById, agentId, state.target.X, state.target.Y, state.target.Z)
+		}
+		if state.paused {
+			item.record(navOpPauseById, agentId, 1)
+		}
+	}
+}
+
+// recordTick logs a crowd update together with the resulting positions.
+func (item *NavMeshItem) recordTick(op string, args ...float32) {
+	if item.recorder == nil {
+		return
+	}
+	item.recorder.write(&NavRecordEvent{
+		Tick:   item.recorder.tick,
+		Op:     op,
+		Args:   args,
+		Agents: item.snapshot("", 0).Agents,
+	})
+	item.recorder.tick++
+}
+
+func (item *NavMeshItem) stopRecording() error {
+	if item.recorder == nil {
+		return nil
+	}
+	r := item.recorder
+	item.recorder = nil
+	err := r.err
+	if flushErr := r.writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// StartRecording logs every following mutating call on the item to filename,
+// starting with the tiles carved by obstacles and the agents already there.
+func (m *NavMgr) StartRecording(id, filename string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	if item.recorder != nil {
+		return errors.New("already recording")
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	item.recorder = &navRecorder{file: file, writer: bufio.NewWriter(file)}
+	item.recorder.write(&NavRecordHeader{
+		Version:  navRecordVersion,
+		Name:     item.name,
+		NavType:  item.navType,
+		DataHash: navDataHash(item.data),
+	})
+	item.recordCacheTiles()
+	item.recordAgents()
+	return nil
+}
+
+func (m *NavMgr) StopRecording(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	return item.stopRecording()
+}
+
+// LoadNavRecord reads a recording written by StartRecording.
+func LoadNavRecord(filename string) (*NavRecordHeader, []*NavRecordEvent, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		return nil, nil, errors.New("empty recording")
+	}
+	header := &NavRecordHeader{}
+	if err = json.Unmarshal(scanner.Bytes(), header); err != nil {
+		return nil, nil, fmt.Errorf("invalid recording header: %v", err)
+	}
+	if header.Version < 1 || header.Version > navRecordVersion {
+		return nil, nil, fmt.Errorf("unsupported recording version: %d", header.Version)
+	}
+
+	var events []*NavRecordEvent
+	for scanner.Scan() {
+		event := &NavRecordEvent{}
+		if err = json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return nil, nil, fmt.Errorf("invalid recording event %d: %v", len(events), err)
+		}
+		events = append(events, event)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return header, events, nil
+}
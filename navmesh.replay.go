@@ -0,0 +1,262 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// navReplayTolerance is the distance above which a replayed agent counts as
+// diverged from the recording.
+const navReplayTolerance = 0.01
+
+// NavReplayState is where a replay stands after a seek or step.
+type NavReplayState struct {
+	Tick       uint64   `json:"tick"`
+	TotalTicks uint64   `json:"total_ticks"`
+	MaxError   float32  `json:"max_error"` // largest distance between replayed and recorded positions at this tick
+	Diverged   []uint32 `json:"diverged"`  // agents off by more than the tolerance, or missing
+}
+
+type navReplay struct {
+	header     *NavRecordHeader
+	events     []*NavRecordEvent
+	cursor     int // next event to apply
+	tick       uint64
+	totalTicks uint64
+	state      *NavReplayState
+}
+
+func isNavTickOp(op string) bool {
+	return op == navOpUpdate || op == navOpStep
+}
+
+// OpenReplay loads a recording and the navmesh it was made on as item id.
+// The replay starts before the first crowd update.
+func (m *NavMgr) OpenReplay(id, recordFile, navFile string) error {
+	header, events, err := LoadNavRecord(recordFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(navFile)
+	if err != nil {
+		return err
+	}
+	if navDataHash(data) != header.DataHash {
+		return errors.New("navmesh data does not match the recording")
+	}
+
+	replay := &navReplay{header: header, events: events}
+	for _, event := range events {
+		if isNavTickOp(event.Op) {
+			replay.totalTicks++
+		}
+	}
+
+	item, err := newNavMeshItem(header.Name, header.NavType, data)
+	if err != nil {
+		return err
+	}
+	item.replay = replay
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.navItems[id]; ok {
+		return errors.New("nav item already exists")
+	}
+	m.navItems[id] = item
+	return nil
+}
+
+// SeekReplay moves the replay to the given tick, going backwards replays the
+// recording from the start.
+func (m *NavMgr) SeekReplay(id string, tick uint64) (*NavReplayState, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	replay := item.replay
+	if replay == nil {
+		return nil, errors.New("nav item is not a replay")
+	}
+
+	if tick < replay.tick {
+		item.stopSimulation()
+		item, err = newNavMeshItem(item.name, item.navType, item.data)
+		if err != nil {
+			return nil, err
+		}
+		replay.cursor = 0
+		replay.tick = 0
+		replay.state = nil
+		item.replay = replay
+		m.navItems[id] = item
+	}
+
+	for replay.cursor < len(replay.events) {
+		event := replay.events[replay.cursor]
+		if isNavTickOp(event.Op) && replay.tick >= tick {
+			break
+		}
+		if err = item.applyRecordEvent(event); err != nil {
+			return nil, fmt.Errorf("replay event %d: %v", replay.cursor, err)
+		}
+		replay.cursor++
+		if isNavTickOp(event.Op) {
+			replay.tick++
+			replay.state = item.compareRecordEvent(event)
+		}
+	}
+
+	state := &NavReplayState{Tick: replay.tick, TotalTicks: replay.totalTicks}
+	if replay.state != nil {
+		state.MaxError = replay.state.MaxError
+		state.Diverged = replay.state.Diverged
+	}
+	return state, nil
+}
+
+// StepReplay moves the replay delta ticks forward or backward.
+func (m *NavMgr) StepReplay(id string, delta int) (*NavReplayState, error) {
+	m.mutex.Lock()
+	item, err := m.getItem(id)
+	if err == nil && item.replay == nil {
+		err = errors.New("nav item is not a replay")
+	}
+	if err != nil {
+		m.mutex.Unlock()
+		return nil, err
+	}
+	tick := int64(item.replay.tick) + int64(delta)
+	m.mutex.Unlock()
+
+	if tick < 0 {
+		tick = 0
+	}
+	return m.SeekReplay(id, uint64(tick))
+}
+
+// applyRecordEvent repeats a recorded call on the item. Errors of the
+// original calls are not recorded, so only a diverging agent id is fatal.
+func (item *NavMeshItem) applyRecordEvent(event *NavRecordEvent) error {
+	args := event.Args
+	arg := func(i int) float32 {
+		if i < len(args) {
+			return args[i]
+		}
+		return 0
+	}
+	pos := Vec3{X: arg(0), Y: arg(1), Z: arg(2)}
+
+	switch event.Op {
+	case navOpAddAgent:
+		agentId := item.AddAgent(arg(0), arg(1), arg(2), arg(3), arg(4), arg(5), arg(6))
+		if agentId < 0 || uint32(agentId) != event.AgentId {
+			return fmt.Errorf("agent id %d, recorded %d", agentId, event.AgentId)
+		}
+		delete(item.agents, event.AgentId)
+	case navOpClearAgent:
+		item.ClearAgent()
+		item.agents = make(map[uint32]*navAgentState)
+	case navOpSetTarget:
+		item.setAgentTarget(pos)
+	case navOpTeleport:
+		item.TeleportAgent(pos.X, pos.Y, pos.Z)
+	case navOpUpdate:
+		item.tick(0)
+	case navOpStep:
+		item.tick(arg(0))
+	case navOpSetTargetById:
+		item.setAgentTargetById(event.AgentId, pos)
+	case navOpTeleportById:
+		item.teleportAgentById(event.AgentId, pos)
+	case navOpRemoveById:
+		item.removeAgentById(event.AgentId)
+	case navOpSetParamsById:
+		item.setAgentParamsById(event.AgentId, ServerAgentParams{Radius: arg(0), Height: arg(1), MaxSpeed: arg(2), MaxAcceleration: arg(3)})
+	case navOpPauseById:
+		item.pauseAgentById(event.AgentId, arg(0) != 0)
+	case navOpSetFilterById:
+		item.setAgentFilterById(event.AgentId, "", filterFromArgs(args))
+	case navOpSetTile:
+		return item.replaceTile(int32(arg(0)), int32(arg(1)), int32(arg(2)), detour.DtTileRef(event.Ref), event.Data)
+	default:
+		return fmt.Errorf("unknown op %s", event.Op)
+	}
+	return nil
+}
+
+// replaceTile removes the tile at x, y, layer and adds data, if any, under
+// ref.
+func (item *NavMeshItem) replaceTile(x, y, layer int32, ref detour.DtTileRef, data []byte) error {
+	mesh := item.GetNavMesh()
+	if mesh == nil {
+		return errors.New("nav item has no navmesh")
+	}
+	var tile *detour.DtMeshTile
+	item.forEachTile(func(_ *detour.DtNavMesh, other *detour.DtMeshTile) {
+		if other.Header.X == x && other.Header.Y == y && other.Header.Layer == layer {
+			tile = other
+		}
+	})
+	if tile != nil && detour.DtStatusFailed(mesh.RemoveTile(mesh.GetTileRef(tile), nil, nil)) {
+		return errors.New("remove navmesh tile failed")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var added detour.DtTileRef
+	data = append([]byte(nil), data...)
+	if detour.DtStatusFailed(mesh.AddTile(data, len(data), detour.DT_TILE_FREE_DATA, ref, &added)) {
+		return fmt.Errorf("add navmesh tile %d,%d failed", x, y)
+	}
+	return nil
+}
+
+// compareRecordEvent compares the current agent positions with the ones
+// recorded after the same tick.
+func (item *NavMeshItem) compareRecordEvent(event *NavRecordEvent) *NavReplayState {
+	state := &NavReplayState{}
+
+	current := make(map[uint32]Vec3)
+	agents := item.snapshot("", 0).Agents
+	for i := 0; i+3 < len(agents); i += 4 {
+		current[uint32(agents[i])] = Vec3{X: agents[i+1], Y: agents[i+2], Z: agents[i+3]}
+	}
+
+	for i := 0; i+3 < len(event.Agents); i += 4 {
+		agentId := uint32(event.Agents[i])
+		pos, ok := current[agentId]
+		if !ok {
+			state.Diverged = append(state.Diverged, agentId)
+			continue
+		}
+		delete(current, agentId)
+
+		dx := pos.X - event.Agents[i+1]
+		dy := pos.Y - event.Agents[i+2]
+		dz := pos.Z - event.Agents[i+3]
+		dist := float32(math.Sqrt(float64(dx*dx + dy*dy + dz*dz)))
+		if dist > state.MaxError {
+			state.MaxError = dist
+		}
+		if dist > navReplayTolerance {
+			state.Diverged = append(state.Diverged, agentId)
+		}
+	}
+
+	// agents which only exist in the replay
+	for agentId := range current {
+		state.Diverged = append(state.Diverged, agentId)
+	}
+	sort.Slice(state.Diverged, func(i, j int) bool { return state.Diverged[i] < state.Diverged[j] })
+	return state
+}
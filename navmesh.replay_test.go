@@ -0,0 +1,104 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestNavReplay records agents on a built navmesh, replays the recording
+// and seeks it both ways, the replayed positions must match exactly.
+func TestNavReplay(t *testing.T) {
+	m, _ := buildTestNavMesh(t, navTestFlatObj, NavBuildParams{TileSize: 11})
+	dir := t.TempDir()
+	navFile, recordFile := filepath.Join(dir, "scene.navmesh"), filepath.Join(dir, "scene.record")
+	if err := m.Save("scene", navFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// agent 0 is gone when the recording starts, agent 1 keeps its id
+	// through a stand-in and starts with a target
+	for _, pos := range []Vec3{{X: -3, Z: -3}, {X: -3, Z: 3}} {
+		if _, err := m.AddAgent("scene", pos.X, pos.Y, pos.Z, 0.5, 2, 3.5, 8); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.RemoveAgentById("scene", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetAgentTargetById("scene", 1, 3, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StartRecording("scene", recordFile); err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(n int) func() error {
+		return func() error {
+			for i := 0; i < n; i++ {
+				if err := m.UpdateAgents("scene"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{"add", func() error {
+			_, err := m.AddAgent("scene", 3, 0, -3, 0.5, 2, 3.5, 8)
+			return err
+		}},
+		{"update", update(5)},
+		{"target", func() error { return m.SetAgentTarget("scene", -3, 0, -3) }},
+		{"update", update(5)},
+		{"teleport", func() error { return m.TeleportAgentById("scene", 1, -2, 0, 2) }},
+		{"pause", func() error { return m.PauseAgentById("scene", 1, true) }},
+		{"update", update(5)},
+		// a tile event between the ticks
+		{"off-mesh connection", func() error {
+			_, err := m.AddOffMeshConnection("scene", NavOffMeshLink{Start: Vec3{X: -3, Z: 0}, End: Vec3{X: 3, Z: 0}, Radius: 0.5, Flags: navPolyFlagWalk})
+			return err
+		}},
+		{"resume", func() error { return m.PauseAgentById("scene", 1, false) }},
+		{"update", update(10)},
+	}
+	for _, c := range calls {
+		if err := c.call(); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+	}
+	if err := m.StopRecording("scene"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.OpenReplay("replay", recordFile, navFile); err != nil {
+		t.Fatal(err)
+	}
+	const total = 25
+	for _, tick := range []uint64{total, 12, 3, 20, total} {
+		state, err := m.SeekReplay("replay", tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Tick != tick || state.TotalTicks != total {
+			t.Fatalf("seek to %d: got tick %d of %d", tick, state.Tick, state.TotalTicks)
+		}
+		if state.MaxError != 0 || len(state.Diverged) != 0 {
+			t.Fatalf("seek to %d: got error %v, agents %v diverged", tick, state.MaxError, state.Diverged)
+		}
+	}
+
+	// the replay ends where the recorded agents did
+	got := m.navItems["replay"].snapshot("", 0).Agents
+	want := m.navItems["scene"].snapshot("", 0).Agents
+	if len(got) != 12 || len(got) != len(want) {
+		t.Fatalf("got %d agent values, want 3 agents", len(got))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got agents %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// NavSimParams controls the simulation loop of a navmesh item.
+type NavSimParams struct {
+	TickRate float32 `json:"tick_rate"` // ticks per second
+	FixedDt  float32 `json:"fixed_dt"`  // seconds per tick, 0 uses the real elapsed time
+}
+
+// NavSimSnapshot is the compact agent state pushed after every tick.
+type NavSimSnapshot struct {
+	Id     string    `json:"id"`
+	Tick   uint64    `json:"tick"`
+	Agents []float32 `json:"agents"`          // id, x, y, z for each agent
+	Error  string    `json:"error,omitempty"` // obstacle tiles which failed to rebuild in this tick
+}
+
+// navSim is the running simulation of one navmesh item.
+type navSim struct {
+	params NavSimParams
+	tick   uint64
+	rate   chan time.Duration
+	stop   chan struct{}
+	done   chan struct{} // closed when the loop returned, after its last sink call
+	sink   func(*NavSimSnapshot)
+}
+
+func (p *NavSimParams) interval() (time.Duration, error) {
+	if p.TickRate <= 0 {
+		return 0, errors.New("tick rate must be positive")
+	}
+	return time.Duration(float64(time.Second) / float64(p.TickRate)), nil
+}
+
+// tick is the single step of UpdateAgents, the simulation and the replays:
+// the changed obstacles are applied, then the crowd moves by dt seconds, or
+// by the fixed step of NavItem.UpdateAgents when dt is 0.
+func (item *NavMeshItem) tick(dt float32) error {
+	err := item.updateObstacles()
+	if dt <= 0 {
+		item.UpdateAgents()
+	} else if c := item.GetCrowd(); c != nil {
+		c.Update(dt, nil)
+	}
+	return err
+}
+
+// snapshot packs the agent positions of the item.
+func (item *NavMeshItem) snapshot(id string, tick uint64) *NavSimSnapshot {
+	snap := &NavSimSnapshot{Id: id, Tick: tick}
+	info := item.GetInfo(false)
+	if info == nil {
+		return snap
+	}
+
+	snap.Agents = make([]float32, 0, len(info.Agents)*4)
+	for _, agent := range info.Agents {
+		snap.Agents = append(snap.Agents, float32(agent.Id), agent.Pos[0], agent.Pos[1], agent.Pos[2])
+	}
+	return snap
+}
+
+// StartSimulation advances the crowd of the item on a goroutine and hands a
+// snapshot to sink after every tick.
+func (m *NavMgr) StartSimulation(id string, params NavSimParams, sink func(*NavSimSnapshot)) error {
+	interval, err := params.interval()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return errors.New("nav item not found")
+	}
+	if item.sim != nil {
+		return errors.New("simulation already running")
+	}
+
+	sim := &navSim{
+		params: params,
+		rate:   make(chan time.Duration, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		sink:   sink,
+	}
+	item.sim = sim
+	go m.runSimulation(id, sim, interval)
+	return nil
+}
+
+// StopSimulation stops the simulation loop of the item, if any, and waits
+// until the loop handed its last snapshot to the sink.
+func (m *NavMgr) StopSimulation(id string) {
+	m.mutex.Lock()
+	item, ok := m.navItems[id]
+	if !ok || item.sim == nil {
+		m.mutex.Unlock()
+		return
+	}
+	sim := item.sim
+	item.stopSimulation()
+	// the loop takes the mutex for every tick
+	m.mutex.Unlock()
+	<-sim.done
+}
+
+func (item *NavMeshItem) stopSimulation() {
+	if item.sim == nil {
+		return
+	}
+	close(item.sim.stop)
+	item.sim = nil
+}
+
+// SetSimulationParams changes tick rate and fixed dt of a running simulation.
+func (m *NavMgr) SetSimulationParams(id string, params NavSimParams) error {
+	interval, err := params.interval()
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok {
+		return errors.New("nav item not found")
+	}
+	if item.sim == nil {
+		return errors.New("simulation not running")
+	}
+
+	item.sim.params = params
+	// drop a pending rate change, the latest one wins
+	select {
+	case <-item.sim.rate:
+	default:
+	}
+	item.sim.rate <- interval
+	return nil
+}
+
+func (m *NavMgr) runSimulation(id string, sim *navSim, interval time.Duration) {
+	defer close(sim.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-sim.stop:
+			return
+		case interval = <-sim.rate:
+			ticker.Reset(interval)
+		case now := <-ticker.C:
+			snap := m.stepSimulation(id, sim, float32(now.Sub(last).Seconds()))
+			last = now
+			if snap == nil {
+				return
+			}
+			sim.sink(snap)
+		}
+	}
+}
+
+// stepSimulation runs one tick, it returns nil when the simulation was
+// stopped in the meantime.
+func (m *NavMgr) stepSimulation(id string, sim *navSim, elapsed float32) *NavSimSnapshot {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, ok := m.navItems[id]
+	if !ok || item.sim != sim {
+		return nil
+	}
+
+	dt := elapsed
+	if sim.params.FixedDt > 0 {
+		dt = sim.params.FixedDt
+	}
+	err := item.tick(dt)
+	item.recordTick(navOpStep, dt)
+	sim.tick++
+	snap := item.snapshot(id, sim.tick)
+	if err != nil {
+		snap.Error = err.Error()
+	}
+	return snap
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// Polygon types of NavPolyInfo.
+const (
+	NavPolyGround  = "ground"
+	NavPolyOffMesh = "offmesh"
+)
+
+// NavTileInfo is the header of a navmesh tile with its polygons and
+// off-mesh links.
+type NavTileInfo struct {
+	Ref             uint64           `json:"ref"`
+	X               int32            `json:"x"`
+	Y               int32            `json:"y"`
+	Layer           int32            `json:"layer"`
+	Bounds          Bounds           `json:"bounds"`
+	PolyCount       int              `json:"poly_count"`
+	VertCount       int              `json:"vert_count"`
+	DetailMeshCount int              `json:"detail_mesh_count"`
+	BvNodeCount     int              `json:"bv_node_count"`
+	OffMeshConCount int              `json:"off_mesh_con_count"`
+	WalkableHeight  float32          `json:"walkable_height"`
+	WalkableRadius  float32          `json:"walkable_radius"`
+	WalkableClimb   float32          `json:"walkable_climb"`
+	Polys           []NavPolyInfo    `json:"polys"`
+	OffMeshLinks    []NavOffMeshLink `json:"off_mesh_links"`
+}
+
+// NavPolyInfo describes a polygon, Links is only filled by GetPoly.
+type NavPolyInfo struct {
+	Ref     uint64          `json:"ref"`
+	TileRef uint64          `json:"tile_ref"`
+	Index   int             `json:"index"` // index in the tile
+	Type    string          `json:"type"`  // one of the NavPoly types
+	Area    uint8           `json:"area"`
+	Flags   uint16          `json:"flags"`
+	Verts   []Vec3          `json:"verts"`
+	Center  Vec3            `json:"center"`
+	Links   []NavPolyLink   `json:"links,omitempty"`
+	OffMesh *NavOffMeshLink `json:"off_mesh,omitempty"`
+}
+
+// NavPolyLink is a link from a polygon edge to a neighbour polygon.
+type NavPolyLink struct {
+	Ref  uint64 `json:"ref"`
+	Edge uint8  `json:"edge"`
+	Side uint8  `json:"side"` // 0xff for neighbours inside the tile
+}
+
+// NavOffMeshLink is an off-mesh connection and the polygon it is stored as.
+type NavOffMeshLink struct {
+	Ref           uint64  `json:"ref"`
+	Start         Vec3    `json:"start"`
+	End           Vec3    `json:"end"`
+	Radius        float32 `json:"radius"`
+	Bidirectional bool    `json:"bidirectional"`
+	Area          uint8   `json:"area"`
+	Flags         uint16  `json:"flags"`
+	UserId        uint32  `json:"user_id"`
+}
+
+// forEachTile calls fn for every tile of the navmesh.
+func (item *NavMeshItem) forEachTile(fn func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile)) error {
+	mesh := item.GetNavMesh()
+	if mesh == nil {
+		return errors.New("nav item has no navmesh")
+	}
+	for i := 0; i < int(mesh.GetMaxTiles()); i++ {
+		tile := mesh.GetTile(i)
+		if tile == nil || tile.Header == nil {
+			continue
+		}
+		fn(mesh, tile)
+	}
+	return nil
+}
+
+// forEachPoly calls fn for every polygon of the navmesh.
+func (item *NavMeshItem) forEachPoly(fn func(ref detour.DtPolyRef, tile *detour.DtMeshTile, poly *detour.DtPoly)) error {
+	return item.forEachTile(func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		base := mesh.GetPolyRefBase(tile)
+		for j := 0; j < int(tile.Header.PolyCount); j++ {
+			fn(base|detour.DtPolyRef(j), tile, &tile.Polys[j])
+		}
+	})
+}
+
+func polyVerts(tile *detour.DtMeshTile, poly *detour.DtPoly) []Vec3 {
+	verts := make([]Vec3, poly.VertCount)
+	for i := range verts {
+		v := tile.Verts[int(poly.Verts[i])*3:]
+		verts[i] = Vec3{X: v[0], Y: v[1], Z: v[2]}
+	}
+	return verts
+}
+
+func offMeshLink(mesh *detour.DtNavMesh, tile *detour.DtMeshTile, con *detour.DtOffMeshConnection) NavOffMeshLink {
+	poly := &tile.Polys[con.Poly]
+	return NavOffMeshLink{
+		Ref:           uint64(mesh.GetPolyRefBase(tile) | detour.DtPolyRef(con.Poly)),
+		Start:         Vec3{X: con.Pos[0], Y: con.Pos[1], Z: con.Pos[2]},
+		End:           Vec3{X: con.Pos[3], Y: con.Pos[4], Z: con.Pos[5]},
+		Radius:        con.Rad,
+		Bidirectional: con.Flags&detour.DT_OFFMESH_CON_BIDIR != 0,
+		Area:          poly.GetArea(),
+		Flags:         poly.Flags,
+		UserId:        con.UserId,
+	}
+}
+
+func polyInfo(mesh *detour.DtNavMesh, tile *detour.DtMeshTile, ref detour.DtPolyRef, poly *detour.DtPoly) NavPolyInfo {
+	info := NavPolyInfo{
+		Ref:     uint64(ref),
+		TileRef: uint64(mesh.GetTileRef(tile)),
+		Index:   int(ref - mesh.GetPolyRefBase(tile)),
+		Type:    NavPolyGround,
+		Area:    poly.GetArea(),
+		Flags:   poly.Flags,
+		Verts:   polyVerts(tile, poly),
+	}
+	for _, v := range info.Verts {
+		info.Center.X += v.X / float32(len(info.Verts))
+		info.Center.Y += v.Y / float32(len(info.Verts))
+		info.Center.Z += v.Z / float32(len(info.Verts))
+	}
+	if poly.GetType() == detour.DT_POLYTYPE_OFFMESH_CONNECTION {
+		info.Type = NavPolyOffMesh
+	}
+	return info
+}
+
+// GetTiles lists the tiles of a navmesh with their polygons.
+func (m *NavMgr) GetTiles(id string) ([]NavTileInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tiles := []NavTileInfo{}
+	err = item.forEachTile(func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		h := tile.Header
+		info := NavTileInfo{
+			Ref:   uint64(mesh.GetTileRef(tile)),
+			X:     h.X,
+			Y:     h.Y,
+			Layer: h.Layer,
+			Bounds: Bounds{
+				Min: Vec3{X: h.Bmin[0], Y: h.Bmin[1], Z: h.Bmin[2]},
+				Max: Vec3{X: h.Bmax[0], Y: h.Bmax[1], Z: h.Bmax[2]},
+			},
+			PolyCount:       int(h.PolyCount),
+			VertCount:       int(h.VertCount),
+			DetailMeshCount: int(h.DetailMeshCount),
+			BvNodeCount:     int(h.BvNodeCount),
+			OffMeshConCount: int(h.OffMeshConCount),
+			WalkableHeight:  h.WalkableHeight,
+			WalkableRadius:  h.WalkableRadius,
+			WalkableClimb:   h.WalkableClimb,
+		}
+
+		base := mesh.GetPolyRefBase(tile)
+		for j := 0; j < int(h.PolyCount); j++ {
+			info.Polys = append(info.Polys, polyInfo(mesh, tile, base|detour.DtPolyRef(j), &tile.Polys[j]))
+		}
+		for j := 0; j < int(h.OffMeshConCount); j++ {
+			info.OffMeshLinks = append(info.OffMeshLinks, offMeshLink(mesh, tile, &tile.OffMeshCons[j]))
+		}
+		tiles = append(tiles, info)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tiles, nil
+}
+
+// GetPoly describes one polygon with its neighbour links.
+func (m *NavMgr) GetPoly(id string, polyRef uint64) (*NavPolyInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	mesh := item.GetNavMesh()
+	if mesh == nil {
+		return nil, errors.New("nav item has no navmesh")
+	}
+
+	var tile *detour.DtMeshTile
+	var poly *detour.DtPoly
+	ref := detour.DtPolyRef(polyRef)
+	if detour.DtStatusFailed(mesh.GetTileAndPolyByRef(ref, &tile, &poly)) {
+		return nil, errors.New("polygon not found")
+	}
+
+	info := polyInfo(mesh, tile, ref, poly)
+	for l := poly.FirstLink; l != detour.DT_NULL_LINK; l = tile.Links[l].Next {
+		link := &tile.Links[l]
+		info.Links = append(info.Links, NavPolyLink{Ref: uint64(link.Ref), Edge: link.Edge, Side: link.Side})
+	}
+	if info.Type == NavPolyOffMesh {
+		for j := 0; j < int(tile.Header.OffMeshConCount); j++ {
+			if con := &tile.OffMeshCons[j]; int(con.Poly) == info.Index {
+				offMesh := offMeshLink(mesh, tile, con)
+				info.OffMesh = &offMesh
+				break
+			}
+		}
+	}
+	return &info, nil
+}
@@ -0,0 +1,647 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/o0olele/detour-go/crowd"
+	"github.com/o0olele/detour-go/detour"
+)
+
+// NavObstacle is a temporary obstacle of a navmesh item, an upright
+// cylinder standing on Pos or, when Radius is 0, a box.
+type NavObstacle struct {
+	Pos    Vec3    `json:"pos"`
+	Radius float32 `json:"radius"`
+	Height float32 `json:"height"`
+	Box    *Bounds `json:"box"`
+}
+
+// NavObstacleInfo is an obstacle of a navmesh item.
+type NavObstacleInfo struct {
+	Id       string      `json:"id"`
+	Obstacle NavObstacle `json:"obstacle"`
+	Pending  bool        `json:"pending"` // the tiles under it are rebuilt on the next update
+}
+
+func (o *NavObstacle) validate() error {
+	if o.Radius > 0 {
+		if o.Height <= 0 {
+			return errors.New("cylinder obstacle height must be positive")
+		}
+		return nil
+	}
+	if o.Box == nil {
+		return errors.New("obstacle needs a radius or a box")
+	}
+	if o.Box.Min.X > o.Box.Max.X || o.Box.Min.Y > o.Box.Max.Y || o.Box.Min.Z > o.Box.Max.Z {
+		return errors.New("obstacle box min is above its max")
+	}
+	return nil
+}
+
+func (o *NavObstacle) bounds() Bounds {
+	if o.Radius > 0 {
+		return Bounds{
+			Min: Vec3{X: o.Pos.X - o.Radius, Y: o.Pos.Y, Z: o.Pos.Z - o.Radius},
+			Max: Vec3{X: o.Pos.X + o.Radius, Y: o.Pos.Y + o.Height, Z: o.Pos.Z + o.Radius},
+		}
+	}
+	return *o.Box
+}
+
+// blocks reports whether the floor at x, y, z is inside the obstacle grown
+// by pad around its sides.
+func (o *NavObstacle) blocks(x, y, z, pad float32) bool {
+	b := o.bounds()
+	if y < b.Min.Y || y > b.Max.Y {
+		return false
+	}
+	if o.Radius > 0 {
+		dx, dz := x-o.Pos.X, z-o.Pos.Z
+		r := o.Radius + pad
+		return dx*dx+dz*dz <= r*r
+	}
+	return x >= b.Min.X-pad && x <= b.Max.X+pad && z >= b.Min.Z-pad && z <= b.Max.Z+pad
+}
+
+// navCacheBorder is the border in cells around the tiles of a cache made
+// from a loaded navmesh, wide enough for the tile sides to become portals.
+const navCacheBorder = 3
+
+// navCacheTile is the compact heightfield of a tile, the input of the
+// stages rerun when obstacles change.
+type navCacheTile struct {
+	x, y, layer int32 // the detour tile
+	bmin, bmax  Vec3  // bounds of chf, with the border
+	chf         *navCompactHeightfield
+	dirty       bool
+	carved      bool   // the navmesh holds a tile rebuilt with obstacles
+	orig        []byte // the tile data before it was carved, nil if there was none
+}
+
+// navTileCache keeps the tiles of a navmesh and carves the obstacles out of
+// them, like the tile cache of Detour. Obstacles are grown by the agent
+// radius since the cached tiles are already eroded. A tile no obstacle
+// touches any more gets its data from before the first carve back.
+type navTileCache struct {
+	build     navBuild // the settings of the build, without its geometry
+	tiles     []*navCacheTile
+	obstacles map[string]NavObstacle
+	pending   map[string]Bounds // area of the obstacles changed since the tiles were last rebuilt
+}
+
+func newNavTileCache(b *navBuild) *navTileCache {
+	c := &navTileCache{
+		build:     *b,
+		obstacles: make(map[string]NavObstacle),
+		pending:   make(map[string]Bounds),
+	}
+	c.build.triangles, c.build.areas = nil, nil
+	c.build.params.Debug = false
+	c.build.cache = nil
+	return c
+}
+
+func (c *navTileCache) add(tx, tz int, chf *navCompactHeightfield) {
+	c.tiles = append(c.tiles, &navCacheTile{x: int32(tx), y: int32(tz), bmin: chf.bmin, bmax: chf.bmax, chf: chf})
+}
+
+// busy reports whether tiles are carved or about to change, the navmesh
+// data of the item must not be saved then.
+func (c *navTileCache) busy() bool {
+	if len(c.obstacles) > 0 || len(c.pending) > 0 {
+		return true
+	}
+	for _, t := range c.tiles {
+		if t.carved {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether b grown by the agent radius reaches the tile.
+func (c *navTileCache) overlaps(t *navCacheTile, b Bounds) bool {
+	pad := c.build.params.AgentRadius
+	return b.Min.X-pad <= t.bmax.X && b.Max.X+pad >= t.bmin.X &&
+		b.Min.Z-pad <= t.bmax.Z && b.Max.Z+pad >= t.bmin.Z
+}
+
+// touch marks the tiles overlapping b as dirty.
+func (c *navTileCache) touch(b Bounds) {
+	for _, t := range c.tiles {
+		if c.overlaps(t, b) {
+			t.dirty = true
+		}
+	}
+}
+
+// navAreaType is the detour area and polygon flags of a heightfield area of
+// a cache made from a loaded navmesh.
+type navAreaType struct {
+	area  uint8
+	flags uint16
+}
+
+// areaType returns the detour area and flags of the polygons built from a
+// heightfield area, false for areas which get no polygons.
+func (b *navBuild) areaType(area uint8) (navAreaType, bool) {
+	if b.areaTypes == nil {
+		return navAreaType{area: navAreaGround, flags: navPolyFlagWalk}, area == navWalkableArea
+	}
+	if area == navNullArea || int(area) > len(b.areaTypes) {
+		return navAreaType{}, false
+	}
+	return b.areaTypes[area-1], true
+}
+
+// tilePolyTriangles returns the detail triangles of polygon i of tile, the
+// triangle fan of the polygon when the tile has no detail meshes.
+func tilePolyTriangles(tile *detour.DtMeshTile, i int) [][3]Vec3 {
+	poly := &tile.Polys[i]
+	if i >= int(tile.Header.DetailMeshCount) {
+		verts := polyVerts(tile, poly)
+		tris := make([][3]Vec3, 0, len(verts))
+		for j := 2; j < len(verts); j++ {
+			tris = append(tris, [3]Vec3{verts[0], verts[j-1], verts[j]})
+		}
+		return tris
+	}
+	dm := &tile.DetailMeshes[i]
+	tris := make([][3]Vec3, 0, dm.TriCount)
+	for k := uint32(0); k < uint32(dm.TriCount); k++ {
+		t := tile.DetailTris[(dm.TriBase+k)*4:]
+		var tri [3]Vec3
+		for j := 0; j < 3; j++ {
+			var v []float32
+			if int(t[j]) < int(poly.VertCount) {
+				v = tile.Verts[int(poly.Verts[t[j]])*3:]
+			} else {
+				v = tile.DetailVerts[(dm.VertBase+uint32(t[j])-uint32(poly.VertCount))*3:]
+			}
+			tri[j] = Vec3{X: v[0], Y: v[1], Z: v[2]}
+		}
+		tris = append(tris, tri)
+	}
+	return tris
+}
+
+// newLoadedTileCache makes the tile cache of a navmesh loaded from a file.
+// The polygons of every tile and of the border around it are voxelized
+// again, one heightfield area per detour area and flags, so the rebuilt
+// tiles follow the loaded ones to the cell size.
+func newLoadedTileCache(item *NavMeshItem) (*navTileCache, error) {
+	type loadedTile struct {
+		x, y, layer int32
+		bmin, bmax  [3]float32
+		tris        [][3]Vec3
+		areas       []uint8
+	}
+	var tiles []*loadedTile
+	var areaTypes []navAreaType
+	var cs, agentHeight, agentRadius, agentClimb float32
+	var tileErr error
+	err := item.forEachTile(func(_ *detour.DtNavMesh, tile *detour.DtMeshTile) {
+		h := tile.Header
+		if tileErr != nil {
+			return
+		}
+		if h.BvQuantFactor <= 0 {
+			tileErr = errors.New("tile without cell size")
+			return
+		}
+		if cs == 0 {
+			cs, agentHeight, agentRadius, agentClimb = 1/h.BvQuantFactor, h.WalkableHeight, h.WalkableRadius, h.WalkableClimb
+		} else if math.Abs(float64(1/h.BvQuantFactor-cs)) > 1e-4 {
+			tileErr = errors.New("navmesh tiles of different cell sizes")
+			return
+		}
+
+		lt := &loadedTile{x: h.X, y: h.Y, layer: h.Layer, bmin: h.Bmin, bmax: h.Bmax}
+		for i := 0; i < int(h.PolyCount); i++ {
+			poly := &tile.Polys[i]
+			if poly.GetType() == detour.DT_POLYTYPE_OFFMESH_CONNECTION {
+				continue
+			}
+			at := navAreaType{area: poly.GetArea(), flags: poly.Flags}
+			area := slices.Index(areaTypes, at) + 1
+			if area == 0 {
+				if len(areaTypes) >= navWalkableArea-1 {
+					tileErr = errors.New("too many area and flag combinations for the tile cache")
+					return
+				}
+				areaTypes = append(areaTypes, at)
+				area = len(areaTypes)
+			}
+			for _, tri := range tilePolyTriangles(tile, i) {
+				lt.tris = append(lt.tris, tri)
+				lt.areas = append(lt.areas, uint8(area))
+			}
+		}
+		tiles = append(tiles, lt)
+	})
+	if err == nil {
+		err = tileErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tiles) == 0 {
+		return nil, errors.New("navmesh has no tiles")
+	}
+
+	params := NavBuildParams{
+		CellSize:      cs,
+		CellHeight:    cs / 2,
+		AgentHeight:   agentHeight,
+		AgentRadius:   agentRadius,
+		AgentMaxClimb: agentClimb,
+	}.withDefaults()
+	c := newNavTileCache(&navBuild{
+		params:         params,
+		result:         &NavBuildResult{Params: params},
+		walkableHeight: int(math.Ceil(float64(params.AgentHeight / params.CellHeight))),
+		walkableClimb:  int(math.Floor(float64(params.AgentMaxClimb / params.CellHeight))),
+		walkableRadius: int(math.Ceil(float64(params.AgentRadius / params.CellSize))),
+		borderSize:     navCacheBorder,
+		areaTypes:      areaTypes,
+	})
+	b := &c.build
+
+	bs := float32(navCacheBorder) * cs
+	for _, lt := range tiles {
+		bmin := Vec3{X: lt.bmin[0] - bs, Y: lt.bmin[1], Z: lt.bmin[2] - bs}
+		bmax := Vec3{X: lt.bmax[0] + bs, Y: lt.bmax[1], Z: lt.bmax[2] + bs}
+		var near []*loadedTile
+		for _, other := range tiles {
+			if other.bmin[0] <= bmax.X && other.bmax[0] >= bmin.X && other.bmin[2] <= bmax.Z && other.bmax[2] >= bmin.Z &&
+				other.bmin[1] <= lt.bmax[1]+params.AgentMaxClimb && other.bmax[1] >= lt.bmin[1]-params.AgentMaxClimb {
+				near = append(near, other)
+				bmin.Y, bmax.Y = min(bmin.Y, other.bmin[1]), max(bmax.Y, other.bmax[1])
+			}
+		}
+		bmin.Y -= params.CellHeight
+		bmax.Y += params.CellHeight
+
+		width := int(math.Round(float64((lt.bmax[0]-lt.bmin[0])/cs))) + navCacheBorder*2
+		height := int(math.Round(float64((lt.bmax[2]-lt.bmin[2])/cs))) + navCacheBorder*2
+		hf := newNavHeightfield(width, height, bmin, bmax, cs, params.CellHeight)
+		for _, other := range near {
+			for i, tri := range other.tris {
+				hf.rasterizeTriangle(tri, other.areas[i], b.walkableClimb)
+			}
+		}
+		chf := hf.compact(b.walkableHeight, b.walkableClimb)
+		chf.borderSize = navCacheBorder
+		c.tiles = append(c.tiles, &navCacheTile{x: lt.x, y: lt.y, layer: lt.layer, bmin: chf.bmin, bmax: chf.bmax, chf: chf})
+	}
+	return c, nil
+}
+
+// clone copies the parts of the heightfield the later stages change.
+func (chf *navCompactHeightfield) clone() *navCompactHeightfield {
+	copied := *chf
+	copied.spans = append([]navCompactSpan(nil), chf.spans...)
+	copied.areas = append([]uint8(nil), chf.areas...)
+	return &copied
+}
+
+// markObstacle clears the area of the spans whose floor the obstacle
+// blocks.
+func (chf *navCompactHeightfield) markObstacle(o *NavObstacle, pad float32) {
+	for z := 0; z < chf.height; z++ {
+		for x := 0; x < chf.width; x++ {
+			cx := chf.bmin.X + (float32(x)+0.5)*chf.cs
+			cz := chf.bmin.Z + (float32(z)+0.5)*chf.cs
+			cell := chf.cells[x+z*chf.width]
+			for i := cell.index; i < cell.index+cell.count; i++ {
+				y := chf.bmin.Y + float32(chf.spans[i].y)*chf.ch
+				if o.blocks(cx, y, cz, pad) {
+					chf.areas[i] = navNullArea
+				}
+			}
+		}
+	}
+}
+
+// buildTile builds the detour data of a cached tile with the obstacles cut
+// out, nil when nothing walkable is left.
+func (c *navTileCache) buildTile(t *navCacheTile, links []NavOffMeshLink) ([]byte, error) {
+	chf := t.chf.clone()
+	for _, o := range c.obstacles {
+		chf.markObstacle(&o, c.build.params.AgentRadius)
+	}
+	c.build.result = &NavBuildResult{}
+	data, _, err := c.build.buildTileMesh(chf, int(t.x), int(t.y), links)
+	return data, err
+}
+
+// updateCacheTile replaces the navmesh tile of t by one built with the
+// obstacles, or by its data from before the first carve when no obstacle
+// touches it. The new tile gets a new ref, which makes the crowd drop the
+// corridors through it. The replacement is recorded, on failure the old
+// tile stays.
+func (item *NavMeshItem) updateCacheTile(t *navCacheTile) error {
+	c := item.tileCache
+	mesh := item.GetNavMesh()
+	var tile *detour.DtMeshTile
+	item.forEachTile(func(_ *detour.DtNavMesh, other *detour.DtMeshTile) {
+		if other.Header.X == t.x && other.Header.Y == t.y && other.Header.Layer == t.layer {
+			tile = other
+		}
+	})
+	var old []byte
+	if tile != nil {
+		old = append([]byte(nil), tile.Data[:tile.DataSize]...)
+	}
+
+	carve := false
+	for _, o := range c.obstacles {
+		if c.overlaps(t, o.bounds()) {
+			carve = true
+			break
+		}
+	}
+	var data []byte
+	switch {
+	case carve:
+		var links []NavOffMeshLink
+		if tile != nil {
+			links = tileOffMeshLinks(mesh, tile)
+		}
+		var err error
+		if data, err = c.buildTile(t, links); err != nil {
+			return err
+		}
+	case t.carved:
+		data = append([]byte(nil), t.orig...)
+	default:
+		return nil
+	}
+
+	var ref detour.DtTileRef
+	if tile != nil {
+		ref = mesh.GetTileRef(tile)
+		if detour.DtStatusFailed(mesh.RemoveTile(ref, nil, nil)) {
+			return errors.New("remove navmesh tile failed")
+		}
+	}
+	var added detour.DtTileRef
+	if len(data) > 0 && detour.DtStatusFailed(mesh.AddTile(data, len(data), detour.DT_TILE_FREE_DATA, 0, &added)) {
+		err := fmt.Errorf("add navmesh tile %d,%d failed", t.x, t.y)
+		if old != nil && detour.DtStatusFailed(mesh.AddTile(old, len(old), detour.DT_TILE_FREE_DATA, ref, &added)) {
+			err = errors.Join(err, fmt.Errorf("restore navmesh tile %d,%d failed", t.x, t.y))
+		}
+		return err
+	}
+	if !t.carved {
+		t.orig = old
+	}
+	t.carved = carve
+	if !carve {
+		t.orig = nil
+	}
+	item.recordTile(t.x, t.y, t.layer, added, data)
+	return nil
+}
+
+// updateObstacles rebuilds the tiles under the obstacles changed since the
+// last update, and the agents with a target plan again. Tiles which fail
+// to build stay dirty and are tried again on the next update.
+func (item *NavMeshItem) updateObstacles() error {
+	c := item.tileCache
+	if c == nil || len(c.pending) == 0 {
+		return nil
+	}
+	if item.GetNavMesh() == nil {
+		return errors.New("nav item has no navmesh")
+	}
+
+	var errs []error
+	changed := false
+	for _, t := range c.tiles {
+		if !t.dirty {
+			continue
+		}
+		if err := item.updateCacheTile(t); err != nil {
+			errs = append(errs, fmt.Errorf("tile %d,%d: %v", t.x, t.y, err))
+			continue
+		}
+		t.dirty = false
+		changed = true
+	}
+	for obstacleId, b := range c.pending {
+		dirty := false
+		for _, t := range c.tiles {
+			if t.dirty && c.overlaps(t, b) {
+				dirty = true
+				break
+			}
+		}
+		if !dirty {
+			delete(c.pending, obstacleId)
+		}
+	}
+	if changed {
+		item.repathAgents()
+	}
+	return errors.Join(errs...)
+}
+
+// recordCacheTiles logs the carved tiles when a recording starts, the
+// recorded navmesh data is the one without obstacles.
+func (item *NavMeshItem) recordCacheTiles() {
+	if item.tileCache == nil {
+		return
+	}
+	for _, t := range item.tileCache.tiles {
+		if !t.carved {
+			continue
+		}
+		var ref detour.DtTileRef
+		var data []byte
+		item.forEachTile(func(mesh *detour.DtNavMesh, tile *detour.DtMeshTile) {
+			if tile.Header.X == t.x && tile.Header.Y == t.y && tile.Header.Layer == t.layer {
+				ref, data = mesh.GetTileRef(tile), tile.Data[:tile.DataSize]
+			}
+		})
+		item.recordTile(t.x, t.y, t.layer, ref, data)
+	}
+}
+
+// repathAgents requests the move target of every agent heading somewhere
+// again, so the paths go around the changed tiles.
+func (item *NavMeshItem) repathAgents() {
+	c := item.GetCrowd()
+	if c == nil {
+		return
+	}
+	for i := 0; i < c.GetAgentCount(); i++ {
+		agent := c.GetAgent(i)
+		if agent == nil || !agent.Active ||
+			agent.TargetState == crowd.DT_CROWDAGENT_TARGET_NONE || agent.TargetState == crowd.DT_CROWDAGENT_TARGET_VELOCITY {
+			continue
+		}
+		target := Vec3{X: agent.TargetPos[0], Y: agent.TargetPos[1], Z: agent.TargetPos[2]}
+		item.requestMoveTarget(uint32(i), target)
+	}
+}
+
+// debugObstacles outlines the obstacles, pending ones in yellow.
+func debugObstacles(obstacles []NavObstacleInfo) *DebugDrawerPrimitive {
+	p := &DebugDrawerPrimitive{Type: navDrawLines}
+	const segments = 16
+	for _, info := range obstacles {
+		o := info.Obstacle
+		color := [4]float32{0.9, 0.2, 0.1, 1}
+		if info.Pending {
+			color = [4]float32{1, 0.8, 0.1, 1}
+		}
+		line := func(a, b Vec3) {
+			p.Vertices = append(p.Vertices, debugVertex(a.X, a.Y, a.Z, color), debugVertex(b.X, b.Y, b.Z, color))
+		}
+		b := o.bounds()
+		if o.Radius > 0 {
+			point := func(k int, y float32) Vec3 {
+				a := float64(k) * 2 * math.Pi / segments
+				return Vec3{X: o.Pos.X + o.Radius*float32(math.Cos(a)), Y: y, Z: o.Pos.Z + o.Radius*float32(math.Sin(a))}
+			}
+			for k := 0; k < segments; k++ {
+				line(point(k, b.Min.Y), point(k+1, b.Min.Y))
+				line(point(k, b.Max.Y), point(k+1, b.Max.Y))
+				if k%4 == 0 {
+					line(point(k, b.Min.Y), point(k, b.Max.Y))
+				}
+			}
+			continue
+		}
+		corner := func(i int) Vec3 {
+			v := b.Min
+			if i&1 != 0 {
+				v.X = b.Max.X
+			}
+			if i&2 != 0 {
+				v.Y = b.Max.Y
+			}
+			if i&4 != 0 {
+				v.Z = b.Max.Z
+			}
+			return v
+		}
+		for i := 0; i < 8; i++ {
+			for _, bit := range [3]int{1, 2, 4} {
+				if i&bit == 0 {
+					line(corner(i), corner(i|bit))
+				}
+			}
+		}
+	}
+	return p
+}
+
+// getTileCache returns the item with its tile cache, a navmesh loaded from
+// a file gets one made from its tiles.
+func (m *NavMgr) getTileCache(id string) (*NavMeshItem, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if item.tileCache == nil {
+		if item.replay != nil {
+			return nil, errors.New("obstacles can't be changed on a replay")
+		}
+		if item.tileCache, err = newLoadedTileCache(item); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// SetObstacle adds or replaces a temporary obstacle, the navmesh changes on
+// the next UpdateAgents or simulation tick. The changed tiles are recorded.
+func (m *NavMgr) SetObstacle(id, obstacleId string, obstacle NavObstacle) error {
+	if err := obstacle.validate(); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getTileCache(id)
+	if err != nil {
+		return err
+	}
+	c := item.tileCache
+	if obstacle.Box != nil {
+		box := *obstacle.Box
+		obstacle.Box = &box
+	}
+	changed := obstacle.bounds()
+	if old, ok := c.obstacles[obstacleId]; ok {
+		changed = boundsUnion(changed, old.bounds())
+	}
+	if b, ok := c.pending[obstacleId]; ok {
+		changed = boundsUnion(changed, b)
+	}
+	c.obstacles[obstacleId] = obstacle
+	c.pending[obstacleId] = changed
+	c.touch(changed)
+	return nil
+}
+
+// RemoveObstacle removes a temporary obstacle on the next update.
+func (m *NavMgr) RemoveObstacle(id, obstacleId string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	c := item.tileCache
+	var old NavObstacle
+	ok := false
+	if c != nil {
+		old, ok = c.obstacles[obstacleId]
+	}
+	if !ok {
+		return errors.New("obstacle not found: " + obstacleId)
+	}
+	changed := old.bounds()
+	if b, ok := c.pending[obstacleId]; ok {
+		changed = boundsUnion(changed, b)
+	}
+	delete(c.obstacles, obstacleId)
+	c.pending[obstacleId] = changed
+	c.touch(changed)
+	return nil
+}
+
+// GetObstacles lists the obstacles of a navmesh item sorted by id, none
+// for items without a tile cache.
+func (m *NavMgr) GetObstacles(id string) ([]NavObstacleInfo, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	obstacles := []NavObstacleInfo{}
+	if item.tileCache == nil {
+		return obstacles, nil
+	}
+	for obstacleId, obstacle := range item.tileCache.obstacles {
+		_, pending := item.tileCache.pending[obstacleId]
+		obstacles = append(obstacles, NavObstacleInfo{
+			Id:       obstacleId,
+			Obstacle: obstacle,
+			Pending:  pending,
+		})
+	}
+	sort.Slice(obstacles, func(i, j int) bool { return obstacles[i].Id < obstacles[j].Id })
+	return obstacles, nil
+}
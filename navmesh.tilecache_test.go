@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/o0olele/detour-go/detour"
+)
+
+// TestNavTileCacheObstacle carves a box out of a tiled navmesh, walks an
+// agent around it and checks that removing it brings the old tiles back.
+func TestNavTileCacheObstacle(t *testing.T) {
+	tests := []struct {
+		name      string
+		tileCache bool // else the cache is made from the loaded tiles
+	}{
+		{name: "built", tileCache: true},
+		{name: "loaded"},
+	}
+	// the box stands across the straight line from start to end
+	box := Bounds{Min: Vec3{X: -1, Y: -0.5, Z: -1.5}, Max: Vec3{X: 1, Y: 2, Z: 1.5}}
+	start, end := Vec3{X: -3.5, Z: 0}, Vec3{X: 3.5, Z: 0}
+	inBox := func(p Vec3) bool {
+		return p.X > box.Min.X && p.X < box.Max.X && p.Z > box.Min.Z && p.Z < box.Max.Z
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, _ := buildTestNavMesh(t, navTestFlatObj, NavBuildParams{TileSize: 11, TileCache: test.tileCache})
+			item := m.navItems["scene"]
+			tiles := func() map[[3]int32][]byte {
+				data := make(map[[3]int32][]byte)
+				item.forEachTile(func(_ *detour.DtNavMesh, tile *detour.DtMeshTile) {
+					h := tile.Header
+					data[[3]int32{h.X, h.Y, h.Layer}] = append([]byte(nil), tile.Data[:tile.DataSize]...)
+				})
+				return data
+			}
+			before := tiles()
+
+			agentId, err := m.AddAgent("scene", start.X, start.Y, start.Z, 0.5, 2, 3.5, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = m.SetAgentTarget("scene", end.X, end.Y, end.Z); err != nil {
+				t.Fatal(err)
+			}
+			if err = m.SetObstacle("scene", "box", NavObstacle{Box: &box}); err != nil {
+				t.Fatal(err)
+			}
+			if obstacles, _ := m.GetObstacles("scene"); len(obstacles) != 1 || !obstacles[0].Pending {
+				t.Fatalf("got obstacles %+v, want the box pending", obstacles)
+			}
+
+			// the first update carves the tiles and the agent plans again
+			for i := 0; i < 300; i++ {
+				if err = m.UpdateAgents("scene"); err != nil {
+					t.Fatal(err)
+				}
+				agents := item.snapshot("", 0).Agents
+				if pos := (Vec3{X: agents[1], Y: agents[2], Z: agents[3]}); inBox(pos) {
+					t.Fatalf("agent %d walked into the box at %v on update %d", agentId, pos, i)
+				}
+			}
+			if obstacles, _ := m.GetObstacles("scene"); len(obstacles) != 1 || obstacles[0].Pending {
+				t.Fatalf("got obstacles %+v, want the box carved", obstacles)
+			}
+			agents := item.snapshot("", 0).Agents
+			if dx, dz := agents[1]-end.X, agents[3]-end.Z; dx*dx+dz*dz > 0.25 {
+				t.Fatalf("agent stopped at %v, want %v", agents[1:4], end)
+			}
+
+			path, err := m.FindPath("scene", start, end, NavQueryFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			around := false
+			for _, p := range path.Points {
+				if inBox(p) {
+					t.Fatalf("waypoint %v is in the box", p)
+				}
+				around = around || p.Z < box.Min.Z || p.Z > box.Max.Z
+			}
+			if path.Partial || !around {
+				t.Fatalf("got path %v, want one around the box", path.Points)
+			}
+
+			if err = m.RemoveObstacle("scene", "box"); err != nil {
+				t.Fatal(err)
+			}
+			if err = m.UpdateAgents("scene"); err != nil {
+				t.Fatal(err)
+			}
+			after := tiles()
+			if len(after) != len(before) {
+				t.Fatalf("got %d tiles, want %d", len(after), len(before))
+			}
+			for pos, data := range before {
+				if !bytes.Equal(after[pos], data) {
+					t.Fatalf("tile %v differs from before the obstacle", pos)
+				}
+			}
+			if item.tileCache.busy() {
+				t.Fatal("tile cache is still busy")
+			}
+		})
+	}
+}
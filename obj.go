@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ObjMesh is the geometry part of a Wavefront OBJ file.
+type ObjMesh struct {
+	Vertices []Vec3
+	Groups   []*ObjGroup
+}
+
+// ObjGroup is a set of faces sharing the same object and group name.
+type ObjGroup struct {
+	Object string
+	Name   string
+	Faces  [][]int // zero based vertex indices, one slice per polygon
+}
+
+// LoadObj reads and parses an OBJ file.
+func LoadObj(filename string) (*ObjMesh, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mesh, err := ParseObj(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse obj %s: %v", filename, err)
+	}
+	return mesh, nil
+}
+
+// ParseObj parses vertices, faces, groups and objects of an OBJ stream.
+// Texture coordinates, normals and materials are ignored.
+func ParseObj(r io.Reader) (*ObjMesh, error) {
+	mesh := &ObjMesh{}
+	var object, group string
+	var current *ObjGroup
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: vertex needs 3 coordinates", lineNo)
+			}
+			var v [3]float32
+			for i := range v {
+				f, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", lineNo, err)
+				}
+				v[i] = float32(f)
+			}
+			mesh.Vertices = append(mesh.Vertices, Vec3{X: v[0], Y: v[1], Z: v[2]})
+		case "o":
+			object = strings.Join(fields[1:], " ")
+			group = ""
+			current = nil
+		case "g":
+			group = strings.Join(fields[1:], " ")
+			current = nil
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: face needs at least 3 vertices", lineNo)
+			}
+			face := make([]int, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				index, err := parseObjIndex(field, len(mesh.Vertices))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %v", lineNo, err)
+				}
+				face = append(face, index)
+			}
+			if current == nil {
+				current = &ObjGroup{Object: object, Name: group}
+				mesh.Groups = append(mesh.Groups, current)
+			}
+			current.Faces = append(current.Faces, face)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mesh, nil
+}
+
+// parseObjIndex resolves the vertex part of a face element like "3/1/2",
+// negative indices are relative to the vertices read so far.
+func parseObjIndex(field string, vertexCount int) (int, error) {
+	if i := strings.IndexByte(field, '/'); i >= 0 {
+		field = field[:i]
+	}
+	index, err := strconv.Atoi(field)
+	if err != nil {
+		return 0, fmt.Errorf("invalid face index %q", field)
+	}
+
+	if index < 0 {
+		index += vertexCount
+	} else {
+		index--
+	}
+	if index < 0 || index >= vertexCount {
+		return 0, fmt.Errorf("face index %s out of range", field)
+	}
+	return index, nil
+}
+
+// Triangles triangulates all faces, polygons are ear clipped so concave
+// ones keep their outline.
+func (m *ObjMesh) Triangles() []Triangle {
+	var triangles []Triangle
+	for _, group := range m.Groups {
+		triangles = append(triangles, group.Triangles(m.Vertices)...)
+	}
+	return triangles
+}
+
+// Triangles triangulates the faces of the group.
+func (g *ObjGroup) Triangles(vertices []Vec3) []Triangle {
+	var triangles []Triangle
+	for _, face := range g.Faces {
+		for _, tri := range triangulateFace(vertices, face) {
+			triangles = append(triangles, Triangle{
+				A: vertices[tri[0]],
+				B: vertices[tri[1]],
+				C: vertices[tri[2]],
+			})
+		}
+	}
+	return triangles
+}
+
+// triangulateFace ear clips a face in the plane of its Newell normal,
+// keeping the winding of the face. Faces which are not simple polygons fall
+// back to a fan for what is left.
+func triangulateFace(vertices []Vec3, face []int) [][3]int {
+	if len(face) == 3 {
+		return [][3]int{{face[0], face[1], face[2]}}
+	}
+
+	// drop the dominant axis of the normal, the projection keeps the
+	// polygon simple and its winding follows the sign of that axis
+	var nx, ny, nz float32
+	for i := range face {
+		a, b := vertices[face[i]], vertices[face[(i+1)%len(face)]]
+		nx += (a.Y - b.Y) * (a.Z + b.Z)
+		ny += (a.Z - b.Z) * (a.X + b.X)
+		nz += (a.X - b.X) * (a.Y + b.Y)
+	}
+	ax, ay, az := abs32(nx), abs32(ny), abs32(nz)
+	project := func(v Vec3) (float32, float32) {
+		switch {
+		case ax >= ay && ax >= az:
+			return v.Y, v.Z
+		case ay >= az:
+			return v.Z, v.X
+		}
+		return v.X, v.Y
+	}
+	sign := nz
+	if ax >= ay && ax >= az {
+		sign = nx
+	} else if ay >= az {
+		sign = ny
+	}
+
+	points := make([][2]float32, len(face))
+	for i, index := range face {
+		points[i][0], points[i][1] = project(vertices[index])
+	}
+	// cross is positive for a left turn at b, which is convex for a counter
+	// clockwise projection
+	cross := func(a, b, c [2]float32) float32 {
+		return ((b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])) * sign
+	}
+
+	remaining := make([]int, len(face))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	var tris [][3]int
+	for len(remaining) > 3 {
+		n := len(remaining)
+		ear := -1
+		for i := 0; i < n && ear < 0; i++ {
+			a, b, c := points[remaining[(i+n-1)%n]], points[remaining[i]], points[remaining[(i+1)%n]]
+			if cross(a, b, c) <= 0 {
+				continue
+			}
+			inside := false
+			for _, k := range remaining {
+				p := points[k]
+				if p == a || p == b || p == c {
+					continue
+				}
+				if cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0 {
+					inside = true
+					break
+				}
+			}
+			if !inside {
+				ear = i
+			}
+		}
+		if ear < 0 {
+			// self intersecting or degenerate, fan the rest
+			break
+		}
+		tris = append(tris, [3]int{face[remaining[(ear+n-1)%n]], face[remaining[ear]], face[remaining[(ear+1)%n]]})
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+	for i := 1; i+1 < len(remaining); i++ {
+		tris = append(tris, [3]int{face[remaining[0]], face[remaining[i]], face[remaining[i+1]]})
+	}
+	return tris
+}
+
+func abs32(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseObjFaces(t *testing.T) {
+	const quad = "v 0 0 0\nv 1 0 0\nv 1 0 1\nv 0 0 1\n"
+	tests := []struct {
+		name  string
+		obj   string
+		faces [][]int
+		err   string
+	}{
+		{name: "triangle", obj: quad + "f 1 2 3\n", faces: [][]int{{0, 1, 2}}},
+		{name: "texture and normal indices", obj: quad + "f 1/1/1 2//2 3/3\n", faces: [][]int{{0, 1, 2}}},
+		{name: "negative indices", obj: quad + "f -3 -2 -1\n", faces: [][]int{{1, 2, 3}}},
+		{name: "negative indices count the vertices read so far", obj: "v 0 0 0\nv 1 0 0\nv 1 0 1\nf -3 -2 -1\nv 0 0 1\nf -4 -2 -1\n", faces: [][]int{{0, 1, 2}, {0, 2, 3}}},
+		{name: "polygon", obj: quad + "f 1 2 3 4\n", faces: [][]int{{0, 1, 2, 3}}},
+		{name: "zero index", obj: quad + "f 0 1 2\n", err: "line 5: face index 0 out of range"},
+		{name: "index past the end", obj: quad + "f 1 2 5\n", err: "line 5: face index 5 out of range"},
+		{name: "negative index before the first vertex", obj: quad + "f -5 1 2\n", err: "line 5: face index -5 out of range"},
+		{name: "two vertices", obj: quad + "f 1 2\n", err: "line 5: face needs at least 3 vertices"},
+		{name: "bad index", obj: quad + "f 1 x 2\n", err: `line 5: invalid face index "x"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mesh, err := ParseObj(strings.NewReader(test.obj))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var faces [][]int
+			for _, group := range mesh.Groups {
+				faces = append(faces, group.Faces...)
+			}
+			if !reflect.DeepEqual(faces, test.faces) {
+				t.Fatalf("got faces %v, want %v", faces, test.faces)
+			}
+		})
+	}
+}
+
+// objFaceArea is twice the signed area of face in the xz plane, positive
+// for a counter clockwise face seen from +y.
+func objFaceArea(vertices []Vec3, face []int) float32 {
+	var area float32
+	for i := range face {
+		a, b := vertices[face[i]], vertices[face[(i+1)%len(face)]]
+		area += a.Z*b.X - a.X*b.Z
+	}
+	return area
+}
+
+func TestTriangulateFace(t *testing.T) {
+	xz := func(points ...[2]float32) []Vec3 {
+		vertices := make([]Vec3, len(points))
+		for i, p := range points {
+			vertices[i] = Vec3{X: p[0], Z: p[1]}
+		}
+		return vertices
+	}
+	tests := []struct {
+		name       string
+		vertices   []Vec3
+		face       []int
+		degenerate bool // no winding to keep, only the triangle count
+	}{
+		{name: "convex", vertices: xz([2]float32{0, 0}, [2]float32{0, 1}, [2]float32{1, 1}, [2]float32{1, 0}), face: []int{0, 1, 2, 3}},
+		{name: "convex reversed", vertices: xz([2]float32{0, 0}, [2]float32{0, 1}, [2]float32{1, 1}, [2]float32{1, 0}), face: []int{3, 2, 1, 0}},
+		// the fan from the first vertex would leave the outline at the reflex corner 0
+		{name: "concave", vertices: xz([2]float32{1, 1}, [2]float32{2, 0}, [2]float32{0, 0}, [2]float32{0, 2}, [2]float32{1, 2}), face: []int{1, 2, 3, 4, 0}},
+		{name: "concave arrow", vertices: xz([2]float32{0, 0}, [2]float32{1, 3}, [2]float32{2, 0}, [2]float32{1, 1}), face: []int{0, 1, 2, 3}},
+		{name: "concave arrow reversed", vertices: xz([2]float32{0, 0}, [2]float32{1, 3}, [2]float32{2, 0}, [2]float32{1, 1}), face: []int{3, 2, 1, 0}},
+		{name: "collinear", vertices: xz([2]float32{0, 0}, [2]float32{1, 0}, [2]float32{2, 0}, [2]float32{3, 0}), face: []int{0, 1, 2, 3}, degenerate: true},
+		{name: "repeated vertex", vertices: xz([2]float32{0, 0}, [2]float32{0, 1}, [2]float32{1, 1}, [2]float32{1, 0}), face: []int{0, 1, 1, 2, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tris := triangulateFace(test.vertices, test.face)
+			if len(tris) != len(test.face)-2 {
+				t.Fatalf("got %d triangles, want %d", len(tris), len(test.face)-2)
+			}
+			for _, tri := range tris {
+				for _, index := range tri {
+					if !slices.Contains(test.face, index) {
+						t.Fatalf("triangle %v uses vertex %d outside of the face", tri, index)
+					}
+				}
+			}
+			if test.degenerate {
+				return
+			}
+
+			// the triangles cover the face once and keep its winding
+			want := objFaceArea(test.vertices, test.face)
+			var got float32
+			for _, tri := range tris {
+				area := objFaceArea(test.vertices, tri[:])
+				if area*want < 0 {
+					t.Fatalf("triangle %v is wound against the face", tri)
+				}
+				got += area
+			}
+			if abs32(got-want) > 1e-5 {
+				t.Fatalf("triangles cover %g, the face %g", got/2, want/2)
+			}
+		})
+	}
+}
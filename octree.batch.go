@@ -0,0 +1,59 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/o0olele/octree-go/math32"
+)
+
+// OctreePathRequest is one query of a FindPaths batch.
+type OctreePathRequest struct {
+	Start   Vec3              `json:"start"`
+	End     Vec3              `json:"end"`
+	Options OctreePathOptions `json:"options"`
+}
+
+// OctreePathResult is the answer to the request at the same index, Error is
+// set instead of Path when the query failed.
+type OctreePathResult struct {
+	Path  *OctreePath `json:"path"`
+	Error string      `json:"error,omitempty"`
+}
+
+// FindPaths runs a batch of path queries in parallel under the read lock of
+// the item, each worker has its own search state.
+func (m *OctreeMgr) FindPaths(id string, requests []OctreePathRequest) ([]OctreePathResult, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	results := make([]OctreePathResult, len(requests))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), len(requests)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			search := newOctreeSearch()
+			for i := range next {
+				request := requests[i]
+				path, err := item.findPath(search, math32.Vector3(request.Start), math32.Vector3(request.End), request.Options)
+				if err != nil {
+					results[i].Error = err.Error()
+					continue
+				}
+				results[i].Path = path
+			}
+		}()
+	}
+	for i := range requests {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+	"github.com/o0olele/octree-go/voxel"
+)
+
+// Settings of builder.Build the workbench build follows. The phases below
+// copy builder.Build and pruneRedundantEdges of octree-go v0.2.0, which
+// prints its progress and can not be stopped between phases, check them
+// against upstream when the module is updated.
+const (
+	octreeMortonResolution = 1024
+	octreeMaxDegree        = 12
+	octreeLongEdgeFactor   = 2.5
+)
+
+// buildOctreeNavData runs the part of builder.Build after the subdivision
+// on tree, one phase at a time: the path graph, the voxel grid padded by the
+// agent and the navigation data. step is called before every phase like in
+// buildOctreeItem. It also returns the graph edges before pruning, which
+// obstacle updates change and prune again.
+func buildOctreeNavData(tree *octree.Octree, param OctreeParam, agent *octree.Agent, step func(phase string) bool, timer *octreeTimer) (*builder.NavigationData, []builder.CompactEdge, error) {
+	if !step(OctreeBuildPhaseGraph) {
+		return nil, nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhaseGraph)
+	pathfinder := octree.NewNodeBasedAStarPathfinderWithParallel(tree, agent, param.StepSize, true)
+
+	if !step(OctreeBuildPhaseVoxelize) {
+		return nil, nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhaseVoxelize)
+	grid := octreeVoxelGrid(tree.Root.Bounds, agent.Radius)
+	grid.VoxelizeWithPadding(tree.GetTriangles(), agent.Radius, agent.Height)
+
+	if !step(OctreeBuildPhaseNavData) {
+		return nil, nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhaseNavData)
+	leaves := octreeFreeLeaves(tree.Root, nil)
+	edges := octreeGraphEdges(pathfinder.GetPathGraph(), leaves)
+	navData := &builder.NavigationData{
+		Bounds:    tree.Root.Bounds,
+		MaxDepth:  param.MaxDepth,
+		MinSize:   param.MinSize,
+		StepSize:  param.StepSize,
+		GridSize:  grid.Size,
+		VoxelSize: grid.VoxelSize,
+		Edges:     pruneOctreeEdges(edges),
+		VoxelData: grid.ToBitmap(),
+	}
+	return newOctreeNavData(navData, leaves), edges, nil
+}
+
+// octreeVoxelGrid is the empty voxel grid builder.Build makes for an agent
+// of radius.
+func octreeVoxelGrid(bounds geometry.AABB, radius float32) *voxel.VoxelGrid {
+	voxelSize := radius * 0.5
+	size := bounds.Size()
+	return voxel.NewVoxelGrid(math32.Vector3i{
+		X: int32(math.Ceil(float64(size.X / voxelSize))),
+		Y: int32(math.Ceil(float64(size.Y / voxelSize))),
+		Z: int32(math.Ceil(float64(size.Z / voxelSize))),
+	}, voxelSize, bounds.Min)
+}
+
+// octreeGraphEdges maps the edges of the pathfinder graph to the indexes of
+// leaves, edges to other nodes are dropped.
+func octreeGraphEdges(graph *octree.PathGraph, leaves []*octree.OctreeNode) []builder.CompactEdge {
+	if graph == nil {
+		return nil
+	}
+	nodeIds := make(map[*octree.OctreeNode]int32, len(leaves))
+	for i, leaf := range leaves {
+		nodeIds[leaf] = int32(i)
+	}
+	edges := make([]builder.CompactEdge, 0, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		a, okA := nodeIds[edge.NodeA.OctreeNode]
+		b, okB := nodeIds[edge.NodeB.OctreeNode]
+		if okA && okB {
+			edges = append(edges, builder.CompactEdge{NodeAID: a, NodeBID: b, Cost: edge.Cost})
+		}
+	}
+	return edges
+}
+
+type octreeEdgeKey struct{ a, b int32 }
+
+func newOctreeEdgeKey(a, b int32) octreeEdgeKey {
+	if a > b {
+		a, b = b, a
+	}
+	return octreeEdgeKey{a, b}
+}
+
+// pruneOctreeEdges removes redundant edges like builder.Build: a node pair
+// keeps its cheapest edge, an edge longer than octreeLongEdgeFactor times
+// the shortest edge at both its ends is dropped and an edge stays when it is
+// one of the octreeMaxDegree cheapest of either end and neither end has
+// octreeMaxDegree edges yet. octree-go walks maps here, the edges are sorted
+// by their node pair so every build keeps the same ones.
+func pruneOctreeEdges(edges []builder.CompactEdge) []builder.CompactEdge {
+	if len(edges) == 0 {
+		return edges
+	}
+
+	cheapest := make(map[octreeEdgeKey]builder.CompactEdge, len(edges))
+	for _, e := range edges {
+		k := newOctreeEdgeKey(e.NodeAID, e.NodeBID)
+		if old, ok := cheapest[k]; !ok || e.Cost < old.Cost {
+			cheapest[k] = e
+		}
+	}
+	dedup := make([]builder.CompactEdge, 0, len(cheapest))
+	for _, e := range cheapest {
+		dedup = append(dedup, e)
+	}
+	sort.Slice(dedup, func(i, j int) bool {
+		ki, kj := newOctreeEdgeKey(dedup[i].NodeAID, dedup[i].NodeBID), newOctreeEdgeKey(dedup[j].NodeAID, dedup[j].NodeBID)
+		if ki.a != kj.a {
+			return ki.a < kj.a
+		}
+		return ki.b < kj.b
+	})
+
+	minLen := make(map[int32]float32)
+	for _, e := range dedup {
+		for _, id := range [2]int32{e.NodeAID, e.NodeBID} {
+			if v, ok := minLen[id]; !ok || e.Cost < v {
+				minLen[id] = e.Cost
+			}
+		}
+	}
+	filtered := dedup[:0]
+	for _, e := range dedup {
+		la, lb := minLen[e.NodeAID], minLen[e.NodeBID]
+		if la == 0 || lb == 0 || e.Cost <= la*octreeLongEdgeFactor || e.Cost <= lb*octreeLongEdgeFactor {
+			filtered = append(filtered, e)
+		}
+	}
+
+	// every node votes for its cheapest edges, ties go to the lower node id
+	adj := make(map[int32][]builder.CompactEdge)
+	for _, e := range filtered {
+		adj[e.NodeAID] = append(adj[e.NodeAID], e)
+		adj[e.NodeBID] = append(adj[e.NodeBID], builder.CompactEdge{NodeAID: e.NodeBID, NodeBID: e.NodeAID, Cost: e.Cost})
+	}
+	keep := make(map[octreeEdgeKey]bool)
+	for id, list := range adj {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Cost != list[j].Cost {
+				return list[i].Cost < list[j].Cost
+			}
+			return list[i].NodeBID < list[j].NodeBID
+		})
+		if len(list) > octreeMaxDegree {
+			list = list[:octreeMaxDegree]
+		}
+		for _, e := range list {
+			keep[newOctreeEdgeKey(id, e.NodeBID)] = true
+		}
+	}
+
+	// an edge kept by one end can still exceed the degree of the other, the
+	// strict pass drops it when either end is full, cheapest edges first
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].Cost < filtered[j].Cost })
+	degree := make(map[int32]int)
+	pruned := make([]builder.CompactEdge, 0, len(filtered))
+	for _, e := range filtered {
+		if keep[newOctreeEdgeKey(e.NodeAID, e.NodeBID)] && degree[e.NodeAID] < octreeMaxDegree && degree[e.NodeBID] < octreeMaxDegree {
+			pruned = append(pruned, e)
+			degree[e.NodeAID]++
+			degree[e.NodeBID]++
+		}
+	}
+	return pruned
+}
+
+// newOctreeNavData numbers leaves as the navigation nodes and builds their
+// morton index like builder.Build. navData holds the settings, edges and
+// voxels.
+func newOctreeNavData(navData *builder.NavigationData, leaves []*octree.OctreeNode) *builder.NavigationData {
+	navData.Nodes = make([]builder.CompactNode, len(leaves))
+	morton := make([]octree.MortonCode, len(leaves))
+	navData.MortonIndex = make([]int32, len(leaves))
+	navData.MortonResolution = octreeMortonResolution
+	for i, leaf := range leaves {
+		navData.Nodes[i] = builder.CompactNode{ID: int32(i), Bounds: leaf.Bounds, Center: leaf.Bounds.Center()}
+		morton[i] = octree.Vector3ToMorton(leaf.Bounds.Center(), navData.Bounds, navData.MortonResolution)
+		navData.MortonIndex[i] = int32(i)
+	}
+	sort.SliceStable(navData.MortonIndex, func(i, j int) bool {
+		return morton[navData.MortonIndex[i]] < morton[navData.MortonIndex[j]]
+	})
+	return navData
+}
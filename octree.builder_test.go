@@ -0,0 +1,197 @@
+package main
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// TestOctreeBuildMatchesBuilder checks that the phases copied from
+// builder.Build give the navigation data octree-go builds for the same scene.
+// Past the degree limit octree-go prunes edges in map order, so the deeper
+// scene compares the graph before pruning and only the degree limit after.
+func TestOctreeBuildMatchesBuilder(t *testing.T) {
+	triangles := []Triangle{
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: -4, Z: 4}, C: Vec3{X: 4, Z: 4}},
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: 4, Z: 4}, C: Vec3{X: 4, Z: -4}},
+		// a wall across half of the floor
+		{A: Vec3{X: -4, Z: 0}, B: Vec3{X: 1, Z: 0}, C: Vec3{X: 1, Y: 2, Z: 0}},
+		{A: Vec3{X: -4, Z: 0}, B: Vec3{X: 1, Y: 2, Z: 0}, C: Vec3{X: -4, Y: 2, Z: 0}},
+	}
+	agentParam := AgentParam{Radius: 0.3, Height: 1}
+
+	tests := []struct {
+		name     string
+		maxDepth uint8
+		prune    bool
+	}{
+		{name: "pruned", maxDepth: 2, prune: true},
+		{name: "over the degree limit", maxDepth: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			param := OctreeParam{
+				Bounds:   Bounds{Min: Vec3{X: -5, Y: -1, Z: -5}, Max: Vec3{X: 5, Y: 3, Z: 5}},
+				MaxDepth: test.maxDepth,
+				MinSize:  0.5,
+				StepSize: 0.5,
+			}
+			item, err := buildOctreeItem(param, agentParam, triangles, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := builder.NewBuilder(geometry.AABB{Min: math32.Vector3(param.Bounds.Min), Max: math32.Vector3(param.Bounds.Max)}, param.MaxDepth, param.MinSize, param.StepSize)
+			b.SetUseVoxel(true)
+			b.SetUsePrune(test.prune)
+			for _, tri := range triangles {
+				b.AddTriangle(geometry.Triangle{A: math32.Vector3(tri.A), B: math32.Vector3(tri.B), C: math32.Vector3(tri.C)})
+			}
+			want := buildQuiet(t, b, octree.NewAgent(agentParam.Radius, agentParam.Height))
+
+			got := item.navData
+			if len(got.Nodes) != len(want.Nodes) {
+				t.Fatalf("got %d nodes, want %d", len(got.Nodes), len(want.Nodes))
+			}
+			for i := range got.Nodes {
+				if got.Nodes[i].Bounds != want.Nodes[i].Bounds {
+					t.Fatalf("node %d has bounds %v, want %v", i, got.Nodes[i].Bounds, want.Nodes[i].Bounds)
+				}
+			}
+			if !reflect.DeepEqual(got.MortonIndex, want.MortonIndex) {
+				t.Fatal("morton index differs")
+			}
+			if got.GridSize != want.GridSize || got.VoxelSize != want.VoxelSize || !reflect.DeepEqual(got.VoxelData, want.VoxelData) {
+				t.Fatal("voxels differ")
+			}
+
+			gotEdges := item.edges
+			if test.prune {
+				gotEdges = got.Edges
+			}
+			if g, w := sortedOctreeEdges(gotEdges), sortedOctreeEdges(want.Edges); !reflect.DeepEqual(g, w) {
+				t.Fatalf("got %d edges, want %d", len(g), len(w))
+			}
+			degree := make(map[int32]int)
+			for _, e := range got.Edges {
+				degree[e.NodeAID]++
+				degree[e.NodeBID]++
+			}
+			for id, d := range degree {
+				if d > octreeMaxDegree {
+					t.Fatalf("node %d has %d edges after pruning", id, d)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneOctreeEdges(t *testing.T) {
+	// a star of n edges from node 0 with increasing costs
+	star := func(n int) []builder.CompactEdge {
+		edges := make([]builder.CompactEdge, n)
+		for i := range edges {
+			edges[i] = builder.CompactEdge{NodeAID: 0, NodeBID: int32(i + 1), Cost: 1 + float32(i)*0.01}
+		}
+		return edges
+	}
+	// n fully connected nodes, the edges of a higher id cost more
+	complete := func(n int) []builder.CompactEdge {
+		var edges []builder.CompactEdge
+		for a := 0; a < n; a++ {
+			for b := a + 1; b < n; b++ {
+				edges = append(edges, builder.CompactEdge{NodeAID: int32(a), NodeBID: int32(b), Cost: 1 + float32(a+b)*0.01})
+			}
+		}
+		return edges
+	}
+
+	tests := []struct {
+		name  string
+		edges []builder.CompactEdge
+		want  []builder.CompactEdge
+	}{
+		{name: "empty"},
+		{
+			name:  "duplicates keep the cheapest",
+			edges: []builder.CompactEdge{{NodeAID: 0, NodeBID: 1, Cost: 2}, {NodeAID: 1, NodeBID: 0, Cost: 1}, {NodeAID: 0, NodeBID: 1, Cost: 3}},
+			want:  []builder.CompactEdge{{NodeAID: 0, NodeBID: 1, Cost: 1}},
+		},
+		{
+			name: "long edge",
+			edges: []builder.CompactEdge{
+				{NodeAID: 0, NodeBID: 1, Cost: 1},
+				{NodeAID: 1, NodeBID: 2, Cost: 1},
+				{NodeAID: 0, NodeBID: 2, Cost: 3},
+			},
+			want: []builder.CompactEdge{{NodeAID: 0, NodeBID: 1, Cost: 1}, {NodeAID: 1, NodeBID: 2, Cost: 1}},
+		},
+		{
+			name: "long edge next to a short one at one end",
+			edges: []builder.CompactEdge{
+				{NodeAID: 0, NodeBID: 1, Cost: 1},
+				{NodeAID: 0, NodeBID: 2, Cost: 2.5},
+			},
+			want: []builder.CompactEdge{{NodeAID: 0, NodeBID: 1, Cost: 1}, {NodeAID: 0, NodeBID: 2, Cost: 2.5}},
+		},
+		// the other ends vote for every edge, the strict pass keeps the cheapest
+		{name: "degree over the limit", edges: star(octreeMaxDegree + 3), want: star(octreeMaxDegree)},
+		// the other nodes fill up with cheaper edges before those of the last one
+		{name: "degree over the limit at every node", edges: complete(octreeMaxDegree + 2), want: complete(octreeMaxDegree + 1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := pruneOctreeEdges(append([]builder.CompactEdge(nil), test.edges...))
+			if g, w := sortedOctreeEdges(got), sortedOctreeEdges(test.want); !reflect.DeepEqual(g, w) {
+				t.Fatalf("got %v, want %v", g, w)
+			}
+		})
+	}
+}
+
+// buildQuiet runs b.Build without its progress output.
+func buildQuiet(t *testing.T, b *builder.Builder, agent *octree.Agent) *builder.NavigationData {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, r)
+		close(done)
+	}()
+	navData, err := b.Build(agent)
+	os.Stdout = stdout
+	w.Close()
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	return navData
+}
+
+// sortedOctreeEdges orders edges by their node pair, with the lower id first.
+func sortedOctreeEdges(edges []builder.CompactEdge) []builder.CompactEdge {
+	sorted := make([]builder.CompactEdge, len(edges))
+	for i, e := range edges {
+		k := newOctreeEdgeKey(e.NodeAID, e.NodeBID)
+		sorted[i] = builder.CompactEdge{NodeAID: k.a, NodeBID: k.b, Cost: e.Cost}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].NodeAID != sorted[j].NodeAID {
+			return sorted[i].NodeAID < sorted[j].NodeAID
+		}
+		return sorted[i].NodeBID < sorted[j].NodeBID
+	})
+	return sorted
+}
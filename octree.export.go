@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// Colors of the exported octree leaves.
+var (
+	octreeExportOccupied = [4]float32{0.9, 0.3, 0.2, 1}
+	octreeExportFree     = [4]float32{0.3, 0.8, 0.4, 1}
+)
+
+// addBox adds the 6 faces of b, wound counter clockwise seen from outside.
+func (g *exportGroup) addBox(b geometry.AABB, color [4]float32) {
+	lo, hi := b.Min, b.Max
+	corner := func(i int) Vec3 {
+		v := Vec3{X: lo.X, Y: lo.Y, Z: lo.Z}
+		if i&1 != 0 {
+			v.X = hi.X
+		}
+		if i&2 != 0 {
+			v.Y = hi.Y
+		}
+		if i&4 != 0 {
+			v.Z = hi.Z
+		}
+		return v
+	}
+	for _, face := range [6][4]int{
+		{0, 4, 6, 2}, // -x
+		{1, 3, 7, 5}, // +x
+		{0, 1, 5, 4}, // -y
+		{2, 6, 7, 3}, // +y
+		{0, 2, 3, 1}, // -z
+		{4, 5, 7, 6}, // +z
+	} {
+		g.addFace([]Vec3{corner(face[0]), corner(face[1]), corner(face[2]), corner(face[3])}, color)
+	}
+}
+
+// octreeExportMesh has a box per leaf kept by filter, leaves cut by
+// filter.MaxDepth are exported at that depth.
+func octreeExportMesh(name string, o *octree.Octree, filter OctreeDataFilter) *exportMesh {
+	mesh := &exportMesh{name: name}
+	occupied := mesh.group("occupied", false)
+	free := mesh.group("free", false)
+
+	flatFilter := &octreeFlatFilter{OctreeDataFilter: filter}
+	if !flatFilter.keep(o.Root) {
+		return mesh
+	}
+	stack := []*octree.OctreeNode{o.Root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !flatFilter.isLod(node) {
+			for _, child := range node.Children {
+				if flatFilter.keep(child) {
+					stack = append(stack, child)
+				}
+			}
+			continue
+		}
+		if node.IsOccupied() {
+			occupied.addBox(node.Bounds, octreeExportOccupied)
+		} else {
+			free.addBox(node.Bounds, octreeExportFree)
+		}
+	}
+	return mesh
+}
+
+// Export writes the leaves of the octree selected by filter to path as OBJ
+// or glTF, with the occupied and free leaves in their own groups.
+func (m *OctreeMgr) Export(id, path, format string, filter OctreeDataFilter) error {
+	if _, err := exportFormat(path, format); err != nil {
+		return err
+	}
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.RLock()
+	mesh := octreeExportMesh(id, item.octree, filter)
+	item.mutex.RUnlock()
+
+	return mesh.write(path, format)
+}
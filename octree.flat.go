@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// OctreeFlagTruncated marks a node whose children were cut by the depth
+// limit, it sits next to the leaf and occupied flags of octree-go.
+const OctreeFlagTruncated = 1 << 2
+
+// OctreeDataFilter selects the nodes of a flat export.
+type OctreeDataFilter struct {
+	MaxDepth     uint8 `json:"max_depth"` // deepest exported level, 0 exports all levels
+	SkipFree     bool  `json:"skip_free"`
+	SkipOccupied bool  `json:"skip_occupied"`
+}
+
+// OctreeFlatExport is the octree flattened in breadth first order. The
+// arrays are little endian and go to the frontend as base64, so they can be
+// viewed as typed arrays without parsing:
+//   - Bounds: Float32Array, min xyz and max xyz per node
+//   - Flags: Uint8Array, bit 0 occupied, bit 1 leaf, bit 2 truncated
+//   - Depth: Uint8Array
+//   - FirstChild: Int32Array, index of the first exported child or -1
+//   - ChildMask: Uint8Array, octants of the exported children, they follow
+//     FirstChild in octant order
+type OctreeFlatExport struct {
+	MaxDepth   uint8   `json:"max_depth"`
+	MinSize    float32 `json:"min_size"`
+	Count      int     `json:"count"`
+	Bounds     []byte  `json:"bounds"`
+	Flags      []byte  `json:"flags"`
+	Depth      []byte  `json:"depth"`
+	FirstChild []byte  `json:"first_child"`
+	ChildMask  []byte  `json:"child_mask"`
+}
+
+type octreeFlatFilter struct {
+	OctreeDataFilter
+	region *geometry.AABB
+}
+
+// isLod reports whether node is drawn as a leaf at the filter depth.
+func (f *octreeFlatFilter) isLod(node *octree.OctreeNode) bool {
+	return node.IsLeaf() || (f.MaxDepth > 0 && node.Depth >= f.MaxDepth)
+}
+
+func (f *octreeFlatFilter) keep(node *octree.OctreeNode) bool {
+	if node == nil {
+		return false
+	}
+	if f.region != nil && !f.region.Intersects(node.Bounds) {
+		return false
+	}
+	if !f.isLod(node) {
+		return true
+	}
+	if node.IsOccupied() {
+		return !f.SkipOccupied
+	}
+	return !f.SkipFree
+}
+
+func putFloat32(buf []byte, v float32) []byte {
+	return binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+}
+
+// OctreeToFlatExport flattens the nodes of o selected by filter.
+func OctreeToFlatExport(o *octree.Octree, filter *octreeFlatFilter) *OctreeFlatExport {
+	export := &OctreeFlatExport{
+		MaxDepth: o.MaxDepth,
+		MinSize:  o.MinSize,
+	}
+	if !filter.keep(o.Root) {
+		return export
+	}
+
+	queue := []*octree.OctreeNode{o.Root}
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+
+		b := node.Bounds
+		for _, v := range [6]float32{b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z} {
+			export.Bounds = putFloat32(export.Bounds, v)
+		}
+		export.Depth = append(export.Depth, node.Depth)
+
+		flags := node.Flags
+		firstChild := int32(-1)
+		var mask uint8
+		if filter.isLod(node) {
+			if !node.IsLeaf() {
+				flags |= OctreeFlagTruncated
+			}
+		} else {
+			for octant, child := range node.Children {
+				if !filter.keep(child) {
+					continue
+				}
+				if firstChild < 0 {
+					firstChild = int32(len(queue))
+				}
+				mask |= 1 << octant
+				queue = append(queue, child)
+			}
+		}
+		export.Flags = append(export.Flags, flags)
+		export.FirstChild = binary.LittleEndian.AppendUint32(export.FirstChild, uint32(firstChild))
+		export.ChildMask = append(export.ChildMask, mask)
+	}
+	export.Count = len(queue)
+	return export
+}
+
+// GetOctreeFlatData exports the octree as flat arrays, region limits the
+// export to the nodes intersecting it.
+func (m *OctreeMgr) GetOctreeFlatData(id string, filter OctreeDataFilter, region *Bounds) (*OctreeFlatExport, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	flatFilter := &octreeFlatFilter{OctreeDataFilter: filter}
+	if region != nil {
+		flatFilter.region = &geometry.AABB{Min: math32.Vector3(region.Min), Max: math32.Vector3(region.Max)}
+	}
+	return OctreeToFlatExport(item.octree, flatFilter), nil
+}
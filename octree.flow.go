@@ -0,0 +1,271 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"time"
+
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// octreeFlowField holds the distance to the goal of every free leaf of the
+// default agent and the next leaf on the way there.
+type octreeFlowField struct {
+	goal     math32.Vector3
+	goalNode int32
+	distance []float32 // -1 when the goal is unreachable
+	next     []int32   // -1 for the goal node and unreachable nodes
+	nodeIds  map[*octree.OctreeNode]int32
+	elapsed  time.Duration
+}
+
+// OctreeFlowFieldInfo describes a built flow field.
+type OctreeFlowFieldInfo struct {
+	Goal        Vec3    `json:"goal"`
+	Nodes       int     `json:"nodes"`
+	Reachable   int     `json:"reachable"` // nodes with a way to the goal
+	MaxDistance float32 `json:"max_distance"`
+	BuildTime   float64 `json:"build_time"` // milliseconds
+}
+
+// OctreeFlowSample is the flow field at a position.
+type OctreeFlowSample struct {
+	Found     bool    `json:"found"` // false outside the free space or when the goal is unreachable
+	Node      int32   `json:"node"`  // -1 outside the free space
+	Distance  float32 `json:"distance"`
+	Direction Vec3    `json:"direction"` // unit vector towards the goal
+}
+
+// OctreeFlowFieldExport is the flow field per node as little endian arrays
+// like OctreeFlatExport:
+//   - Bounds: Float32Array, min xyz and max xyz per node
+//   - Directions: Float32Array, unit xyz per node, zero when unreachable
+//   - Distances: Float32Array, -1 when unreachable
+type OctreeFlowFieldExport struct {
+	Goal        Vec3    `json:"goal"`
+	Count       int     `json:"count"`
+	MaxDistance float32 `json:"max_distance"`
+	Bounds      []byte  `json:"bounds"`
+	Directions  []byte  `json:"directions"`
+	Distances   []byte  `json:"distances"`
+}
+
+// buildFlowField runs Dijkstra from the goal over the path graph of the
+// default agent.
+func (item *OctreeItem) buildFlowField(goal math32.Vector3) (*octreeFlowField, error) {
+	start := time.Now()
+	navData := item.navData
+	goalNode := octreeClosestNode(navData, goal)
+	if goalNode < 0 {
+		return nil, errors.New("no octree node near goal")
+	}
+
+	field := &octreeFlowField{
+		goal:     goal,
+		goalNode: goalNode,
+		distance: make([]float32, len(navData.Nodes)),
+		next:     make([]int32, len(navData.Nodes)),
+		nodeIds:  make(map[*octree.OctreeNode]int32, len(navData.Nodes)),
+	}
+	for i := range field.distance {
+		field.distance[i] = -1
+		field.next[i] = -1
+	}
+	for i, leaf := range octreeFreeLeaves(item.octree.Root, nil) {
+		field.nodeIds[leaf] = int32(i)
+	}
+
+	done := make([]bool, len(navData.Nodes))
+	field.distance[goalNode] = navData.Nodes[goalNode].Center.Distance(goal)
+	openList := &octreeOpenList{}
+	heap.Push(openList, &octreeOpenNode{id: goalNode, f: field.distance[goalNode]})
+	for openList.Len() > 0 {
+		current := heap.Pop(openList).(*octreeOpenNode)
+		if done[current.id] {
+			continue
+		}
+		done[current.id] = true
+
+		center := navData.Nodes[current.id].Center
+		for _, neighbor := range navData.GetNeighbors(current.id) {
+			if done[neighbor] {
+				continue
+			}
+			cost, ok := navData.GetEdgeCostByNodes(current.id, neighbor)
+			if !ok {
+				cost = center.Distance(navData.Nodes[neighbor].Center)
+			}
+			d := current.f + cost
+			if old := field.distance[neighbor]; old >= 0 && d >= old {
+				continue
+			}
+			field.distance[neighbor] = d
+			field.next[neighbor] = current.id
+			heap.Push(openList, &octreeOpenNode{id: neighbor, f: d})
+		}
+	}
+
+	field.elapsed = time.Since(start)
+	return field, nil
+}
+
+// direction is the unit vector from pos towards the goal through node.
+func (f *octreeFlowField) direction(item *OctreeItem, node int32, pos math32.Vector3) (math32.Vector3, float32) {
+	target, rest := f.goal, float32(0)
+	if next := f.next[node]; next >= 0 {
+		target, rest = item.navData.Nodes[next].Center, f.distance[next]
+	}
+	return target.Sub(pos).Normalize(), pos.Distance(target) + rest
+}
+
+// findLeaf descends the octree to the free leaf containing pos, -1 for
+// positions in occupied or outside space.
+func (f *octreeFlowField) findLeaf(item *OctreeItem, pos math32.Vector3) int32 {
+	node := item.octree.Root
+	if !node.Bounds.Contains(pos) {
+		return -1
+	}
+	for !node.IsLeaf() {
+		var next *octree.OctreeNode
+		for _, child := range node.Children {
+			if child != nil && child.Bounds.Contains(pos) {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return -1
+		}
+		node = next
+	}
+	if id, ok := f.nodeIds[node]; ok {
+		return id
+	}
+	return -1
+}
+
+func (f *octreeFlowField) info() *OctreeFlowFieldInfo {
+	info := &OctreeFlowFieldInfo{
+		Goal:      Vec3(f.goal),
+		Nodes:     len(f.distance),
+		BuildTime: float64(f.elapsed.Microseconds()) / 1000,
+	}
+	for _, d := range f.distance {
+		if d >= 0 {
+			info.Reachable++
+			info.MaxDistance = max(info.MaxDistance, d)
+		}
+	}
+	return info
+}
+
+// BuildFlowField computes the distance and direction towards goal for every
+// free leaf of the default agent, replacing the previous field of the item.
+// Obstacle updates build it again.
+func (m *OctreeMgr) BuildFlowField(id string, goal Vec3) (*OctreeFlowFieldInfo, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	field, err := item.buildFlowField(math32.Vector3(goal))
+	if err != nil {
+		return nil, err
+	}
+	item.flowField, item.flowErr = field, nil
+	return field.info(), nil
+}
+
+func (m *OctreeMgr) RemoveFlowField(id string) error {
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	item.flowField, item.flowErr = nil, nil
+	return nil
+}
+
+// currentFlowField returns the flow field of the item or why there is none.
+func (item *OctreeItem) currentFlowField() (*octreeFlowField, error) {
+	if item.flowField == nil {
+		if item.flowErr != nil {
+			return nil, item.flowErr
+		}
+		return nil, errors.New("octree has no flow field")
+	}
+	return item.flowField, nil
+}
+
+// SampleFlowField looks up the flow field at every position, one sample per
+// position.
+func (m *OctreeMgr) SampleFlowField(id string, positions []Vec3) ([]OctreeFlowSample, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	field, err := item.currentFlowField()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]OctreeFlowSample, len(positions))
+	for i, position := range positions {
+		pos := math32.Vector3(position)
+		node := field.findLeaf(item, pos)
+		samples[i].Node = node
+		if node < 0 || field.distance[node] < 0 {
+			continue
+		}
+		direction, distance := field.direction(item, node, pos)
+		samples[i].Found = true
+		samples[i].Distance = distance
+		samples[i].Direction = Vec3(direction)
+	}
+	return samples, nil
+}
+
+// GetFlowFieldData exports the flow field of the item for the octree scene.
+func (m *OctreeMgr) GetFlowFieldData(id string) (*OctreeFlowFieldExport, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	field, err := item.currentFlowField()
+	if err != nil {
+		return nil, err
+	}
+
+	export := &OctreeFlowFieldExport{
+		Goal:        Vec3(field.goal),
+		Count:       len(field.distance),
+		MaxDistance: field.info().MaxDistance,
+	}
+	for i, node := range item.navData.Nodes {
+		b := node.Bounds
+		for _, v := range [6]float32{b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z} {
+			export.Bounds = putFloat32(export.Bounds, v)
+		}
+
+		var direction math32.Vector3
+		if field.distance[i] >= 0 {
+			direction, _ = field.direction(item, int32(i), node.Center)
+		}
+		for _, v := range [3]float32{direction.X, direction.Y, direction.Z} {
+			export.Directions = putFloat32(export.Directions, v)
+		}
+		export.Distances = putFloat32(export.Distances, field.distance[i])
+	}
+	return export, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+// TestOctreeSampleFlowField checks that only positions in the free space
+// find a node of the flow field.
+func TestOctreeSampleFlowField(t *testing.T) {
+	m, id := pathTestOctree(t, -5)
+	if _, err := m.BuildFlowField(id, Vec3{X: 3, Y: 0.6, Z: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		pos   Vec3
+		found bool
+	}{
+		{name: "free", pos: Vec3{X: -3, Y: 0.6, Z: -3}, found: true},
+		{name: "in the floor", pos: Vec3{X: -3, Y: 0, Z: -3}},
+		{name: "out of bounds", pos: Vec3{X: -3, Y: 10, Z: -3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			samples, err := m.SampleFlowField(id, []Vec3{test.pos})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sample := samples[0]
+			if sample.Found != test.found || sample.Found != (sample.Node >= 0) {
+				t.Fatalf("got sample %+v, want found %v", sample, test.found)
+			}
+			if sample.Found && sample.Distance <= 0 {
+				t.Fatalf("got distance %v to the goal", sample.Distance)
+			}
+		})
+	}
+}
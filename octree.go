@@ -2,7 +2,6 @@ package main
 
 import (
 	"errors"
-	"fmt"
 	"sync"
 
 	"github.com/o0olele/octree-go/builder"
@@ -12,20 +11,40 @@ import (
 	"github.com/o0olele/octree-go/query"
 )
 
+// OctreeItem is guarded by its own mutex, queries share the read lock and
+// changes like obstacles and profiles take the write lock.
 type OctreeItem struct {
-	builder *builder.Builder
-	query   *query.NavigationQuery
-	agent   *octree.Agent
+	mutex      sync.RWMutex
+	octree     *octree.Octree
+	navData    *builder.NavigationData
+	edges      []builder.CompactEdge // the graph before pruning, nil after Load
+	query      *query.NavigationQuery
+	agent      *octree.Agent
+	param      OctreeParam
+	agentParam AgentParam
+	profiles   map[string]*octreeProfile
+	phases     []OctreePhaseTime // build time per phase
+	flowField  *octreeFlowField
+	flowErr    error // why an obstacle update dropped the flow field
+
+	// dynamic obstacles, baseTriangles is the build geometry without them
+	baseTriangles []geometry.Triangle
+	obstacles     map[string][]geometry.Triangle
 }
 
+// OctreeMgr guards the item and job maps, the items lock themselves so a
+// change on one item does not block queries on another.
 type OctreeMgr struct {
-	items map[string]*OctreeItem
-	mutex sync.Mutex
+	items   map[string]*OctreeItem
+	jobs    map[string]*octreeJob
+	lastJob uint64
+	mutex   sync.RWMutex
 }
 
 func NewOctreeMgr() *OctreeMgr {
 	return &OctreeMgr{
 		items: make(map[string]*OctreeItem),
+		jobs:  make(map[string]*octreeJob),
 	}
 }
 
@@ -67,36 +86,72 @@ func (m *OctreeMgr) Add(id string, octreeParam OctreeParam, agentParam AgentPara
 	}
 	m.mutex.Unlock()
 
-	builder := builder.NewBuilder(geometry.AABB{Min: math32.Vector3(octreeParam.Bounds.Min), Max: math32.Vector3(octreeParam.Bounds.Max)}, octreeParam.MaxDepth, octreeParam.MinSize, octreeParam.StepSize)
-	for _, tri := range triangles {
-		builder.AddTriangle(geometry.Triangle{A: math32.Vector3(tri.A), B: math32.Vector3(tri.B), C: math32.Vector3(tri.C)})
+	item, err := buildOctreeItem(octreeParam, agentParam, triangles, nil)
+	if err != nil {
+		return err
 	}
-	builder.SetUseVoxel(true)
 
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.items[id] = item
+	return nil
+}
+
+// buildOctreeItem builds the octree and its navigation data. step is called
+// before every phase, when it returns false the build stops with
+// errOctreeBuildCancelled.
+func buildOctreeItem(octreeParam OctreeParam, agentParam AgentParam, triangles []Triangle, step func(phase string) bool) (*OctreeItem, error) {
+	// the voxel grid and the clearance checks divide by the radius
+	if agentParam.Radius <= 0 || agentParam.Height <= 0 {
+		return nil, errors.New("agent radius and height must be positive")
+	}
+	if step == nil {
+		step = func(string) bool { return true }
+	}
+
+	var timer octreeTimer
+	if !step(OctreeBuildPhasePrepare) {
+		return nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhasePrepare)
+	tree := octree.NewOctree(geometry.AABB{Min: math32.Vector3(octreeParam.Bounds.Min), Max: math32.Vector3(octreeParam.Bounds.Max)}, octreeParam.MaxDepth, octreeParam.MinSize)
+	for _, tri := range triangles {
+		tree.AddTriangle(geometry.Triangle{A: math32.Vector3(tri.A), B: math32.Vector3(tri.B), C: math32.Vector3(tri.C)})
+	}
 	agent := octree.NewAgent(agentParam.Radius, agentParam.Height)
 
-	navData, err := builder.Build(agent)
+	if !step(OctreeBuildPhaseSubdivide) {
+		return nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhaseSubdivide)
+	tree.Build()
+
+	navData, edges, err := buildOctreeNavData(tree, octreeParam, agent, step, &timer)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	if !step(OctreeBuildPhaseQuery) {
+		return nil, errOctreeBuildCancelled
+	}
+	timer.begin(OctreeBuildPhaseQuery)
 	query, err := query.NewNavigationQuery(navData)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	query.SetAgent(agent)
-
-	item := &OctreeItem{
-		builder: builder,
-		query:   query,
-		agent:   agent,
-	}
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-	m.items[id] = item
-
-	fmt.Println("AddOctreeItem", id, agentParam)
-	return nil
+	timer.end()
+
+	return &OctreeItem{
+		octree:     tree,
+		navData:    navData,
+		edges:      edges,
+		query:      query,
+		agent:      agent,
+		param:      octreeParam,
+		agentParam: agentParam,
+		phases:     timer.phases,
+	}, nil
 }
 
 // OctreeExport is the simplified structure for JSON serialization
@@ -148,17 +203,14 @@ func OctreeNodeToExport(o *octree.Octree, node *octree.OctreeNode) *OctreeNodeEx
 }
 
 func (m *OctreeMgr) GetOctreeData(id string) (*OctreeExport, error) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	fmt.Println("GetOctreeData", id)
-
-	item, ok := m.items[id]
-	if !ok {
-		return nil, errors.New("octree not found")
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
 	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
 
-	export := OctreeToExport(item.builder.GetOctree())
+	export := OctreeToExport(item.octree)
 	return export, nil
 }
 
@@ -170,29 +222,63 @@ func (m *OctreeMgr) Remove(id string) {
 }
 
 func (m *OctreeMgr) Exist(id string) bool {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
 
 	_, ok := m.items[id]
 	return ok
 }
 
-func (m *OctreeMgr) FindPath(id string, start, end Vec3) []Vec3 {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+// getItem looks up an item, the caller locks the item itself.
+func (m *OctreeMgr) getItem(id string) (*OctreeItem, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
 
 	item, ok := m.items[id]
 	if !ok {
+		return nil, errors.New("octree not found")
+	}
+	return item, nil
+}
+
+// FindPath finds a path for the named agent profile, an empty name uses the
+// agent the octree was built with. It runs the search of query.FindPath
+// under the read lock so queries do not wait for each other.
+func (m *OctreeMgr) FindPath(id, profileName string, start, end Vec3) []Vec3 {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	profile, err := item.profile(profileName)
+	if err != nil {
+		return nil
+	}
+	// every caller gets its own copy of the query. query.FindPath looks the
+	// nodes up through the LRU cache of the navigation data, which is not
+	// safe next to other queries, and query.NewNavigationQuery rewrites the
+	// node centers, so the copy is made from the built query and the nodes
+	// are found with octreeClosestNode.
+	q := *profile.query
+	navData := q.GetNavigationData()
+	startPos, endPos := math32.Vector3(start), math32.Vector3(end)
+	startId, endId := octreeClosestNode(navData, startPos), octreeClosestNode(navData, endPos)
+	if startId == -1 || endId == -1 {
 		return nil
 	}
 
-	path := item.query.FindPath(math32.Vector3(start), math32.Vector3(end))
-	if path == nil {
+	nodes := q.AstarBidirectional(startId, endId)
+	if nodes == nil {
+		nodes = q.Astar(startId, endId)
+	}
+	if nodes == nil {
 		return nil
 	}
 
 	var vec3Path []Vec3
-	for _, v := range path {
+	for _, v := range q.ConvertToWorldPath(nodes, startPos, endPos) {
 		vec3Path = append(vec3Path, Vec3{X: v.X, Y: v.Y, Z: v.Z})
 	}
 	return vec3Path
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Build phases reported by a build job, in the order they run. A job is
+// cancelled between phases, the octree-go call of a phase can't be stopped.
+const (
+	OctreeBuildPhasePrepare   = "prepare"
+	OctreeBuildPhaseSubdivide = "subdivide" // octree subdivision
+	OctreeBuildPhaseGraph     = "graph"     // path graph between the free leaves
+	OctreeBuildPhaseVoxelize  = "voxelize"  // voxel grid padded by the agent
+	OctreeBuildPhaseNavData   = "nav_data"  // edge pruning and node indexes
+	OctreeBuildPhaseQuery     = "query"
+	OctreeBuildPhaseDone      = "done"
+)
+
+// Build job states.
+const (
+	OctreeBuildRunning   = "running"
+	OctreeBuildDone      = "done"
+	OctreeBuildFailed    = "failed"
+	OctreeBuildCancelled = "cancelled"
+)
+
+var errOctreeBuildCancelled = errors.New("octree build cancelled")
+
+// octreeBuildPhaseProgress is the rough share of the build done when a phase
+// starts, the path graph takes most of it.
+var octreeBuildPhaseProgress = map[string]float32{
+	OctreeBuildPhasePrepare:   0,
+	OctreeBuildPhaseSubdivide: 0.02,
+	OctreeBuildPhaseGraph:     0.05,
+	OctreeBuildPhaseVoxelize:  0.85,
+	OctreeBuildPhaseNavData:   0.88,
+	OctreeBuildPhaseQuery:     0.97,
+	OctreeBuildPhaseDone:      1,
+}
+
+// octreeJobTTL is how long a finished job can still be looked up.
+const octreeJobTTL = 10 * time.Minute
+
+// OctreeBuildProgress is the state of a build job, it is sent on every
+// change.
+type OctreeBuildProgress struct {
+	JobId    string  `json:"job_id"`
+	Id       string  `json:"id"` // octree item id
+	Status   string  `json:"status"`
+	Phase    string  `json:"phase"`
+	Progress float32 `json:"progress"` // 0 to 1
+	Error    string  `json:"error,omitempty"`
+	Elapsed  float64 `json:"elapsed"` // seconds since the job started
+}
+
+type octreeJob struct {
+	progress OctreeBuildProgress
+	start    time.Time
+	end      time.Time // when the job finished
+	cancel   chan struct{}
+	sink     func(*OctreeBuildProgress)
+}
+
+// updateJob changes the job under the manager mutex and returns a copy for
+// the sink, or nil when the job already finished.
+func (m *OctreeMgr) updateJob(job *octreeJob, phase, status string, err error) *OctreeBuildProgress {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if job.progress.Status != OctreeBuildRunning {
+		return nil
+	}
+	if phase != "" {
+		job.progress.Phase = phase
+		job.progress.Progress = octreeBuildPhaseProgress[phase]
+	}
+	job.progress.Status = status
+	if status != OctreeBuildRunning {
+		job.end = time.Now()
+	}
+	if err != nil {
+		job.progress.Error = err.Error()
+	}
+	job.progress.Elapsed = time.Since(job.start).Seconds()
+
+	progress := job.progress
+	return &progress
+}
+
+func (job *octreeJob) report(progress *OctreeBuildProgress) {
+	if progress != nil && job.sink != nil {
+		job.sink(progress)
+	}
+}
+
+// StartBuild builds an octree item on a goroutine and returns the job id,
+// sink receives the job state at every phase and when it ends.
+func (m *OctreeMgr) StartBuild(id string, octreeParam OctreeParam, agentParam AgentParam, triangles []Triangle, sink func(*OctreeBuildProgress)) (string, error) {
+	m.mutex.Lock()
+	if _, ok := m.items[id]; ok {
+		m.mutex.Unlock()
+		return "", errors.New("octree already exists")
+	}
+	m.pruneJobs()
+	for _, job := range m.jobs {
+		if job.progress.Id == id && job.progress.Status == OctreeBuildRunning {
+			m.mutex.Unlock()
+			return "", errors.New("octree is already being built")
+		}
+	}
+
+	m.lastJob++
+	job := &octreeJob{
+		progress: OctreeBuildProgress{
+			JobId:  strconv.FormatUint(m.lastJob, 10),
+			Id:     id,
+			Status: OctreeBuildRunning,
+			Phase:  OctreeBuildPhasePrepare,
+		},
+		start:  time.Now(),
+		cancel: make(chan struct{}),
+		sink:   sink,
+	}
+	m.jobs[job.progress.JobId] = job
+	m.mutex.Unlock()
+
+	go m.runBuild(job, octreeParam, agentParam, triangles)
+	return job.progress.JobId, nil
+}
+
+func (m *OctreeMgr) runBuild(job *octreeJob, octreeParam OctreeParam, agentParam AgentParam, triangles []Triangle) {
+	var item *OctreeItem
+	var err error
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("octree build panic: %v", r)
+			}
+		}()
+		item, err = buildOctreeItem(octreeParam, agentParam, triangles, func(phase string) bool {
+			select {
+			case <-job.cancel:
+				return false
+			default:
+			}
+			job.report(m.updateJob(job, phase, OctreeBuildRunning, nil))
+			return true
+		})
+	}()
+
+	if err == errOctreeBuildCancelled {
+		// CancelBuild already reported it
+		return
+	}
+	if err != nil {
+		job.report(m.updateJob(job, "", OctreeBuildFailed, err))
+		return
+	}
+
+	m.mutex.Lock()
+	if job.progress.Status != OctreeBuildRunning {
+		// cancelled during the last phase, drop the result
+		m.mutex.Unlock()
+		return
+	}
+	if _, ok := m.items[job.progress.Id]; ok {
+		m.mutex.Unlock()
+		job.report(m.updateJob(job, "", OctreeBuildFailed, errors.New("octree already exists")))
+		return
+	}
+	m.items[job.progress.Id] = item
+	job.progress.Status = OctreeBuildDone
+	job.end = time.Now()
+	job.progress.Phase = OctreeBuildPhaseDone
+	job.progress.Progress = octreeBuildPhaseProgress[OctreeBuildPhaseDone]
+	job.progress.Elapsed = time.Since(job.start).Seconds()
+	progress := job.progress
+	m.mutex.Unlock()
+
+	job.report(&progress)
+}
+
+// CancelBuild stops a running build job. A job inside the octree-go call of
+// a phase finishes that call in the background, its result is dropped.
+func (m *OctreeMgr) CancelBuild(jobId string) error {
+	m.mutex.Lock()
+	job, ok := m.jobs[jobId]
+	if !ok {
+		m.mutex.Unlock()
+		return errors.New("octree build job not found")
+	}
+	if job.progress.Status != OctreeBuildRunning {
+		m.mutex.Unlock()
+		return errors.New("octree build job already finished")
+	}
+	close(job.cancel)
+	job.progress.Status = OctreeBuildCancelled
+	job.end = time.Now()
+	job.progress.Error = errOctreeBuildCancelled.Error()
+	job.progress.Elapsed = time.Since(job.start).Seconds()
+	progress := job.progress
+	m.mutex.Unlock()
+
+	job.report(&progress)
+	return nil
+}
+
+// pruneJobs drops the jobs finished more than octreeJobTTL ago, the caller
+// holds the manager mutex.
+func (m *OctreeMgr) pruneJobs() {
+	for jobId, job := range m.jobs {
+		if job.progress.Status != OctreeBuildRunning && time.Since(job.end) > octreeJobTTL {
+			delete(m.jobs, jobId)
+		}
+	}
+}
+
+// GetBuildStatus returns the current state of a build job, finished jobs
+// are kept for octreeJobTTL.
+func (m *OctreeMgr) GetBuildStatus(jobId string) (*OctreeBuildProgress, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pruneJobs()
+	job, ok := m.jobs[jobId]
+	if !ok {
+		return nil, errors.New("octree build job not found")
+	}
+	progress := job.progress
+	if progress.Status == OctreeBuildRunning {
+		progress.Elapsed = time.Since(job.start).Seconds()
+	}
+	return &progress, nil
+}
@@ -0,0 +1,610 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+	"github.com/o0olele/octree-go/query"
+	"github.com/o0olele/octree-go/voxel"
+)
+
+// OctreeObstacle is a dynamic obstacle, either a triangle set or a box.
+type OctreeObstacle struct {
+	Triangles []Triangle `json:"triangles"`
+	Box       *Bounds    `json:"box"` // used when Triangles is empty
+}
+
+func vecMin(a, b math32.Vector3) math32.Vector3 {
+	return math32.Vector3{X: math32.Min(a.X, b.X), Y: math32.Min(a.Y, b.Y), Z: math32.Min(a.Z, b.Z)}
+}
+
+func vecMax(a, b math32.Vector3) math32.Vector3 {
+	return math32.Vector3{X: math32.Max(a.X, b.X), Y: math32.Max(a.Y, b.Y), Z: math32.Max(a.Z, b.Z)}
+}
+
+func aabbUnion(a, b geometry.AABB) geometry.AABB {
+	return geometry.AABB{Min: vecMin(a.Min, b.Min), Max: vecMax(a.Max, b.Max)}
+}
+
+func aabbContains(outer, inner geometry.AABB) bool {
+	return outer.Min.X <= inner.Min.X && outer.Min.Y <= inner.Min.Y && outer.Min.Z <= inner.Min.Z &&
+		outer.Max.X >= inner.Max.X && outer.Max.Y >= inner.Max.Y && outer.Max.Z >= inner.Max.Z
+}
+
+func trianglesBounds(triangles []geometry.Triangle) geometry.AABB {
+	bounds := geometry.AABB{Min: triangles[0].A, Max: triangles[0].A}
+	for _, tri := range triangles {
+		for _, v := range [3]math32.Vector3{tri.A, tri.B, tri.C} {
+			bounds.Min = vecMin(bounds.Min, v)
+			bounds.Max = vecMax(bounds.Max, v)
+		}
+	}
+	return bounds
+}
+
+// boxTriangles returns the 12 triangles of a box.
+func boxTriangles(box Bounds) []geometry.Triangle {
+	lo, hi := math32.Vector3(box.Min), math32.Vector3(box.Max)
+	corner := func(i int) math32.Vector3 {
+		c := lo
+		if i&1 != 0 {
+			c.X = hi.X
+		}
+		if i&2 != 0 {
+			c.Y = hi.Y
+		}
+		if i&4 != 0 {
+			c.Z = hi.Z
+		}
+		return c
+	}
+	faces := [6][4]int{
+		{0, 2, 6, 4}, {1, 5, 7, 3}, // -x, +x
+		{0, 4, 5, 1}, {2, 3, 7, 6}, // -y, +y
+		{0, 1, 3, 2}, {4, 6, 7, 5}, // -z, +z
+	}
+	triangles := make([]geometry.Triangle, 0, 12)
+	for _, f := range faces {
+		triangles = append(triangles,
+			geometry.Triangle{A: corner(f[0]), B: corner(f[1]), C: corner(f[2])},
+			geometry.Triangle{A: corner(f[0]), B: corner(f[2]), C: corner(f[3])})
+	}
+	return triangles
+}
+
+func (o *OctreeObstacle) triangles() ([]geometry.Triangle, error) {
+	if len(o.Triangles) == 0 {
+		if o.Box == nil {
+			return nil, errors.New("obstacle has neither triangles nor box")
+		}
+		return boxTriangles(*o.Box), nil
+	}
+	triangles := make([]geometry.Triangle, 0, len(o.Triangles))
+	for _, tri := range o.Triangles {
+		triangles = append(triangles, geometry.Triangle{A: math32.Vector3(tri.A), B: math32.Vector3(tri.B), C: math32.Vector3(tri.C)})
+	}
+	return triangles, nil
+}
+
+// allTriangles is the build geometry followed by the obstacles in id order.
+func (item *OctreeItem) allTriangles() []geometry.Triangle {
+	triangles := append([]geometry.Triangle(nil), item.baseTriangles...)
+	var ids []string
+	for id := range item.obstacles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		triangles = append(triangles, item.obstacles[id]...)
+	}
+	return triangles
+}
+
+// setObstacle adds, replaces or with nil triangles removes an obstacle and
+// updates the octree around it.
+func (item *OctreeItem) setObstacle(obstacleId string, triangles []geometry.Triangle) error {
+	if item.obstacles == nil {
+		item.baseTriangles = item.octree.GetTriangles()
+		item.obstacles = make(map[string][]geometry.Triangle)
+	}
+
+	var changed geometry.AABB
+	hasChanged := false
+	if old, ok := item.obstacles[obstacleId]; ok && len(old) > 0 {
+		changed, hasChanged = trianglesBounds(old), true
+	}
+	if len(triangles) > 0 {
+		bounds := trianglesBounds(triangles)
+		if hasChanged {
+			bounds = aabbUnion(changed, bounds)
+		}
+		changed, hasChanged = bounds, true
+	}
+
+	old, had := item.obstacles[obstacleId]
+	if triangles == nil {
+		delete(item.obstacles, obstacleId)
+	} else {
+		item.obstacles[obstacleId] = triangles
+	}
+	if !hasChanged {
+		return nil
+	}
+
+	start := time.Now()
+	if err := item.updateRegion(changed); err != nil {
+		if had {
+			item.obstacles[obstacleId] = old
+		} else {
+			delete(item.obstacles, obstacleId)
+		}
+		return err
+	}
+	item.setPhaseTime(OctreePhaseUpdate, time.Since(start))
+
+	// the node ids changed, keep the flow field towards the same goal. The
+	// obstacle is in place either way, a flow field that can not be built
+	// again is dropped and the reason reported when it is sampled
+	if item.flowField != nil {
+		field, err := item.buildFlowField(item.flowField.goal)
+		if err != nil {
+			item.flowErr = fmt.Errorf("flow field dropped by obstacle %s: %v", obstacleId, err)
+		}
+		item.flowField = field
+	}
+	return nil
+}
+
+// updateRegion rebuilds the smallest subtree around the changed bounds and,
+// for the default agent and every profile, the voxels its padding can reach
+// from there and the graph edges near it. Nothing changes unless all agents
+// are updated.
+func (item *OctreeItem) updateRegion(changed geometry.AABB) error {
+	tree := item.octree
+	triangles := item.allTriangles()
+
+	names := make([]string, 0, len(item.profiles))
+	for name := range item.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	profiles := make([]*octreeProfile, 0, len(names)+1)
+	for _, name := range append([]string{OctreeDefaultProfile}, names...) {
+		profile, err := item.profile(name)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	// the padding of the largest agent spreads the change over the voxels
+	// around it
+	var pad float32
+	for _, profile := range profiles {
+		pad = math32.Max(pad, math32.Max(profile.agentParam.Radius, profile.agentParam.Height)+profile.navData.VoxelSize)
+	}
+	padding := math32.Vector3{X: pad, Y: pad, Z: pad}
+	region := geometry.AABB{
+		Min: vecMax(changed.Min.Sub(padding), tree.Root.Bounds.Min),
+		Max: vecMin(changed.Max.Add(padding), tree.Root.Bounds.Max),
+	}
+
+	// 1. rebuild the deepest node containing the region
+	subRoot := tree.Root
+	for !subRoot.IsLeaf() {
+		var next *octree.OctreeNode
+		for _, child := range subRoot.Children {
+			if child != nil && aabbContains(child.Bounds, region) {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		subRoot = next
+	}
+
+	oldLeaves := octreeFreeLeaves(tree.Root, nil)
+	oldFlags, oldNodeTriangles, oldChildren := subRoot.Flags, subRoot.Triangles, subRoot.Children
+	oldTriangles := tree.GetTriangles()
+
+	sub := octree.NewOctree(subRoot.Bounds, tree.MaxDepth-subRoot.Depth, tree.MinSize)
+	for i := range triangles {
+		if triangles[i].IntersectsAABB(subRoot.Bounds) {
+			sub.AddTriangle(triangles[i])
+		}
+	}
+	sub.Build()
+	subRoot.Flags = sub.Root.Flags
+	subRoot.Triangles = sub.Root.Triangles
+	subRoot.Children = sub.Root.Children
+	offsetOctreeDepth(subRoot, subRoot.Depth)
+	tree.SetTriangles(triangles)
+	leaves := octreeFreeLeaves(tree.Root, nil)
+
+	// 2. update every agent, the old subtree goes back when one fails
+	updated := make([]*octreeProfile, len(profiles))
+	for i, profile := range profiles {
+		next, err := item.updateProfileRegion(profile, region, triangles, oldLeaves, leaves)
+		if err != nil {
+			subRoot.Flags, subRoot.Triangles, subRoot.Children = oldFlags, oldNodeTriangles, oldChildren
+			tree.SetTriangles(oldTriangles)
+			return err
+		}
+		updated[i] = next
+	}
+
+	item.navData, item.edges, item.query = updated[0].navData, updated[0].edges, updated[0].query
+	for i, name := range names {
+		item.profiles[name] = updated[i+1]
+	}
+	return nil
+}
+
+// updateProfileRegion voxelizes the region again for the agent of profile,
+// keeps the graph edges away from the region, connects the leaves near it
+// like the path graph of octree-go and prunes the graph like a build does.
+func (item *OctreeItem) updateProfileRegion(profile *octreeProfile, region geometry.AABB, triangles []geometry.Triangle,
+	oldLeaves, leaves []*octree.OctreeNode) (*octreeProfile, error) {
+	base := profile.navData
+	voxelData, err := revoxelize(profile, region, triangles)
+	if err != nil {
+		return nil, err
+	}
+	navData := &builder.NavigationData{
+		Bounds:    base.Bounds,
+		MaxDepth:  base.MaxDepth,
+		MinSize:   base.MinSize,
+		StepSize:  base.StepSize,
+		GridSize:  base.GridSize,
+		VoxelSize: base.VoxelSize,
+		VoxelData: voxelData,
+	}
+
+	// octreeNodesAdjacent only looks at the geometry along the segment
+	// between the leaf centers and at the agent around it, the answer for a
+	// pair only changes when that segment crosses the padded region
+	newIds := make(map[geometry.AABB]int32, len(leaves))
+	var maxSize float32
+	for i, leaf := range leaves {
+		newIds[leaf.Bounds] = int32(i)
+		maxSize = math32.Max(maxSize, octreeNodeSize(leaf.Bounds))
+	}
+	// a leaf split off or freed by the update has no edges yet
+	changed := make([]bool, len(leaves))
+	for i := range changed {
+		changed[i] = true
+	}
+	for _, leaf := range oldLeaves {
+		if id, ok := newIds[leaf.Bounds]; ok {
+			changed[id] = false
+		}
+	}
+	crosses := func(a, b int32) bool {
+		return segmentIntersectsAABB(region, leaves[a].Bounds.Center(), leaves[b].Bounds.Center())
+	}
+
+	// loaded navigation data only has the pruned edges
+	oldEdges := profile.edges
+	if oldEdges == nil {
+		oldEdges = base.Edges
+	}
+	var edges []builder.CompactEdge
+	for _, edge := range oldEdges {
+		a, okA := newIds[oldLeaves[edge.NodeAID].Bounds]
+		b, okB := newIds[oldLeaves[edge.NodeBID].Bounds]
+		if okA && okB && !crosses(a, b) {
+			edges = append(edges, builder.CompactEdge{NodeAID: a, NodeBID: b, Cost: edge.Cost})
+		}
+	}
+
+	// the path graph of octree-go leaves out the leaves without headroom
+	headroom := make(map[int32]bool)
+	hasHeadroom := func(id int32) bool {
+		free, ok := headroom[id]
+		if !ok {
+			hit, _, _, _ := item.octree.Raycast(leaves[id].Bounds.Center(), math32.Vector3{Y: 1}, profile.agent.Height)
+			free = !hit
+			headroom[id] = free
+		}
+		return free
+	}
+	// both ends of a connected pair are at most 0.6 times their sizes from
+	// the other one, so only the leaves that close to the region can cross it
+	check := make([]bool, len(leaves))
+	for i, leaf := range leaves {
+		reach := 0.6 * (octreeNodeSize(leaf.Bounds) + maxSize)
+		check[i] = changed[i] || aabbDistance(region, leaf.Bounds.Center()) <= reach
+	}
+	var near []int32
+	for i := range leaves {
+		a := int32(i)
+		if !check[a] || !hasHeadroom(a) {
+			continue
+		}
+		// octreeNodesAdjacent needs the bounds within a fifth of the smaller
+		// size, the candidates are sorted to add the edges in the same order
+		near = octreeLeavesNear(item.octree.Root, leaves[a].Bounds, 0.2*octreeNodeSize(leaves[a].Bounds), newIds, near[:0])
+		sort.Slice(near, func(x, y int) bool { return near[x] < near[y] })
+		for _, b := range near {
+			if b == a || check[b] && b < a || !changed[a] && !changed[b] && !crosses(a, b) {
+				continue
+			}
+			if octreeNodesAdjacent(item.octree, profile.agent, base.StepSize, leaves[a], leaves[b]) && hasHeadroom(b) {
+				lo, hi := min(a, b), max(a, b)
+				edges = append(edges, builder.CompactEdge{NodeAID: lo, NodeBID: hi, Cost: leaves[lo].Bounds.Center().Distance(leaves[hi].Bounds.Center())})
+			}
+		}
+	}
+	navData.Edges = pruneOctreeEdges(edges)
+
+	navData = newOctreeNavData(navData, leaves)
+	q, err := query.NewNavigationQuery(navData)
+	if err != nil {
+		return nil, err
+	}
+	q.SetAgent(profile.agent)
+	return &octreeProfile{
+		agent:      profile.agent,
+		agentParam: profile.agentParam,
+		navData:    navData,
+		edges:      edges,
+		query:      q,
+	}, nil
+}
+
+func offsetOctreeDepth(node *octree.OctreeNode, depth uint8) {
+	for _, child := range node.Children {
+		if child != nil {
+			child.Depth += depth
+			offsetOctreeDepth(child, depth)
+		}
+	}
+}
+
+// aabbDistance is the distance from p to the box, 0 inside it.
+func aabbDistance(box geometry.AABB, p math32.Vector3) float32 {
+	dx := math32.Max(0, math32.Max(box.Min.X-p.X, p.X-box.Max.X))
+	dy := math32.Max(0, math32.Max(box.Min.Y-p.Y, p.Y-box.Max.Y))
+	dz := math32.Max(0, math32.Max(box.Min.Z-p.Z, p.Z-box.Max.Z))
+	return math32.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// aabbGap is the distance between two boxes, 0 when they touch.
+func aabbGap(a, b geometry.AABB) float32 {
+	dx := math32.Max(0, math32.Max(a.Min.X-b.Max.X, b.Min.X-a.Max.X))
+	dy := math32.Max(0, math32.Max(a.Min.Y-b.Max.Y, b.Min.Y-a.Max.Y))
+	dz := math32.Max(0, math32.Max(a.Min.Z-b.Max.Z, b.Min.Z-a.Max.Z))
+	return math32.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// octreeLeavesNear appends the ids of the leaves at most pad away from box,
+// only descending the nodes that are that close.
+func octreeLeavesNear(node *octree.OctreeNode, box geometry.AABB, pad float32, ids map[geometry.AABB]int32, near []int32) []int32 {
+	if node == nil || aabbGap(node.Bounds, box) > pad {
+		return near
+	}
+	if node.IsLeaf() {
+		if id, ok := ids[node.Bounds]; ok {
+			near = append(near, id)
+		}
+		return near
+	}
+	for _, child := range node.Children {
+		near = octreeLeavesNear(child, box, pad, ids, near)
+	}
+	return near
+}
+
+// segmentIntersectsAABB reports whether the segment from a to b touches the
+// box, clipping it against the slabs of every axis.
+func segmentIntersectsAABB(box geometry.AABB, a, b math32.Vector3) bool {
+	tMin, tMax := float32(0), float32(1)
+	for axis := 0; axis < 3; axis++ {
+		start, d := axisOf(a, axis), axisOf(b, axis)-axisOf(a, axis)
+		lo, hi := axisOf(box.Min, axis), axisOf(box.Max, axis)
+		if d == 0 {
+			if start < lo || start > hi {
+				return false
+			}
+			continue
+		}
+		t0, t1 := (lo-start)/d, (hi-start)/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin, tMax = math32.Max(tMin, t0), math32.Min(tMax, t1)
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
+
+func axisOf(v math32.Vector3, axis int) float32 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	}
+	return v.Z
+}
+
+func octreeNodeSize(bounds geometry.AABB) float32 {
+	size := bounds.Size()
+	return math32.Max(math32.Max(size.X, size.Y), size.Z)
+}
+
+// octreeNodesAdjacent reports whether the path graph of octree-go connects
+// two leaves. It copies areNodesAdjacent and isPathClearRelaxed of the
+// pathfinder of octree-go v0.2.0, which are not exported.
+func octreeNodesAdjacent(tree *octree.Octree, agent *octree.Agent, stepSize float32, a, b *octree.OctreeNode) bool {
+	centerA, centerB := a.Bounds.Center(), b.Bounds.Center()
+	distance := centerA.Distance(centerB)
+	sizeA, sizeB := octreeNodeSize(a.Bounds), octreeNodeSize(b.Bounds)
+	if distance > (sizeA+sizeB)*0.6 {
+		return false
+	}
+	if !a.Bounds.Intersects(b.Bounds) && aabbGap(a.Bounds, b.Bounds) > math32.Min(sizeA, sizeB)*0.2 {
+		return false
+	}
+
+	if distance < 0.001 {
+		return true
+	}
+	direction := centerB.Sub(centerA).Scale(1 / distance)
+	if hit, _, _, _ := tree.Raycast(centerA, direction, distance); hit {
+		return false
+	}
+	steps := math32.CeilToInt(distance / math32.Min(stepSize*0.5, 0.2))
+	steps = min(max(steps, 5), 20)
+	up := math32.Vector3{Y: 1}
+	for i := 0; i <= steps; i++ {
+		t := float32(i) / float32(steps)
+		sample := centerA.Add(direction.Scale(distance * t))
+		if tree.IsOccupied(sample) || tree.IsAgentOccupied(agent, sample) {
+			return false
+		}
+		if hit, _, _, _ := tree.Raycast(sample, up, agent.Height); hit {
+			return false
+		}
+	}
+	return true
+}
+
+// revoxelize recomputes the voxel bits of profile inside region. The voxels
+// are made from the triangles near the region padded by the agent size so
+// solids just outside still pad inwards.
+func revoxelize(profile *octreeProfile, region geometry.AABB, triangles []geometry.Triangle) ([]uint64, error) {
+	base := profile.navData
+	bitmap := append(math32.Bitmap(nil), base.VoxelData...)
+	if base.VoxelSize <= 0 {
+		return bitmap, nil
+	}
+
+	size := base.GridSize
+	toVoxel := func(p math32.Vector3) math32.Vector3i {
+		local := p.Sub(base.Bounds.Min).Scale(1 / base.VoxelSize)
+		return math32.Vector3i{
+			X: math32.Min(math32.Max(int32(math.Floor(float64(local.X))), 0), size.X-1),
+			Y: math32.Min(math32.Max(int32(math.Floor(float64(local.Y))), 0), size.Y-1),
+			Z: math32.Min(math32.Max(int32(math.Floor(float64(local.Z))), 0), size.Z-1),
+		}
+	}
+	wMin, wMax := toVoxel(region.Min), toVoxel(region.Max)
+
+	radius := int32(math.Ceil(float64(profile.agentParam.Radius*0.9/base.VoxelSize))) + 1
+	height := int32(math.Ceil(float64(profile.agentParam.Height*0.9/base.VoxelSize))) + 1
+	pad := math32.Max(radius, height)
+	lMin := math32.Vector3i{X: math32.Max(wMin.X-pad, 0), Y: math32.Max(wMin.Y-pad, 0), Z: math32.Max(wMin.Z-pad, 0)}
+	lMax := math32.Vector3i{X: math32.Min(wMax.X+pad, size.X-1), Y: math32.Min(wMax.Y+pad, size.Y-1), Z: math32.Min(wMax.Z+pad, size.Z-1)}
+
+	origin := base.Bounds.Min.Add(math32.Vector3{X: float32(lMin.X), Y: float32(lMin.Y), Z: float32(lMin.Z)}.Scale(base.VoxelSize))
+	// the grid starts at the origin of the full one, voxel corners computed
+	// from another origin round differently and a triangle on a voxel face
+	// would mark other voxels than a build does
+	local := voxel.NewVoxelGrid(math32.Vector3i{X: lMax.X + 1, Y: lMax.Y + 1, Z: lMax.Z + 1}, base.VoxelSize, base.Bounds.Min)
+	localBounds := geometry.AABB{Min: origin, Max: base.Bounds.Min.Add(math32.Vector3{X: float32(lMax.X + 1), Y: float32(lMax.Y + 1), Z: float32(lMax.Z + 1)}.Scale(base.VoxelSize))}
+
+	var near []geometry.Triangle
+	for i := range triangles {
+		if triangles[i].IntersectsAABB(localBounds) {
+			near = append(near, triangles[i])
+		}
+	}
+	local.VoxelizeWithPadding(near, profile.agentParam.Radius, profile.agentParam.Height)
+
+	for z := wMin.Z; z <= wMax.Z; z++ {
+		for y := wMin.Y; y <= wMax.Y; y++ {
+			for x := wMin.X; x <= wMax.X; x++ {
+				index := uint32(z*size.X*size.Y + y*size.X + x)
+				v := local.GetVoxel(math32.Vector3i{X: x, Y: y, Z: z})
+				if v != nil && *v == voxel.Voxel(voxel.VoxelSolid) {
+					bitmap.Set(index)
+				} else {
+					bitmap.Remove(index)
+				}
+			}
+		}
+	}
+	return bitmap, nil
+}
+
+// SetObstacle adds or replaces a dynamic obstacle on an octree item.
+func (m *OctreeMgr) SetObstacle(id, obstacleId string, obstacle OctreeObstacle) error {
+	triangles, err := obstacle.triangles()
+	if err != nil {
+		return err
+	}
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	return item.setObstacle(obstacleId, triangles)
+}
+
+func (m *OctreeMgr) RemoveObstacle(id, obstacleId string) error {
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	if _, ok := item.obstacles[obstacleId]; !ok {
+		return errors.New("obstacle not found")
+	}
+	return item.setObstacle(obstacleId, nil)
+}
+
+// MoveObstacle translates an obstacle by offset.
+func (m *OctreeMgr) MoveObstacle(id, obstacleId string, offset Vec3) error {
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	old, ok := item.obstacles[obstacleId]
+	if !ok {
+		return errors.New("obstacle not found")
+	}
+
+	d := math32.Vector3(offset)
+	triangles := make([]geometry.Triangle, len(old))
+	for i, tri := range old {
+		triangles[i] = geometry.Triangle{A: tri.A.Add(d), B: tri.B.Add(d), C: tri.C.Add(d)}
+	}
+	return item.setObstacle(obstacleId, triangles)
+}
+
+// GetObstacles lists the obstacle ids of an octree item.
+func (m *OctreeMgr) GetObstacles(id string) ([]string, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	ids := []string{}
+	for obstacleId := range item.obstacles {
+		ids = append(ids, obstacleId)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestOctreeObstacleCycle checks that adding and removing an obstacle gives
+// the navigation data of a fresh build of the same geometry.
+func TestOctreeObstacleCycle(t *testing.T) {
+	// the floor is large enough for the update to keep edges away from the box
+	floor := []Triangle{
+		{A: Vec3{X: -9, Z: -9}, B: Vec3{X: -9, Z: 9}, C: Vec3{X: 9, Z: 9}},
+		{A: Vec3{X: -9, Z: -9}, B: Vec3{X: 9, Z: 9}, C: Vec3{X: 9, Z: -9}},
+	}
+	param := OctreeParam{
+		Bounds:   Bounds{Min: Vec3{X: -10, Y: -1, Z: -10}, Max: Vec3{X: 10, Y: 3, Z: 10}},
+		MaxDepth: 5,
+		MinSize:  0.5,
+		StepSize: 0.5,
+	}
+	agent := AgentParam{Radius: 0.3, Height: 1}
+	tests := []struct {
+		name string
+		box  Bounds
+	}{
+		{name: "on the floor", box: Bounds{Min: Vec3{X: 0.2, Y: 0, Z: -1.3}, Max: Vec3{X: 1.4, Y: 1.5, Z: 0.4}}},
+		// splits the large empty leaves above the floor
+		{name: "in the air", box: Bounds{Min: Vec3{X: -6, Y: 2.2, Z: 5}, Max: Vec3{X: -5.5, Y: 2.6, Z: 5.5}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testOctreeObstacleCycle(t, param, agent, floor, test.box)
+		})
+	}
+}
+
+func testOctreeObstacleCycle(t *testing.T, param OctreeParam, agent AgentParam, floor []Triangle, box Bounds) {
+	withBox := append([]Triangle(nil), floor...)
+	for _, tri := range boxTriangles(box) {
+		withBox = append(withBox, Triangle{A: Vec3(tri.A), B: Vec3(tri.B), C: Vec3(tri.C)})
+	}
+
+	m := NewOctreeMgr()
+	for id, triangles := range map[string][]Triangle{"scene": floor, "floor": floor, "box": withBox} {
+		if err := m.Add(id, param, agent, triangles); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the flow field follows the update
+	if _, err := m.BuildFlowField("scene", Vec3{X: 3, Y: 0.6, Z: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name   string
+		update func() error
+		want   string
+	}{
+		{name: "add", update: func() error { return m.SetObstacle("scene", "box", OctreeObstacle{Box: &box}) }, want: "box"},
+		{name: "remove", update: func() error { return m.RemoveObstacle("scene", "box") }, want: "floor"},
+	}
+	for _, step := range steps {
+		if err := step.update(); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := m.getItem("scene")
+		want, _ := m.getItem(step.want)
+		if len(got.navData.Nodes) != len(want.navData.Nodes) {
+			t.Fatalf("%s: got %d nodes, want %d", step.name, len(got.navData.Nodes), len(want.navData.Nodes))
+		}
+		for i := range got.navData.Nodes {
+			if got.navData.Nodes[i].Bounds != want.navData.Nodes[i].Bounds {
+				t.Fatalf("%s: node %d has bounds %v, want %v", step.name, i, got.navData.Nodes[i].Bounds, want.navData.Nodes[i].Bounds)
+			}
+		}
+		if !reflect.DeepEqual(got.navData.VoxelData, want.navData.VoxelData) {
+			t.Fatalf("%s: voxels differ", step.name)
+		}
+		if g, w := sortedOctreeEdges(got.edges), sortedOctreeEdges(want.edges); !reflect.DeepEqual(g, w) {
+			t.Fatalf("%s: got %d graph edges, want %d", step.name, len(g), len(w))
+		}
+		if g, w := sortedOctreeEdges(got.navData.Edges), sortedOctreeEdges(want.navData.Edges); !reflect.DeepEqual(g, w) {
+			t.Fatalf("%s: got %d pruned edges, want %d", step.name, len(g), len(w))
+		}
+		if got.flowField == nil || len(got.flowField.distance) != len(want.navData.Nodes) {
+			t.Fatalf("%s: flow field was not rebuilt", step.name)
+		}
+	}
+}
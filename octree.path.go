@@ -0,0 +1,407 @@
+package main
+
+import (
+	"container/heap"
+	"errors"
+	"time"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+	"github.com/o0olele/octree-go/query"
+)
+
+// Path smoothing modes of OctreePathOptions.
+const (
+	OctreeSmoothNone       = ""
+	OctreeSmoothCatmullRom = "catmull_rom"
+	OctreeSmoothBezier     = "bezier"
+)
+
+const (
+	octreeMaxIterations  = 20000 // same limit as the octree-go A*
+	octreeSmoothSegments = 8
+)
+
+// OctreePathOptions controls the post-processing of an octree path.
+type OctreePathOptions struct {
+	Profile       string `json:"profile"`        // agent profile, empty for the default one
+	Prune         bool   `json:"prune"`          // drop waypoints that are in line of sight
+	Smooth        string `json:"smooth"`         // one of the OctreeSmooth modes
+	Segments      int    `json:"segments"`       // samples per smoothed segment, 0 uses 8
+	MaxIterations int    `json:"max_iterations"` // A* expansion limit, 0 uses the 20000 of octree-go
+}
+
+// OctreePathStats describes how a path was found.
+type OctreePathStats struct {
+	Length     float32 `json:"length"`
+	Expansions int     `json:"expansions"`  // nodes taken from the open list
+	SearchTime float64 `json:"search_time"` // milliseconds spent in A*
+	RawPoints  int     `json:"raw_points"`  // waypoints before pruning and smoothing
+}
+
+// OctreePath is a path through the free nodes of an octree. A partial path
+// ends at the node closest to the goal.
+type OctreePath struct {
+	Points  []Vec3          `json:"points"`
+	Nodes   []int32         `json:"nodes"`
+	Partial bool            `json:"partial"`
+	Stats   OctreePathStats `json:"stats"`
+}
+
+type octreeOpenNode struct {
+	id    int32
+	f     float32
+	index int
+}
+
+type octreeOpenList []*octreeOpenNode
+
+func (h octreeOpenList) Len() int           { return len(h) }
+func (h octreeOpenList) Less(i, j int) bool { return h[i].f < h[j].f }
+func (h octreeOpenList) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *octreeOpenList) Push(x interface{}) {
+	node := x.(*octreeOpenNode)
+	node.index = len(*h)
+	*h = append(*h, node)
+}
+
+func (h *octreeOpenList) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
+
+// octreeSearch is the scratch state of the A* search, a worker reuses it
+// for all its queries.
+type octreeSearch struct {
+	gScore   map[int32]float32
+	cameFrom map[int32]int32
+	closed   map[int32]bool
+	open     map[int32]*octreeOpenNode
+	openList octreeOpenList
+}
+
+func newOctreeSearch() *octreeSearch {
+	return &octreeSearch{
+		gScore:   make(map[int32]float32),
+		cameFrom: make(map[int32]int32),
+		closed:   make(map[int32]bool),
+		open:     make(map[int32]*octreeOpenNode),
+	}
+}
+
+// octreeMoveCost is the movement cost of query.NavigationQuery: the center
+// distance weighted by the path preferences, paths along the boundary of
+// the free space cost more and paths through dense node areas less.
+func octreeMoveCost(q *query.NavigationQuery, from, to int32) float32 {
+	navData := q.GetNavigationData()
+	cost := navData.Nodes[from].Center.Distance(navData.Nodes[to].Center)
+	prefs := q.GetPathPreferences()
+	if prefs == nil || !prefs.EnablePathPreference {
+		return cost
+	}
+
+	threshold := q.GetStepSize() * prefs.BoundaryThreshold
+	boundary := 0
+	for _, id := range [2]int32{from, to} {
+		if navData.GetNodeBoundaryDistance(id) < threshold {
+			boundary++
+		}
+	}
+	multiplier := 1 - prefs.InteriorPathBonus
+	switch boundary {
+	case 2:
+		multiplier = 1 + prefs.BoundaryPathPenalty
+	case 1:
+		multiplier = 1 + prefs.BoundaryPathPenalty*0.5
+	}
+
+	neighbors := float32(navData.GetNodeNeighborCount(from)+navData.GetNodeNeighborCount(to)) / 2
+	switch {
+	case neighbors > 6:
+		multiplier *= 1 - prefs.DensityBonus
+	case neighbors > 4:
+		multiplier *= 1 - prefs.DensityBonus*0.5
+	case neighbors < 3:
+		multiplier *= 1 + prefs.DensityBonus*1.5
+	}
+	return cost * multiplier
+}
+
+// astar is query.NavigationQuery.Astar, the search its FindPath falls back
+// to, with the same cost, heuristic and default limit. It counts the
+// expansions and when end is not reached returns the path to the expanded
+// node closest to end.
+func (s *octreeSearch) astar(q *query.NavigationQuery, start, end int32, maxIterations int) ([]int32, bool, int) {
+	navData := q.GetNavigationData()
+	goal := navData.Nodes[end].Center
+	heuristic := func(id int32) float32 {
+		return navData.Nodes[id].Center.Distance(goal)
+	}
+
+	clear(s.gScore)
+	clear(s.cameFrom)
+	clear(s.closed)
+	clear(s.open)
+	s.openList = s.openList[:0]
+	gScore, cameFrom, closed, open := s.gScore, s.cameFrom, s.closed, s.open
+	openList := &s.openList
+
+	gScore[start] = 0
+	first := &octreeOpenNode{id: start, f: heuristic(start)}
+	heap.Push(openList, first)
+	open[start] = first
+
+	best, bestH := start, heuristic(start)
+	expansions := 0
+	for openList.Len() > 0 && expansions < maxIterations {
+		current := heap.Pop(openList).(*octreeOpenNode)
+		delete(open, current.id)
+		expansions++
+
+		if current.id == end {
+			best = end
+			break
+		}
+		closed[current.id] = true
+		if h := heuristic(current.id); h < bestH {
+			best, bestH = current.id, h
+		}
+
+		for _, neighbor := range navData.GetNeighbors(current.id) {
+			if closed[neighbor] {
+				continue
+			}
+			g := gScore[current.id] + octreeMoveCost(q, current.id, neighbor)
+			if old, ok := gScore[neighbor]; ok && g >= old {
+				continue
+			}
+			gScore[neighbor] = g
+			cameFrom[neighbor] = current.id
+
+			if node, ok := open[neighbor]; ok {
+				node.f = g + heuristic(neighbor)
+				heap.Fix(openList, node.index)
+			} else {
+				node = &octreeOpenNode{id: neighbor, f: g + heuristic(neighbor)}
+				heap.Push(openList, node)
+				open[neighbor] = node
+			}
+		}
+	}
+
+	var path []int32
+	for id := best; ; id = cameFrom[id] {
+		path = append(path, id)
+		if id == start {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, best != end, expansions
+}
+
+// prunePath keeps only the waypoints needed to stay in line of sight.
+func prunePath(navData *builder.NavigationData, agent *octree.Agent, points []math32.Vector3) []math32.Vector3 {
+	if len(points) <= 2 {
+		return points
+	}
+
+	pruned := []math32.Vector3{points[0]}
+	for current := 0; current < len(points)-1; {
+		next := current + 1
+		for i := len(points) - 1; i > next; i-- {
+			if navData.IsPathClear(agent, points[current], points[i]) {
+				next = i
+				break
+			}
+		}
+		pruned = append(pruned, points[next])
+		current = next
+	}
+	return pruned
+}
+
+func catmullRom(p0, p1, p2, p3 math32.Vector3, t float32) math32.Vector3 {
+	t2 := t * t
+	t3 := t2 * t
+	return p1.Scale(2).
+		Add(p2.Sub(p0).Scale(t)).
+		Add(p0.Scale(2).Sub(p1.Scale(5)).Add(p2.Scale(4)).Sub(p3).Scale(t2)).
+		Add(p1.Scale(3).Sub(p0).Sub(p2.Scale(3)).Add(p3).Scale(t3)).
+		Scale(0.5)
+}
+
+func quadraticBezier(p0, p1, p2 math32.Vector3, t float32) math32.Vector3 {
+	u := 1 - t
+	return p0.Scale(u * u).Add(p1.Scale(2 * u * t)).Add(p2.Scale(t * t))
+}
+
+// isCurveClear checks that the sampled curve starting at from stays free.
+func isCurveClear(navData *builder.NavigationData, agent *octree.Agent, from math32.Vector3, curve []math32.Vector3) bool {
+	for _, p := range curve {
+		if !navData.IsPathClear(agent, from, p) {
+			return false
+		}
+		from = p
+	}
+	return true
+}
+
+// smoothPath replaces the polyline by a curve, spans where the curve leaves
+// free space keep the straight segments.
+func smoothPath(navData *builder.NavigationData, agent *octree.Agent, points []math32.Vector3, mode string, segments int) []math32.Vector3 {
+	if len(points) <= 2 || mode == OctreeSmoothNone {
+		return points
+	}
+
+	smoothed := []math32.Vector3{points[0]}
+	switch mode {
+	case OctreeSmoothCatmullRom:
+		for i := 0; i < len(points)-1; i++ {
+			p0 := points[max(i-1, 0)]
+			p3 := points[min(i+2, len(points)-1)]
+			curve := make([]math32.Vector3, 0, segments)
+			for s := 1; s <= segments; s++ {
+				curve = append(curve, catmullRom(p0, points[i], points[i+1], p3, float32(s)/float32(segments)))
+			}
+			curve[len(curve)-1] = points[i+1]
+
+			if isCurveClear(navData, agent, points[i], curve) {
+				smoothed = append(smoothed, curve...)
+			} else {
+				smoothed = append(smoothed, points[i+1])
+			}
+		}
+	case OctreeSmoothBezier:
+		// round every corner with a quadratic curve between the segment
+		// midpoints
+		for i := 1; i < len(points)-1; i++ {
+			from := points[i-1].Add(points[i]).Scale(0.5)
+			if i == 1 {
+				from = points[0]
+			}
+			to := points[i].Add(points[i+1]).Scale(0.5)
+			if i == len(points)-2 {
+				to = points[i+1]
+			}
+
+			curve := make([]math32.Vector3, 0, segments)
+			for s := 1; s <= segments; s++ {
+				curve = append(curve, quadraticBezier(from, points[i], to, float32(s)/float32(segments)))
+			}
+
+			smoothed = append(smoothed, from)
+			if isCurveClear(navData, agent, from, curve) {
+				smoothed = append(smoothed, curve...)
+			} else {
+				smoothed = append(smoothed, points[i], to)
+			}
+		}
+		smoothed = append(smoothed, points[len(points)-1])
+	default:
+		return points
+	}
+
+	// drop the repeated points where curves meet
+	result := smoothed[:1]
+	for _, p := range smoothed[1:] {
+		if p != result[len(result)-1] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func pathLength(points []math32.Vector3) float32 {
+	var length float32
+	for i := 1; i < len(points); i++ {
+		length += points[i-1].Distance(points[i])
+	}
+	return length
+}
+
+// FindPathWithOptions finds a path and post-processes it. The nodes come
+// from the unidirectional A* of octree-go, FindPath tries a bidirectional
+// one first, so both can take a different route of about the same cost.
+func (m *OctreeMgr) FindPathWithOptions(id string, start, end Vec3, options OctreePathOptions) (*OctreePath, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	return item.findPath(newOctreeSearch(), math32.Vector3(start), math32.Vector3(end), options)
+}
+
+// findPath runs one query with the scratch state of search, queries with
+// their own search can run in parallel under the read lock of the item.
+func (item *OctreeItem) findPath(search *octreeSearch, start, end math32.Vector3, options OctreePathOptions) (*OctreePath, error) {
+	switch options.Smooth {
+	case OctreeSmoothNone, OctreeSmoothCatmullRom, OctreeSmoothBezier:
+	default:
+		return nil, errors.New("unknown smooth mode")
+	}
+	if options.Segments <= 0 {
+		options.Segments = octreeSmoothSegments
+	}
+	if options.MaxIterations <= 0 {
+		options.MaxIterations = octreeMaxIterations
+	}
+
+	profile, err := item.profile(options.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	navData := profile.navData
+	startNode := octreeClosestNode(navData, start)
+	endNode := octreeClosestNode(navData, end)
+	if startNode < 0 || endNode < 0 {
+		return nil, errors.New("no octree node near start or end")
+	}
+
+	searchStart := time.Now()
+	nodes, partial, expansions := search.astar(profile.query, startNode, endNode, options.MaxIterations)
+	searchTime := time.Since(searchStart)
+
+	points := make([]math32.Vector3, 0, len(nodes)+2)
+	points = append(points, start)
+	for _, node := range nodes {
+		points = append(points, navData.Nodes[node].Bounds.Center())
+	}
+	if !partial {
+		points = append(points, end)
+	}
+	rawPoints := len(points)
+
+	if options.Prune {
+		points = prunePath(navData, profile.agent, points)
+	}
+	points = smoothPath(navData, profile.agent, points, options.Smooth, options.Segments)
+
+	path := &OctreePath{
+		Nodes:   nodes,
+		Partial: partial,
+		Stats: OctreePathStats{
+			Length:     pathLength(points),
+			Expansions: expansions,
+			SearchTime: float64(searchTime.Microseconds()) / 1000,
+			RawPoints:  rawPoints,
+		},
+	}
+	for _, p := range points {
+		path.Points = append(path.Points, Vec3(p))
+	}
+	return path, nil
+}
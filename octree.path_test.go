@@ -0,0 +1,126 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/o0olele/octree-go/math32"
+)
+
+// pathTestOctree builds a floor split at z = 0.3 by a wall that runs from
+// x = -5 to wallEnd, the cells of the octree are 0.625 wide.
+func pathTestOctree(t *testing.T, wallEnd float32) (*OctreeMgr, string) {
+	t.Helper()
+	triangles := []Triangle{
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: -4, Z: 4}, C: Vec3{X: 4, Z: 4}},
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: 4, Z: 4}, C: Vec3{X: 4, Z: -4}},
+	}
+	if wallEnd > -5 {
+		triangles = append(triangles,
+			Triangle{A: Vec3{X: -5, Y: -1, Z: 0.3}, B: Vec3{X: wallEnd, Y: -1, Z: 0.3}, C: Vec3{X: wallEnd, Y: 3, Z: 0.3}},
+			Triangle{A: Vec3{X: -5, Y: -1, Z: 0.3}, B: Vec3{X: wallEnd, Y: 3, Z: 0.3}, C: Vec3{X: -5, Y: 3, Z: 0.3}},
+		)
+	}
+	param := OctreeParam{
+		Bounds:   Bounds{Min: Vec3{X: -5, Y: -1, Z: -5}, Max: Vec3{X: 5, Y: 3, Z: 5}},
+		MaxDepth: 4,
+		MinSize:  0.5,
+		StepSize: 0.5,
+	}
+	m := NewOctreeMgr()
+	if err := m.Add("scene", param, AgentParam{Radius: 0.3, Height: 1}, triangles); err != nil {
+		t.Fatal(err)
+	}
+	return m, "scene"
+}
+
+func TestOctreeFindPathWithOptions(t *testing.T) {
+	start, end := Vec3{X: -3, Y: 0.6, Z: -3}, Vec3{X: -3, Y: 0.6, Z: 3}
+
+	tests := []struct {
+		name    string
+		wallEnd float32 // -5 for no wall
+		options OctreePathOptions
+		partial bool
+		check   func(t *testing.T, path *OctreePath)
+	}{
+		{
+			name:    "straight",
+			wallEnd: -5,
+			check: func(t *testing.T, path *OctreePath) {
+				if len(path.Points) != path.Stats.RawPoints || len(path.Points) < 3 {
+					t.Fatalf("got %d points of %d raw ones", len(path.Points), path.Stats.RawPoints)
+				}
+			},
+		},
+		{
+			name:    "pruned",
+			wallEnd: -5,
+			options: OctreePathOptions{Prune: true},
+			check: func(t *testing.T, path *OctreePath) {
+				if len(path.Points) != 2 {
+					t.Fatalf("got %d points in the open, want 2", len(path.Points))
+				}
+				if got, want := path.Stats.Length, math32.Vector3(start).Distance(math32.Vector3(end)); got != want {
+					t.Fatalf("got length %v, want %v", got, want)
+				}
+			},
+		},
+		{
+			name:    "around a wall",
+			wallEnd: 2,
+			options: OctreePathOptions{Prune: true},
+			check: func(t *testing.T, path *OctreePath) {
+				if len(path.Points) < 3 {
+					t.Fatalf("got %d points, the wall is in the way", len(path.Points))
+				}
+				for _, p := range path.Points[1 : len(path.Points)-1] {
+					if p.X < 2 && p.Z > 0 && p.Z < 0.6 {
+						t.Fatalf("waypoint %v goes through the wall", p)
+					}
+				}
+			},
+		},
+		{
+			name:    "blocked",
+			wallEnd: 5,
+			partial: true,
+			check: func(t *testing.T, path *OctreePath) {
+				for _, p := range path.Points {
+					if p.Z > 0.3 {
+						t.Fatalf("waypoint %v is past the wall", p)
+					}
+				}
+			},
+		},
+		{
+			name:    "partial",
+			wallEnd: -5,
+			options: OctreePathOptions{MaxIterations: 3},
+			partial: true,
+			check: func(t *testing.T, path *OctreePath) {
+				if path.Stats.Expansions != 3 {
+					t.Fatalf("got %d expansions, want 3", path.Stats.Expansions)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, id := pathTestOctree(t, test.wallEnd)
+			path, err := m.FindPathWithOptions(id, start, end, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path.Partial != test.partial {
+				t.Fatalf("got partial %v, want %v", path.Partial, test.partial)
+			}
+			if path.Points[0] != start {
+				t.Fatalf("path starts at %v, want %v", path.Points[0], start)
+			}
+			if last := path.Points[len(path.Points)-1]; (last == end) == test.partial {
+				t.Fatalf("path ends at %v, partial %v", last, test.partial)
+			}
+			test.check(t, path)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/octree"
+	"github.com/o0olele/octree-go/query"
+)
+
+// OctreeDefaultProfile names the agent an octree item was built with.
+const OctreeDefaultProfile = "default"
+
+// octreeProfile is the per agent part of an octree item: the path graph
+// and the voxel grid padded by the agent size.
+type octreeProfile struct {
+	agent      *octree.Agent
+	agentParam AgentParam
+	navData    *builder.NavigationData
+	edges      []builder.CompactEdge // the graph before pruning
+	query      *query.NavigationQuery
+}
+
+// OctreeProfileInfo describes an agent profile of an octree item.
+type OctreeProfileInfo struct {
+	Name  string     `json:"name"`
+	Agent AgentParam `json:"agent"`
+}
+
+// profile returns the named agent profile, an empty name is the default one.
+func (item *OctreeItem) profile(name string) (*octreeProfile, error) {
+	if name == "" || name == OctreeDefaultProfile {
+		return &octreeProfile{
+			agent:      item.agent,
+			agentParam: item.agentParam,
+			navData:    item.navData,
+			edges:      item.edges,
+			query:      item.query,
+		}, nil
+	}
+	profile, ok := item.profiles[name]
+	if !ok {
+		return nil, errors.New("octree agent profile not found")
+	}
+	return profile, nil
+}
+
+// octreeFreeLeaves lists the free leaves in the order octree-go numbers the
+// navigation nodes.
+func octreeFreeLeaves(node *octree.OctreeNode, leaves []*octree.OctreeNode) []*octree.OctreeNode {
+	if node.IsLeaf() {
+		if !node.IsOccupied() {
+			leaves = append(leaves, node)
+		}
+		return leaves
+	}
+	for _, child := range node.Children {
+		if child != nil {
+			leaves = octreeFreeLeaves(child, leaves)
+		}
+	}
+	return leaves
+}
+
+// buildProfile computes the path graph, voxel grid and navigation data for
+// another agent on the already subdivided octree of the item, the same way
+// the build does for the default agent.
+func (item *OctreeItem) buildProfile(param AgentParam) (*octreeProfile, error) {
+	if param.Radius <= 0 || param.Height <= 0 {
+		return nil, errors.New("agent radius and height must be positive")
+	}
+
+	agent := octree.NewAgent(param.Radius, param.Height)
+	var timer octreeTimer
+	navData, edges, err := buildOctreeNavData(item.octree, item.param, agent, func(string) bool { return true }, &timer)
+	if err != nil {
+		return nil, err
+	}
+	query, err := query.NewNavigationQuery(navData)
+	if err != nil {
+		return nil, err
+	}
+	query.SetAgent(agent)
+
+	return &octreeProfile{
+		agent:      agent,
+		agentParam: param,
+		navData:    navData,
+		edges:      edges,
+		query:      query,
+	}, nil
+}
+
+// AddProfile adds a named agent profile to an octree item. Profiles are not
+// saved by Save, add them again after Load.
+func (m *OctreeMgr) AddProfile(id, name string, param AgentParam) error {
+	if name == "" || name == OctreeDefaultProfile {
+		return errors.New("invalid octree agent profile name")
+	}
+
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	if _, exists := item.profiles[name]; exists {
+		return errors.New("octree agent profile already exists")
+	}
+
+	// obstacle updates change the octree, so the profile is built under the
+	// write lock of the item
+	profile, err := item.buildProfile(param)
+	if err != nil {
+		return err
+	}
+	if item.profiles == nil {
+		item.profiles = make(map[string]*octreeProfile)
+	}
+	item.profiles[name] = profile
+	return nil
+}
+
+func (m *OctreeMgr) RemoveProfile(id, name string) error {
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+
+	if _, ok := item.profiles[name]; !ok {
+		return errors.New("octree agent profile not found")
+	}
+	delete(item.profiles, name)
+	return nil
+}
+
+// GetProfiles lists the agent profiles of an item, the default one first.
+func (m *OctreeMgr) GetProfiles(id string) ([]OctreeProfileInfo, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	profiles := []OctreeProfileInfo{{Name: OctreeDefaultProfile, Agent: item.agentParam}}
+	var names []string
+	for name := range item.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profiles = append(profiles, OctreeProfileInfo{Name: name, Agent: item.profiles[name].agentParam})
+	}
+	return profiles, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// octreeMortonWindow is how many nodes on each side of the Morton position
+// octree-go compares when looking for the closest node.
+const octreeMortonWindow = 8
+
+// closestPointInAABB clamps pos into the box.
+func closestPointInAABB(aabb geometry.AABB, pos math32.Vector3) math32.Vector3 {
+	return math32.Vector3{
+		X: math32.Max(aabb.Min.X, math32.Min(aabb.Max.X, pos.X)),
+		Y: math32.Max(aabb.Min.Y, math32.Min(aabb.Max.Y, pos.Y)),
+		Z: math32.Max(aabb.Min.Z, math32.Min(aabb.Max.Z, pos.Z)),
+	}
+}
+
+// octreeClosestNode finds the same node as FindClosestNodeMorton without its
+// LRU cache, the cache reorders entries under a read lock so it is not safe
+// for concurrent queries. Small graphs, where octree-go gives up, are
+// searched by brute force.
+func octreeClosestNode(navData *builder.NavigationData, pos math32.Vector3) int32 {
+	index := navData.MortonIndex
+	if len(index)/4 < octreeMortonWindow {
+		return navData.FindClosestNodeBruteForce(pos)
+	}
+
+	morton := func(p math32.Vector3) octree.MortonCode {
+		return octree.Vector3ToMorton(p, navData.Bounds, navData.MortonResolution)
+	}
+	queryMorton := morton(pos)
+	target := sort.Search(len(index), func(i int) bool {
+		return morton(navData.Nodes[index[i]].Center) >= queryMorton
+	})
+
+	best, bestDistance := int32(-1), math32.MaxFloat32
+	for i := max(target-octreeMortonWindow, 0); i <= min(target+octreeMortonWindow, len(index)-1); i++ {
+		if d := pos.Distance(navData.Nodes[index[i]].Center); d < bestDistance {
+			best, bestDistance = index[i], d
+		}
+	}
+	return best
+}
+
+// findContainingNode returns the free node containing pos, or -1.
+func (item *OctreeItem) findContainingNode(pos math32.Vector3) int32 {
+	navData := item.query.GetNavigationData()
+	nodeID := octreeClosestNode(navData, pos)
+	if nodeID < 0 || !navData.Nodes[nodeID].Bounds.Contains(pos) {
+		return -1
+	}
+	return nodeID
+}
+
+// Raycast casts a ray from start to end against the geometry of the octree,
+// Visited holds the free nodes the ray passes before the hit.
+func (m *OctreeMgr) Raycast(id string, start, end Vec3) (*RaycastResult, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	origin := math32.Vector3(start)
+	direction := math32.Vector3(end).Sub(origin)
+	distance := direction.Length()
+	if !(distance < math32.MaxFloat32) {
+		return nil, errors.New("ray start and end must be finite")
+	}
+
+	result := &RaycastResult{Position: end}
+	hit, t, point, tri := item.octree.Raycast(origin, direction, distance)
+	if hit {
+		result.Hit = true
+		result.Position = Vec3(point)
+		result.Normal = Vec3(tri.GetNormal())
+		distance = t
+	}
+
+	// sample the part of the ray inside the octree with half of the smallest
+	// node size to collect the nodes
+	if distance <= 0 {
+		return result, nil
+	}
+	dir := direction.Normalize()
+	enter, exit, ok := geometry.RayAABB(origin, dir, item.octree.Root.Bounds)
+	enter, exit = math32.Max(enter, 0), math32.Min(exit, distance)
+	if ok && enter <= exit {
+		step := math32.Max(item.octree.MinSize*0.5, 0.01)
+		steps := math32.CeilToInt((exit - enter) / step)
+		last := int32(-1)
+		for i := 0; i <= steps; i++ {
+			sample := origin.Add(dir.Scale(math32.Min(enter+float32(i)*step, exit)))
+			nodeID := item.findContainingNode(sample)
+			if nodeID >= 0 && nodeID != last {
+				result.Visited = append(result.Visited, uint64(nodeID))
+				last = nodeID
+			}
+		}
+	}
+	return result, nil
+}
+
+// FindNearestPoint finds the closest point inside a free node within the
+// search box pos +- extents.
+func (m *OctreeMgr) FindNearestPoint(id string, pos, extents Vec3) (*NearestPoint, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	p := math32.Vector3(pos)
+	navData := item.query.GetNavigationData()
+	nodeID := octreeClosestNode(navData, p)
+	if nodeID < 0 {
+		return &NearestPoint{Position: pos}, nil
+	}
+
+	nearest := closestPointInAABB(navData.Nodes[nodeID].Bounds, p)
+	diff := nearest.Sub(p)
+	if math32.Abs(diff.X) > extents.X || math32.Abs(diff.Y) > extents.Y || math32.Abs(diff.Z) > extents.Z {
+		return &NearestPoint{Position: pos}, nil
+	}
+	return &NearestPoint{Found: true, Position: Vec3(nearest), Ref: uint64(nodeID)}, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestOctreeRaycast checks that only the part of a ray inside the octree is
+// sampled for the visited nodes.
+func TestOctreeRaycast(t *testing.T) {
+	m, id := pathTestOctree(t, -5)
+	inside, err := m.Raycast(id, Vec3{X: -5, Y: 0.6, Z: -3}, Vec3{X: 5, Y: 0.6, Z: -3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inside.Hit || len(inside.Visited) == 0 {
+		t.Fatalf("got %+v, want a ray through free nodes", inside)
+	}
+
+	tests := []struct {
+		name       string
+		start, end Vec3
+		visited    []uint64
+		fails      bool
+	}{
+		{name: "far outside", start: Vec3{X: -1e4, Y: 0.6, Z: -3}, end: Vec3{X: 1e4, Y: 0.6, Z: -3}, visited: inside.Visited},
+		{name: "missing the octree", start: Vec3{X: -1e4, Y: 10, Z: -3}, end: Vec3{X: 1e4, Y: 10, Z: -3}},
+		{name: "infinite", start: Vec3{X: -5, Y: 0.6, Z: -3}, end: Vec3{X: float32(math.Inf(1)), Y: 0.6, Z: -3}, fails: true},
+		{name: "nan", start: Vec3{X: -5, Y: 0.6, Z: -3}, end: Vec3{X: float32(math.NaN()), Y: 0.6, Z: -3}, fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := m.Raycast(id, test.start, test.end)
+			if test.fails {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Hit || !reflect.DeepEqual(result.Visited, test.visited) {
+				t.Fatalf("got visited %v, want %v", result.Visited, test.visited)
+			}
+		})
+	}
+}
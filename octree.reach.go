@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/o0olele/octree-go/math32"
+)
+
+// CheckReachability finds which points reach each other through the free
+// space of an agent profile. With a seed point it also lists the islands of
+// free space the seed can not reach.
+func (m *OctreeMgr) CheckReachability(id, profileName string, points []ReachPoint, seed string) (*ReachabilityReport, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	profile, err := item.profile(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	report, seedIndex, err := newReachabilityReport(points, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	navData := profile.navData
+	roots := octreeComponentRoots(navData)
+	nodes := make([]int32, len(points))
+	snapped := make([]bool, len(points))
+	for i, point := range points {
+		nodes[i] = octreeClosestNode(navData, math32.Vector3(point.Position))
+		snapped[i] = nodes[i] >= 0
+	}
+
+	// the components answer most pairs without a search, the search is not
+	// limited so it only fails when the graph disconnects the points
+	options := OctreePathOptions{
+		Profile:       profileName,
+		Prune:         true,
+		MaxIterations: len(navData.Nodes) + 1,
+	}
+	search := newOctreeSearch()
+	report.fill(snapped, func(from, to int) (float32, bool) {
+		if roots[nodes[from]] != roots[nodes[to]] {
+			return 0, false
+		}
+		path, err := item.findPath(search, math32.Vector3(points[from].Position), math32.Vector3(points[to].Position), options)
+		if err != nil || path.Partial {
+			return 0, false
+		}
+		return path.Stats.Length, true
+	})
+
+	if seedIndex >= 0 {
+		skip := int32(-1)
+		if snapped[seedIndex] {
+			skip = roots[nodes[seedIndex]]
+		}
+		var islands []ReachIsland
+		for _, component := range octreeComponents(navData, roots, skip) {
+			islands = append(islands, ReachIsland{
+				Size:   component.Nodes,
+				Volume: component.Volume,
+				Bounds: component.Bounds,
+			})
+		}
+		report.setIslands(islands)
+	}
+	return report, nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"runtime"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/octree"
+)
+
+// Timed phases of items that are not built by a build job.
+const (
+	OctreePhaseLoad   = "load"   // reading a saved octree, the octree itself is timed as subdivide
+	OctreePhaseUpdate = "update" // the last obstacle update
+)
+
+// octreeReportComponents is how many of the largest components a report
+// lists.
+const octreeReportComponents = 32
+
+// OctreePhaseTime is the time spent in one phase of the build.
+type OctreePhaseTime struct {
+	Phase string  `json:"phase"`
+	Time  float64 `json:"time"` // milliseconds
+}
+
+// OctreeDepthReport counts the nodes at one depth.
+type OctreeDepthReport struct {
+	Depth          uint8 `json:"depth"`
+	Nodes          int   `json:"nodes"`
+	OccupiedLeaves int   `json:"occupied_leaves"`
+	FreeLeaves     int   `json:"free_leaves"`
+}
+
+// OctreeTriangleReport counts the triangles that are not fully inside the
+// octree bounds, octree-go drops the ones outside.
+type OctreeTriangleReport struct {
+	Total    int `json:"total"`
+	Outside  int `json:"outside"`
+	Crossing int `json:"crossing"` // partly outside
+}
+
+// OctreeComponentReport is a set of free leaves connected by the path graph.
+type OctreeComponentReport struct {
+	Nodes  int     `json:"nodes"`
+	Volume float32 `json:"volume"`
+	Bounds Bounds  `json:"bounds"`
+}
+
+// OctreeProfileReport describes the path graph of an agent profile.
+type OctreeProfileReport struct {
+	Name       string                  `json:"name"`
+	Agent      AgentParam              `json:"agent"`
+	Edges      int                     `json:"edges"`
+	Components int                     `json:"components"`
+	Largest    []OctreeComponentReport `json:"largest"` // largest components first
+}
+
+// OctreeMemoryReport is the estimated size of an item in bytes, HeapAlloc is
+// the heap of the whole process.
+type OctreeMemoryReport struct {
+	Octree    uint64 `json:"octree"`
+	Triangles uint64 `json:"triangles"`
+	NavData   uint64 `json:"nav_data"` // all agent profiles
+	Total     uint64 `json:"total"`
+	HeapAlloc uint64 `json:"heap_alloc"`
+}
+
+// OctreeReport helps to tell why a path is not found: bounds too small,
+// MinSize too coarse or an agent too large for a corridor.
+type OctreeReport struct {
+	Id             string                `json:"id"`
+	Nodes          int                   `json:"nodes"`
+	Depths         []OctreeDepthReport   `json:"depths"`
+	OccupiedVolume float32               `json:"occupied_volume"`
+	FreeVolume     float32               `json:"free_volume"`
+	Triangles      OctreeTriangleReport  `json:"triangles"`
+	Profiles       []OctreeProfileReport `json:"profiles"` // the default profile first
+	Phases         []OctreePhaseTime     `json:"phases"`
+	Memory         OctreeMemoryReport    `json:"memory"`
+}
+
+// octreeTimer records the time of consecutive phases.
+type octreeTimer struct {
+	phases []OctreePhaseTime
+	phase  string
+	start  time.Time
+}
+
+func (t *octreeTimer) begin(phase string) {
+	t.end()
+	t.phase, t.start = phase, time.Now()
+}
+
+func (t *octreeTimer) end() {
+	if t.phase == "" {
+		return
+	}
+	t.phases = append(t.phases, OctreePhaseTime{
+		Phase: t.phase,
+		Time:  float64(time.Since(t.start).Microseconds()) / 1000,
+	})
+	t.phase = ""
+}
+
+// setPhaseTime replaces the time of phase or adds it.
+func (item *OctreeItem) setPhaseTime(phase string, d time.Duration) {
+	timing := OctreePhaseTime{Phase: phase, Time: float64(d.Microseconds()) / 1000}
+	for i := range item.phases {
+		if item.phases[i].Phase == phase {
+			item.phases[i] = timing
+			return
+		}
+	}
+	item.phases = append(item.phases, timing)
+}
+
+func aabbVolume(aabb geometry.AABB) float32 {
+	size := aabb.Size()
+	return size.X * size.Y * size.Z
+}
+
+// reportNodes walks the octree counting nodes per depth and leaf volumes,
+// it returns the octree memory.
+func (r *OctreeReport) reportNodes(node *octree.OctreeNode) uint64 {
+	if node == nil {
+		return 0
+	}
+	for len(r.Depths) <= int(node.Depth) {
+		r.Depths = append(r.Depths, OctreeDepthReport{Depth: uint8(len(r.Depths))})
+	}
+	depth := &r.Depths[node.Depth]
+	depth.Nodes++
+	r.Nodes++
+
+	size := uint64(unsafe.Sizeof(*node)) + uint64(cap(node.Triangles))*uint64(unsafe.Sizeof(node))
+	if node.IsLeaf() {
+		if node.IsOccupied() {
+			depth.OccupiedLeaves++
+			r.OccupiedVolume += aabbVolume(node.Bounds)
+		} else {
+			depth.FreeLeaves++
+			r.FreeVolume += aabbVolume(node.Bounds)
+		}
+		return size
+	}
+	for _, child := range node.Children {
+		size += r.reportNodes(child)
+	}
+	return size
+}
+
+// octreeComponentRoots labels every node of navData with a representative
+// node of its connected component.
+func octreeComponentRoots(navData *builder.NavigationData) []int32 {
+	parent := make([]int32, len(navData.Nodes))
+	for i := range parent {
+		parent[i] = int32(i)
+	}
+	find := func(i int32) int32 {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for _, edge := range navData.Edges {
+		a, b := find(edge.NodeAID), find(edge.NodeBID)
+		if a != b {
+			parent[a] = b
+		}
+	}
+	for i := range parent {
+		parent[i] = find(int32(i))
+	}
+	return parent
+}
+
+// octreeComponents groups the nodes of navData by their component roots,
+// leaving out the component of skip unless it is -1.
+func octreeComponents(navData *builder.NavigationData, roots []int32, skip int32) []OctreeComponentReport {
+	index := make(map[int32]int)
+	var components []OctreeComponentReport
+	var bounds []geometry.AABB
+	for i, node := range navData.Nodes {
+		root := roots[i]
+		if root == skip {
+			continue
+		}
+		c, ok := index[root]
+		if !ok {
+			c = len(components)
+			index[root] = c
+			components = append(components, OctreeComponentReport{})
+			bounds = append(bounds, node.Bounds)
+		}
+		components[c].Nodes++
+		components[c].Volume += aabbVolume(node.Bounds)
+		bounds[c] = aabbUnion(bounds[c], node.Bounds)
+	}
+	for c := range components {
+		components[c].Bounds = Bounds{Min: Vec3(bounds[c].Min), Max: Vec3(bounds[c].Max)}
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].Volume > components[j].Volume
+	})
+	return components
+}
+
+func reportProfile(name string, profile *octreeProfile) OctreeProfileReport {
+	components := octreeComponents(profile.navData, octreeComponentRoots(profile.navData), -1)
+	report := OctreeProfileReport{
+		Name:       name,
+		Agent:      profile.agentParam,
+		Edges:      len(profile.navData.Edges),
+		Components: len(components),
+		Largest:    components,
+	}
+	if len(components) > octreeReportComponents {
+		report.Largest = components[:octreeReportComponents]
+	}
+	return report
+}
+
+// GetReport collects the build diagnostics of an item.
+func (m *OctreeMgr) GetReport(id string) (*OctreeReport, error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+
+	report := &OctreeReport{
+		Id:     id,
+		Phases: append([]OctreePhaseTime(nil), item.phases...),
+	}
+	report.Memory.Octree = report.reportNodes(item.octree.Root)
+
+	bounds := item.octree.Root.Bounds
+	triangles := item.octree.GetTriangles()
+	report.Triangles.Total = len(triangles)
+	for i := range triangles {
+		tri := &triangles[i]
+		if !tri.IntersectsAABB(bounds) {
+			report.Triangles.Outside++
+		} else if !bounds.Contains(tri.A) || !bounds.Contains(tri.B) || !bounds.Contains(tri.C) {
+			report.Triangles.Crossing++
+		}
+	}
+	report.Memory.Triangles = uint64(cap(triangles)) * uint64(unsafe.Sizeof(geometry.Triangle{}))
+
+	defaultProfile, _ := item.profile(OctreeDefaultProfile)
+	report.Profiles = append(report.Profiles, reportProfile(OctreeDefaultProfile, defaultProfile))
+	report.Memory.NavData = uint64(item.navData.GetDataSize())
+	var names []string
+	for name := range item.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := item.profiles[name]
+		report.Profiles = append(report.Profiles, reportProfile(name, profile))
+		report.Memory.NavData += uint64(profile.navData.GetDataSize())
+	}
+
+	report.Memory.Total = report.Memory.Octree + report.Memory.Triangles + report.Memory.NavData
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	report.Memory.HeapAlloc = stats.HeapAlloc
+	return report, nil
+}
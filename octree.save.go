@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/o0olele/octree-go/builder"
+	"github.com/o0olele/octree-go/geometry"
+	"github.com/o0olele/octree-go/math32"
+	"github.com/o0olele/octree-go/octree"
+	"github.com/o0olele/octree-go/query"
+)
+
+// Octree file layout: OctreeFileHeader, the source triangles, the size of
+// the navigation data and the navigation data in the octree-go format.
+const (
+	octreeFileMagic   = 0x544f4257 // "WBOT"
+	octreeFileVersion = 1
+)
+
+// octreeMaxNavDataSize caps the decompressed navigation data of a file.
+var octreeMaxNavDataSize = 1 << 30
+
+// OctreeFileHeader is the header of a saved octree, it holds everything
+// needed to tell whether the file matches a build request.
+type OctreeFileHeader struct {
+	Magic         uint32
+	Version       uint32
+	Octree        OctreeParam
+	Agent         AgentParam
+	GeometryHash  [32]byte // sha256 of the source triangles
+	TriangleCount uint32
+}
+
+// GeometryHash hashes the triangles the way they are stored in a saved octree.
+func GeometryHash(triangles []Triangle) [32]byte {
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, triangles)
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Save writes the built navigation data of the item to filename, the agent
+// profiles added with AddProfile are not saved. Items with obstacles are
+// refused, their navigation data does not match the build geometry.
+func (m *OctreeMgr) Save(id, filename string) (err error) {
+	item, err := m.getItem(id)
+	if err != nil {
+		return err
+	}
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+	if len(item.obstacles) > 0 {
+		return errors.New("octree has obstacles, remove them before saving")
+	}
+
+	source := item.baseTriangles
+	if source == nil {
+		source = item.octree.GetTriangles()
+	}
+	var triangles []Triangle
+	for _, tri := range source {
+		triangles = append(triangles, Triangle{A: Vec3(tri.A), B: Vec3(tri.B), C: Vec3(tri.C)})
+	}
+
+	navData, err := saveNavData(item.navData)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+	header := &OctreeFileHeader{
+		Magic:         octreeFileMagic,
+		Version:       octreeFileVersion,
+		Octree:        item.param,
+		Agent:         item.agentParam,
+		GeometryHash:  GeometryHash(triangles),
+		TriangleCount: uint32(len(triangles)),
+	}
+	if err = binary.Write(w, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("failed to write header: %v", err)
+	}
+	if err = binary.Write(w, binary.LittleEndian, triangles); err != nil {
+		return fmt.Errorf("failed to write triangles: %v", err)
+	}
+	if err = binary.Write(w, binary.LittleEndian, uint32(len(navData))); err != nil {
+		return fmt.Errorf("failed to write navigation data size: %v", err)
+	}
+	if _, err = w.Write(navData); err != nil {
+		return fmt.Errorf("failed to write navigation data: %v", err)
+	}
+	return w.Flush()
+}
+
+// LoadOctreeHeader reads only the header of a saved octree.
+func LoadOctreeHeader(filename string) (*OctreeFileHeader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readOctreeHeader(file)
+}
+
+func readOctreeHeader(r io.Reader) (*OctreeFileHeader, error) {
+	header := &OctreeFileHeader{}
+	if err := binary.Read(r, binary.LittleEndian, header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	if header.Magic != octreeFileMagic {
+		return nil, errors.New("invalid octree file")
+	}
+	if header.Version != octreeFileVersion {
+		return nil, fmt.Errorf("unsupported octree file version: %d", header.Version)
+	}
+	return header, nil
+}
+
+// Load adds an item from a file written by Save without building it again.
+func (m *OctreeMgr) Load(id, filename string) error {
+	if m.Exist(id) {
+		return errors.New("octree already exists")
+	}
+
+	var timer octreeTimer
+	timer.begin(OctreePhaseLoad)
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewReader(content)
+
+	header, err := readOctreeHeader(buf)
+	if err != nil {
+		return err
+	}
+
+	// the count comes from the file, it must fit in what is left of it
+	if uint64(header.TriangleCount)*uint64(binary.Size(Triangle{})) > uint64(buf.Len()) {
+		return errors.New("octree file is truncated")
+	}
+	triangles := make([]Triangle, header.TriangleCount)
+	if err = binary.Read(buf, binary.LittleEndian, triangles); err != nil {
+		return fmt.Errorf("failed to read triangles: %v", err)
+	}
+	if GeometryHash(triangles) != header.GeometryHash {
+		return errors.New("octree file geometry hash mismatch")
+	}
+
+	var size uint32
+	if err = binary.Read(buf, binary.LittleEndian, &size); err != nil {
+		return fmt.Errorf("failed to read navigation data size: %v", err)
+	}
+	if int64(size) != int64(buf.Len()) {
+		return errors.New("octree file is truncated")
+	}
+	navData, err := loadNavData(content[len(content)-int(size):])
+	if err != nil {
+		return err
+	}
+
+	// the octree itself is cheap to rebuild compared to the path graph, it
+	// is needed for raycasts and the octree view
+	timer.begin(OctreeBuildPhaseSubdivide)
+	param := header.Octree
+	tree := octree.NewOctree(geometry.AABB{Min: math32.Vector3(param.Bounds.Min), Max: math32.Vector3(param.Bounds.Max)}, param.MaxDepth, param.MinSize)
+	for _, tri := range triangles {
+		tree.AddTriangle(geometry.Triangle{A: math32.Vector3(tri.A), B: math32.Vector3(tri.B), C: math32.Vector3(tri.C)})
+	}
+	tree.Build()
+
+	timer.begin(OctreeBuildPhaseQuery)
+	agent := octree.NewAgent(header.Agent.Radius, header.Agent.Height)
+	query, err := query.NewNavigationQuery(navData)
+	if err != nil {
+		return err
+	}
+	query.SetAgent(agent)
+	timer.end()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.items[id]; ok {
+		return errors.New("octree already exists")
+	}
+	m.items[id] = &OctreeItem{
+		octree:     tree,
+		navData:    navData,
+		query:      query,
+		agent:      agent,
+		param:      header.Octree,
+		agentParam: header.Agent,
+		phases:     timer.phases,
+	}
+	return nil
+}
+
+// saveNavData encodes navData in the layout of builder.Save, always gzipped
+// whatever builder.UseGzip was set to, without going through a file.
+func saveNavData(navData *builder.NavigationData) ([]byte, error) {
+	if err := navData.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid navigation data: %v", err)
+	}
+	mortonIndex := make([]uint32, len(navData.MortonIndex))
+	for i, index := range navData.MortonIndex {
+		mortonIndex[i] = uint32(index)
+	}
+
+	var buf bytes.Buffer
+	for _, v := range []interface{}{
+		builder.FileHeader{Magic: builder.NAVIGATION_FILE_MAGIC, Version: builder.NAVIGATION_FILE_VERSION},
+		navData.Bounds,
+		navData.MaxDepth,
+		navData.MinSize,
+		navData.StepSize,
+		navData.GridSize,
+		navData.VoxelSize,
+		uint32(len(navData.Nodes)), navData.Nodes,
+		uint32(len(navData.Edges)), navData.Edges,
+		uint32(len(mortonIndex)), mortonIndex,
+		navData.MortonResolution,
+		uint32(len(navData.GeometryData)), navData.GeometryData,
+		uint32(len(navData.VoxelData)), navData.VoxelData,
+	} {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("failed to encode navigation data: %v", err)
+		}
+	}
+	return builder.Compress(buf.Bytes()), nil
+}
+
+// loadNavData decodes data written by saveNavData. The lookup caches are
+// built by query.NewNavigationQuery, the octree-go LRU cache of builder.Load
+// is left out since the queries find their nodes with octreeClosestNode.
+func loadNavData(data []byte) (*builder.NavigationData, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress navigation data: %v", err)
+	}
+	content, err := io.ReadAll(io.LimitReader(gz, int64(octreeMaxNavDataSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress navigation data: %v", err)
+	}
+	if len(content) > octreeMaxNavDataSize {
+		return nil, errors.New("navigation data is too large")
+	}
+	r := bytes.NewReader(content)
+
+	var header builder.FileHeader
+	if err = binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("failed to read navigation data header: %v", err)
+	}
+	if header.Magic != builder.NAVIGATION_FILE_MAGIC {
+		return nil, errors.New("invalid navigation data")
+	}
+	if header.Version != builder.NAVIGATION_FILE_VERSION {
+		return nil, fmt.Errorf("unsupported navigation data version: %d", header.Version)
+	}
+
+	navData := &builder.NavigationData{}
+	for _, v := range []interface{}{
+		&navData.Bounds,
+		&navData.MaxDepth,
+		&navData.MinSize,
+		&navData.StepSize,
+		&navData.GridSize,
+		&navData.VoxelSize,
+	} {
+		if err = binary.Read(r, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("failed to read navigation data: %v", err)
+		}
+	}
+	if navData.Nodes, err = readNavSlice[builder.CompactNode](r); err != nil {
+		return nil, err
+	}
+	if navData.Edges, err = readNavSlice[builder.CompactEdge](r); err != nil {
+		return nil, err
+	}
+	if navData.MortonIndex, err = readNavSlice[int32](r); err != nil {
+		return nil, err
+	}
+	if err = binary.Read(r, binary.LittleEndian, &navData.MortonResolution); err != nil {
+		return nil, fmt.Errorf("failed to read navigation data: %v", err)
+	}
+	if navData.GeometryData, err = readNavSlice[geometry.Triangle](r); err != nil {
+		return nil, err
+	}
+	if navData.VoxelData, err = readNavSlice[uint64](r); err != nil {
+		return nil, err
+	}
+	return navData, nil
+}
+
+// readNavSlice reads a count and that many values of the navigation data,
+// the count comes from the file and must fit in what is left of r.
+func readNavSlice[T any](r *bytes.Reader) ([]T, error) {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read navigation data: %v", err)
+	}
+	var value T
+	if uint64(count)*uint64(binary.Size(value)) > uint64(r.Len()) {
+		return nil, errors.New("navigation data is truncated")
+	}
+	values := make([]T, count)
+	if err := binary.Read(r, binary.LittleEndian, values); err != nil {
+		return nil, fmt.Errorf("failed to read navigation data: %v", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/o0olele/octree-go/builder"
+)
+
+// saveTestOctree builds a small floor and saves it, returning the manager,
+// the item id and the file.
+func saveTestOctree(t *testing.T) (*OctreeMgr, string, string) {
+	t.Helper()
+	floor := []Triangle{
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: -4, Z: 4}, C: Vec3{X: 4, Z: 4}},
+		{A: Vec3{X: -4, Z: -4}, B: Vec3{X: 4, Z: 4}, C: Vec3{X: 4, Z: -4}},
+	}
+	param := OctreeParam{
+		Bounds:   Bounds{Min: Vec3{X: -5, Y: -1, Z: -5}, Max: Vec3{X: 5, Y: 3, Z: 5}},
+		MaxDepth: 4,
+		MinSize:  0.5,
+		StepSize: 0.5,
+	}
+	m := NewOctreeMgr()
+	if err := m.Add("floor", param, AgentParam{Radius: 0.3, Height: 1}, floor); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "floor.octree")
+	if err := m.Save("floor", filename); err != nil {
+		t.Fatal(err)
+	}
+	return m, "floor", filename
+}
+
+func TestOctreeSaveLoad(t *testing.T) {
+	m, id, filename := saveTestOctree(t)
+	if err := m.Load("loaded", filename); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, _ := m.getItem(id)
+	loaded, _ := m.getItem("loaded")
+	if loaded.param != saved.param || loaded.agentParam != saved.agentParam {
+		t.Fatalf("got params %+v %+v, want %+v %+v", loaded.param, loaded.agentParam, saved.param, saved.agentParam)
+	}
+	if !reflect.DeepEqual(loaded.navData.Nodes, saved.navData.Nodes) {
+		t.Fatal("nodes differ after load")
+	}
+	if !reflect.DeepEqual(loaded.navData.Edges, saved.navData.Edges) {
+		t.Fatal("edges differ after load")
+	}
+	if !reflect.DeepEqual(loaded.navData.MortonIndex, saved.navData.MortonIndex) {
+		t.Fatal("morton index differs after load")
+	}
+	if got, want := len(loaded.octree.GetTriangles()), len(saved.octree.GetTriangles()); got != want {
+		t.Fatalf("got %d triangles, want %d", got, want)
+	}
+
+	start, end := Vec3{X: -3, Y: 0.6, Z: -3}, Vec3{X: 3, Y: 0.6, Z: 3}
+	want := m.FindPath(id, "", start, end)
+	if len(want) == 0 {
+		t.Fatal("no path on the built octree")
+	}
+	if got := m.FindPath("loaded", "", start, end); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got path %v, want %v", got, want)
+	}
+
+	header, err := LoadOctreeHeader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.TriangleCount != 2 || header.Octree != saved.param || header.Agent != saved.agentParam {
+		t.Fatalf("got header %+v", header)
+	}
+}
+
+func TestOctreeSaveObstacles(t *testing.T) {
+	m, id, filename := saveTestOctree(t)
+	want, err := LoadOctreeHeader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	box := Bounds{Min: Vec3{X: -1, Z: -1}, Max: Vec3{X: 1, Y: 1, Z: 1}}
+	if err = m.SetObstacle(id, "box", OctreeObstacle{Box: &box}); err != nil {
+		t.Fatal(err)
+	}
+	withBox := filepath.Join(t.TempDir(), "box.octree")
+	if err = m.Save(id, withBox); err == nil || !strings.Contains(err.Error(), "obstacles") {
+		t.Fatalf("got error %v saving with an obstacle", err)
+	}
+
+	// the geometry is the build geometry again once the obstacle is gone
+	if err = m.RemoveObstacle(id, "box"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Save(id, withBox); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadOctreeHeader(withBox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Fatalf("got header %+v, want %+v", got, want)
+	}
+	if err = m.Load("loaded", withBox); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOctreeLoadCorrupt(t *testing.T) {
+	m, _, filename := saveTestOctree(t)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headerSize := binary.Size(OctreeFileHeader{})
+	navStart := headerSize + 2*binary.Size(Triangle{}) + 4
+
+	tests := []struct {
+		name   string
+		change func(data []byte) []byte
+		err    string
+	}{
+		{name: "empty", change: func(data []byte) []byte { return nil }, err: "failed to read header"},
+		{name: "truncated header", change: func(data []byte) []byte { return data[:headerSize/2] }, err: "failed to read header"},
+		{name: "bad magic", change: func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data, 0x12345678)
+			return data
+		}, err: "invalid octree file"},
+		{name: "bad version", change: func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[4:], octreeFileVersion+1)
+			return data
+		}, err: "unsupported octree file version"},
+		{name: "triangle count past the end", change: func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[headerSize-4:], 0xffffffff)
+			return data
+		}, err: "octree file is truncated"},
+		{name: "truncated triangles", change: func(data []byte) []byte { return data[:headerSize+10] }, err: "octree file is truncated"},
+		{name: "changed triangles", change: func(data []byte) []byte {
+			data[headerSize] ^= 0xff
+			return data
+		}, err: "octree file geometry hash mismatch"},
+		{name: "truncated navigation data", change: func(data []byte) []byte { return data[:len(data)-10] }, err: "octree file is truncated"},
+		{name: "navigation data size past the end", change: func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[navStart-4:], uint32(len(data)))
+			return data
+		}, err: "octree file is truncated"},
+		{name: "corrupt navigation data", change: func(data []byte) []byte {
+			for i := navStart; i < navStart+4; i++ {
+				data[i] ^= 0xff
+			}
+			return data
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			corrupt := filepath.Join(t.TempDir(), "corrupt.octree")
+			if err := os.WriteFile(corrupt, test.change(append([]byte(nil), data...)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := m.Load("corrupt", corrupt)
+			if err == nil {
+				t.Fatal("corrupt file loaded")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %q, want %q", err, test.err)
+			}
+			if m.Exist("corrupt") {
+				t.Fatal("corrupt file added an item")
+			}
+		})
+	}
+}
+
+func TestOctreeLoadNavData(t *testing.T) {
+	m, id, _ := saveTestOctree(t)
+	item, _ := m.getItem(id)
+	data, err := saveNavData(item.navData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saveNavData always gzips, so loading does not depend on the octree-go flag
+	builder.UseGzip(false)
+	defer builder.UseGzip(true)
+	navData, err := loadNavData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(navData.Nodes, item.navData.Nodes) || !reflect.DeepEqual(navData.Edges, item.navData.Edges) {
+		t.Fatal("navigation data differs after load")
+	}
+
+	content := builder.Decompress(data)
+	nodeCount := binary.Size(builder.FileHeader{})
+	for _, v := range []interface{}{item.navData.Bounds, item.navData.MaxDepth, item.navData.MinSize, item.navData.StepSize, item.navData.GridSize, item.navData.VoxelSize} {
+		nodeCount += binary.Size(v)
+	}
+	tests := []struct {
+		name   string
+		change func(content []byte) []byte
+		err    string
+	}{
+		{name: "not gzipped", change: func(content []byte) []byte { return content }, err: "failed to decompress navigation data"},
+		{name: "bad magic", change: func(content []byte) []byte {
+			content[0] ^= 0xff
+			return builder.Compress(content)
+		}, err: "invalid navigation data"},
+		{name: "node count past the end", change: func(content []byte) []byte {
+			binary.LittleEndian.PutUint32(content[nodeCount:], 0xffffffff)
+			return builder.Compress(content)
+		}, err: "navigation data is truncated"},
+		{name: "truncated", change: func(content []byte) []byte { return builder.Compress(content[:len(content)-3]) }, err: "navigation data is truncated"},
+		{name: "too large", change: func(content []byte) []byte {
+			return builder.Compress(append(content, make([]byte, len(content))...))
+		}, err: "navigation data is too large"},
+	}
+	// twice the valid data is past the cap
+	defer func(size int) { octreeMaxNavDataSize = size }(octreeMaxNavDataSize)
+	octreeMaxNavDataSize = len(content) * 3 / 2
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadNavData(test.change(append([]byte(nil), content...)))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
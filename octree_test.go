@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestOctreeFindPathConcurrent(t *testing.T) {
+	m, id, _ := saveTestOctree(t)
+	start, end := Vec3{X: -3, Y: 0.6, Z: -3}, Vec3{X: 3, Y: 0.6, Z: 3}
+	want := m.FindPath(id, "", start, end)
+	if len(want) < 2 || want[0] != start || want[len(want)-1] != end {
+		t.Fatalf("got path %v from %v to %v", want, start, end)
+	}
+
+	var wg sync.WaitGroup
+	paths := make([][]Vec3, 8)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i] = m.FindPath(id, "", start, end)
+		}(i)
+	}
+	wg.Wait()
+	for i, path := range paths {
+		if !reflect.DeepEqual(path, want) {
+			t.Fatalf("query %d got path %v, want %v", i, path, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+// reachMaxIslands is how many of the largest islands a report lists.
+const reachMaxIslands = 64
+
+// ReachPoint is a named position checked by a reachability query, e.g. a
+// spawn point or an objective.
+type ReachPoint struct {
+	Name     string `json:"name"`
+	Position Vec3   `json:"position"`
+}
+
+// ReachIsland is a connected region of free space or polygons that can not
+// be reached from the seed point.
+type ReachIsland struct {
+	Size   int     `json:"size"`             // polygons or octree nodes
+	Area   float32 `json:"area,omitempty"`   // navmesh surface area
+	Volume float32 `json:"volume,omitempty"` // octree free volume
+	Bounds Bounds  `json:"bounds"`
+}
+
+// ReachabilityReport is the reachability matrix of a set of points,
+// Reachable[i][j] tells whether Points[j] can be reached from Points[i].
+type ReachabilityReport struct {
+	Points       []string    `json:"points"`
+	Reachable    [][]bool    `json:"reachable"`
+	Lengths      [][]float32 `json:"lengths"` // path lengths, -1 when unreachable or unknown
+	AllReachable bool        `json:"all_reachable"`
+
+	// islands are only computed when a seed point is given
+	Seed        string        `json:"seed,omitempty"`
+	Unreachable int           `json:"unreachable"`  // polygons or nodes not reachable from the seed
+	IslandCount int           `json:"island_count"` // Islands lists at most 64 of them
+	Islands     []ReachIsland `json:"islands"`      // largest first
+}
+
+// newReachabilityReport checks the point names and finds the seed index, -1
+// when there is no seed.
+func newReachabilityReport(points []ReachPoint, seed string) (*ReachabilityReport, int, error) {
+	report := &ReachabilityReport{
+		Points:       make([]string, len(points)),
+		Reachable:    make([][]bool, len(points)),
+		Lengths:      make([][]float32, len(points)),
+		AllReachable: true,
+		Seed:         seed,
+	}
+
+	seedIndex := -1
+	names := make(map[string]bool, len(points))
+	for i, point := range points {
+		if point.Name == "" {
+			return nil, 0, errors.New("reach point without a name")
+		}
+		if names[point.Name] {
+			return nil, 0, errors.New("duplicate reach point name " + point.Name)
+		}
+		names[point.Name] = true
+		if point.Name == seed {
+			seedIndex = i
+		}
+
+		report.Points[i] = point.Name
+		report.Reachable[i] = make([]bool, len(points))
+		report.Lengths[i] = make([]float32, len(points))
+	}
+	if seed != "" && seedIndex < 0 {
+		return nil, 0, errors.New("seed is not one of the reach points")
+	}
+	return report, seedIndex, nil
+}
+
+// fill computes every entry of the matrix with path, which returns the path
+// length and whether the end was reached. Points that are not on the
+// navigation data reach nothing, not even themselves.
+func (r *ReachabilityReport) fill(snapped []bool, path func(from, to int) (float32, bool)) {
+	for i := range r.Reachable {
+		for j := range r.Reachable[i] {
+			length, ok := float32(0), snapped[i] && snapped[j]
+			if ok && i != j {
+				length, ok = path(i, j)
+			}
+			r.Reachable[i][j] = ok
+			r.Lengths[i][j] = length
+			if !ok {
+				r.Lengths[i][j] = -1
+				r.AllReachable = false
+			}
+		}
+	}
+}
+
+// setIslands sorts the islands by size and keeps the largest ones.
+func (r *ReachabilityReport) setIslands(islands []ReachIsland) {
+	sort.SliceStable(islands, func(i, j int) bool {
+		return islands[i].Area+islands[i].Volume > islands[j].Area+islands[j].Volume
+	})
+	r.Unreachable = 0
+	for _, island := range islands {
+		r.Unreachable += island.Size
+	}
+	r.IslandCount = len(islands)
+	if len(islands) > reachMaxIslands {
+		islands = islands[:reachMaxIslands]
+	}
+	r.Islands = islands
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FilePath is an App method argument naming a file. Over http it is taken
+// relative to the root of the server and must stay under it, without a root
+// the calls fail.
+type FilePath string
+
+// apiMethods are the App methods exposed over http. Every method lists its
+// string arguments which are not a FilePath, so a path passed as a plain
+// string shows up here, see TestApiMethodArgs.
+var apiMethods = map[string][]string{
+	"LoadNavMeshLocal":             {"id", "name", "navType"},
+	"LoadNavMesh":                  {"id", "name", "navType"},
+	"RemoveNavMesh":                {"id"},
+	"BuildNavMesh":                 {"id"},
+	"BuildNavMeshFromObj":          {"id"},
+	"ExportNavMesh":                {"id", "format"},
+	"GetNavOffMeshConnections":     {"id"},
+	"CanEditNavOffMeshConnections": {"id"},
+	"AddNavOffMeshConnection":      {"id"},
+	"MoveNavOffMeshConnection":     {"id"},
+	"RemoveNavOffMeshConnection":   {"id"},
+	"SetNavObstacle":               {"id", "obstacleId"},
+	"RemoveNavObstacle":            {"id", "obstacleId"},
+	"GetNavObstacles":              {"id"},
+	"DiffNavMesh":                  {"idA", "idB"},
+	"SaveNavMesh":                  {"id"},
+	"AddAgent":                     {"id"},
+	"UpdateAgents":                 {"id"},
+	"ClearAgent":                   {"id"},
+	"SetAgentTarget":               {"id"},
+	"TeleportAgent":                {"id"},
+	"FindPathNavMesh":              {"id"},
+	"FindPathNavMeshWithPreset":    {"id", "preset"},
+	"SetNavFilterPreset":           {"name"},
+	"RemoveNavFilterPreset":        {"name"},
+	"GetNavFilterPresets":          {},
+	"RaycastNavMesh":               {"id"},
+	"FindNearestPointNavMesh":      {"id"},
+	"GetNavMeshTiles":              {"id"},
+	"GetNavMeshPoly":               {"id"},
+	"CheckNavMeshReachability":     {"id", "seed"},
+	"SetAgentTargetById":           {"id"},
+	"TeleportAgentById":            {"id"},
+	"RemoveAgentById":              {"id"},
+	"SetAgentParamsById":           {"id"},
+	"SetAgentFilterById":           {"id", "preset"},
+	"PauseAgentById":               {"id"},
+	"StartNavSimulation":           {"id"},
+	"StopNavSimulation":            {"id"},
+	"SetNavSimulationParams":       {"id"},
+	"StartNavRecording":            {"id"},
+	"StopNavRecording":             {"id"},
+	"OpenNavReplay":                {"id"},
+	"SeekNavReplay":                {"id"},
+	"StepNavReplay":                {"id"},
+	"GetNavMeshInfo":               {"id"},
+	"AddOctreeItem":                {"id"},
+	"AddOctreeItemFromObj":         {"id"},
+	"GetOctreeData":                {"id"},
+	"ResetOctree":                  {"id"},
+	"ExistOctree":                  {"id"},
+	"FindPathOctree":               {"id", "profile"},
+	"AddOctreeProfile":             {"id", "name"},
+	"RemoveOctreeProfile":          {"id", "name"},
+	"GetOctreeProfiles":            {"id"},
+	"RaycastOctree":                {"id"},
+	"FindNearestPointOctree":       {"id"},
+	"SaveOctree":                   {"id"},
+	"LoadOctree":                   {"id"},
+	"StartOctreeBuild":             {"id"},
+	"StartOctreeBuildFromObj":      {"id"},
+	"CancelOctreeBuild":            {"jobId"},
+	"GetOctreeBuildStatus":         {"jobId"},
+	"ExportOctree":                 {"id", "format"},
+	"GetOctreeFlatData":            {"id"},
+	"GetOctreeFlatDataInBounds":    {"id"},
+	"FindPathOctreeWithOptions":    {"id"},
+	"SetOctreeObstacle":            {"id", "obstacleId"},
+	"RemoveOctreeObstacle":         {"id", "obstacleId"},
+	"MoveOctreeObstacle":           {"id", "obstacleId"},
+	"GetOctreeObstacles":           {"id"},
+	"GetOctreeReport":              {"id"},
+	"CheckOctreeReachability":      {"id", "profile", "seed"},
+	"BuildOctreeFlowField":         {"id"},
+	"RemoveOctreeFlowField":        {"id"},
+	"SampleOctreeFlowField":        {"id"},
+	"GetOctreeFlowFieldData":       {"id"},
+	"FindPathsOctree":              {"id"},
+}
+
+// apiExcluded are the App methods which are not exposed over http, they
+// control the server itself, need the Wails window or the physx world of
+// InitPhysx, which connects to any PVD host.
+var apiExcluded = map[string]bool{
+	"StartApiServer":              true,
+	"StopApiServer":               true,
+	"ApiServerStatus":             true,
+	"OpenFileDialog":              true,
+	"ProcessSelectedFiles":        true,
+	"InitPhysx":                   true,
+	"LoadPhysxXml":                true,
+	"LoadPhysxXmlString":          true,
+	"LoadAndCreateRigidKinematic": true,
+	"CreateRigidKinematic":        true,
+	"SetRigidKinematicPosition":   true,
+	"ReleasePhysx":                true,
+	"PhysxStep":                   true,
+}
+
+// apiMaxBody is the largest request body, it leaves room for the triangles
+// of a build and the bytes of a navmesh.
+const apiMaxBody = 256 << 20
+
+// ApiServer exposes the apiMethods of App over loopback http, so other
+// processes can drive the workbench with the same request/response types as
+// the frontend.
+//
+// Every method is called with POST /api/<Method> and a JSON array holding the
+// arguments in order, the reply is an ApiResponse. GET /api lists the methods.
+// Requests carry the token returned by Start as "Authorization: Bearer
+// <token>", and requests from browsers of other sites are rejected, since
+// the methods read and write files. The FilePath arguments must be under
+// the root directory given to Start.
+type ApiServer struct {
+	mutex   sync.Mutex
+	server  *http.Server
+	addr    string
+	token   string
+	root    string
+	methods map[string]reflect.Value
+}
+
+// ApiResponse is the reply of a method call.
+type ApiResponse struct {
+	Result interface{} `json:"result"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ApiMethod describes an exposed method.
+type ApiMethod struct {
+	Name    string   `json:"name"`
+	Args    []string `json:"args"`
+	Results []string `json:"results"`
+}
+
+var (
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	filePathType = reflect.TypeOf(FilePath(""))
+)
+
+func NewApiServer(app *App) *ApiServer {
+	s := &ApiServer{
+		methods: make(map[string]reflect.Value),
+	}
+
+	v := reflect.ValueOf(app)
+	for name := range apiMethods {
+		if method := v.MethodByName(name); method.IsValid() {
+			if n := apiValueResults(method.Type()); n > 1 {
+				panic(fmt.Sprintf("api method %s has %d results besides the error, at most one is returned", name, n))
+			}
+			s.methods[name] = method
+		}
+	}
+	return s
+}
+
+// apiValueResults counts the results of t which are not an error, the
+// ApiResponse holds only one.
+func apiValueResults(t reflect.Type) int {
+	n := 0
+	for i := 0; i < t.NumOut(); i++ {
+		if t.Out(i) != errorType {
+			n++
+		}
+	}
+	return n
+}
+
+// isLoopbackHost reports whether host, without port, names the loopback
+// interface.
+func isLoopbackHost(host string) bool {
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return host == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+// Start listens on addr, which must be a loopback address, and returns the
+// token the requests must carry. The methods reading or writing files only
+// reach the files under root, none when root is empty.
+func (s *ApiServer) Start(addr, root string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.server != nil {
+		return "", errors.New("api server already started")
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if !isLoopbackHost(host) {
+		return "", fmt.Errorf("api server only listens on loopback, got %s", host)
+	}
+	if root != "" {
+		if root, err = filepath.Abs(root); err == nil {
+			root, err = filepath.EvalSymlinks(root)
+		}
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("api root %s is not a directory", root)
+		}
+	}
+
+	secret := make([]byte, 16)
+	if _, err = rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api", s.handleList)
+	mux.HandleFunc("/api/", s.handleCall)
+
+	s.server = &http.Server{Handler: mux}
+	s.addr = listener.Addr().String()
+	s.token = hex.EncodeToString(secret)
+	s.root = root
+	go s.server.Serve(listener)
+	return s.token, nil
+}
+
+// Stop shuts the server down.
+func (s *ApiServer) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Shutdown(context.Background())
+	s.server = nil
+	s.addr = ""
+	s.token = ""
+	s.root = ""
+	return err
+}
+
+// Addr returns the listening address, empty when stopped.
+func (s *ApiServer) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.addr
+}
+
+func typeNames(n int, at func(int) reflect.Type) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = at(i).String()
+	}
+	return names
+}
+
+// authorize checks the token of r and rejects the requests a web page can
+// make: the Host must be loopback, which defeats DNS rebinding, and a
+// browser sending an Origin must be on a loopback page too.
+func (s *ApiServer) authorize(w http.ResponseWriter, r *http.Request) bool {
+	// the Host has no port for the default http port
+	host := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		host = h
+	}
+	if !isLoopbackHost(host) {
+		http.Error(w, "forbidden host", http.StatusForbidden)
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !isLoopbackHost(u.Hostname()) {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return false
+		}
+	}
+
+	s.mutex.Lock()
+	token := s.token
+	s.mutex.Unlock()
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (s *ApiServer) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	methods := make([]*ApiMethod, 0, len(names))
+	for _, name := range names {
+		t := s.methods[name].Type()
+		methods = append(methods, &ApiMethod{
+			Name:    name,
+			Args:    typeNames(t.NumIn(), t.In),
+			Results: typeNames(t.NumOut(), t.Out),
+		})
+	}
+	writeJSON(w, http.StatusOK, methods)
+}
+
+func (s *ApiServer) handleCall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	// a form or text/plain post needs no preflight, json does
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/")
+	method, ok := s.methods[name]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, &ApiResponse{Error: "unknown method " + name})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, apiMaxBody)
+	var rawArgs []json.RawMessage
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&rawArgs); err != nil {
+			writeJSON(w, http.StatusBadRequest, &ApiResponse{Error: "invalid arguments: " + err.Error()})
+			return
+		}
+	}
+
+	t := method.Type()
+	if len(rawArgs) != t.NumIn() {
+		writeJSON(w, http.StatusBadRequest, &ApiResponse{Error: fmt.Sprintf("%s takes %d arguments, got %d", name, t.NumIn(), len(rawArgs))})
+		return
+	}
+
+	args := make([]reflect.Value, t.NumIn())
+	for i, raw := range rawArgs {
+		arg := reflect.New(t.In(i))
+		if err := json.Unmarshal(raw, arg.Interface()); err != nil {
+			writeJSON(w, http.StatusBadRequest, &ApiResponse{Error: fmt.Sprintf("invalid argument %d: %v", i, err)})
+			return
+		}
+		args[i] = arg.Elem()
+	}
+	for i, arg := range args {
+		if !isFilePathType(arg.Type()) {
+			continue
+		}
+		if err := s.rootArg(arg); err != nil {
+			writeJSON(w, http.StatusForbidden, &ApiResponse{Error: fmt.Sprintf("invalid argument %d: %v", i, err)})
+			return
+		}
+	}
+
+	resp := &ApiResponse{}
+	for i, out := range method.Call(args) {
+		if t.Out(i) == errorType {
+			if !out.IsNil() {
+				resp.Error = out.Interface().(error).Error()
+			}
+			continue
+		}
+		resp.Result = out.Interface()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// isFilePathType reports whether t is a FilePath or a slice of them.
+func isFilePathType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == filePathType
+}
+
+// rootArg replaces the path, or the paths, held by arg with their location
+// under the root of the server.
+func (s *ApiServer) rootArg(arg reflect.Value) error {
+	if arg.Kind() == reflect.Slice {
+		for i := 0; i < arg.Len(); i++ {
+			if err := s.rootArg(arg.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	path, err := s.rootPath(arg.String())
+	if err != nil {
+		return err
+	}
+	arg.SetString(path)
+	return nil
+}
+
+// rootPath resolves path, relative paths from the root, and checks that it
+// stays under the root once the symlinks are followed.
+func (s *ApiServer) rootPath(path string) (string, error) {
+	s.mutex.Lock()
+	root := s.root
+	s.mutex.Unlock()
+	if root == "" {
+		return "", errors.New("the api server has no root, it can't reach files")
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	// a file to be written doesn't exist yet, the links are followed up to
+	// its nearest existing directory
+	for dir, rest := path, ""; ; dir, rest = filepath.Dir(dir), filepath.Join(filepath.Base(dir), rest) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			path = filepath.Join(resolved, rest)
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the api root", path)
+	}
+	return path, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
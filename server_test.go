@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestApiMethodArgs checks that every App method is either exposed or
+// excluded, and that the plain string arguments of the exposed ones are the
+// listed ones, so a new file argument can't skip the root check. They must
+// return at most one value besides the error.
+func TestApiMethodArgs(t *testing.T) {
+	appType := reflect.TypeOf(&App{})
+	for i := 0; i < appType.NumMethod(); i++ {
+		name := appType.Method(i).Name
+		_, exposed := apiMethods[name]
+		if exposed == apiExcluded[name] {
+			t.Errorf("%s must be in exactly one of apiMethods and apiExcluded", name)
+		}
+	}
+
+	for name, plain := range apiMethods {
+		method, ok := appType.MethodByName(name)
+		if !ok {
+			t.Errorf("%s is not an App method", name)
+			continue
+		}
+
+		count := 0
+		// the first argument is the receiver
+		for i := 1; i < method.Type.NumIn(); i++ {
+			arg := method.Type.In(i)
+			if isFilePathType(arg) {
+				continue
+			}
+			if arg.Kind() == reflect.Slice {
+				arg = arg.Elem()
+			}
+			if arg.Kind() == reflect.String {
+				count++
+			}
+		}
+		if count != len(plain) {
+			t.Errorf("%s has %d string arguments which are not a FilePath, %v are listed", name, count, plain)
+		}
+		if n := apiValueResults(method.Type); n > 1 {
+			t.Errorf("%s has %d results besides the error, the response holds one", name, n)
+		}
+	}
+}
+
+func TestApiRootPath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(filepath.Join(root, "levels"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	if err = os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	s := &ApiServer{root: root}
+	tests := []struct {
+		path string
+		want string // empty when the path is rejected
+	}{
+		{path: "level.bin", want: filepath.Join(root, "level.bin")},
+		{path: "levels/new/level.bin", want: filepath.Join(root, "levels", "new", "level.bin")},
+		{path: filepath.Join(root, "levels", "level.bin"), want: filepath.Join(root, "levels", "level.bin")},
+		{path: "../level.bin"},
+		{path: "levels/../../level.bin"},
+		{path: filepath.Join(outside, "level.bin")},
+		{path: "link/level.bin"},
+	}
+	for _, test := range tests {
+		got, err := s.rootPath(test.path)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%s: got %s, want an error", test.path, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %s, %v, want %s", test.path, got, err, test.want)
+		}
+	}
+
+	paths := reflect.ValueOf(&[]FilePath{"a.obj", "../b.obj"}).Elem()
+	if err = s.rootArg(paths); err == nil {
+		t.Error("a slice with a path outside of the root is accepted")
+	}
+}